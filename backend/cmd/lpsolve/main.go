@@ -0,0 +1,353 @@
+// lpsolve solves a model in the LP language (e.g. "let x1 >= 0; max x1; s.t. x1 <= 5;") from a file or stdin.
+//
+//	lpsolve [-format table|json|csv] [-solver go|cpp] [-cpp-solver path] [-time-limit 10s] [-iteration-limit n] [-exact] [file]
+//
+// With -exact the model is folded and solved with rationals, and the values are also shown as fractions (e.g. 5/4).
+//
+// The exit code gives the result: 0 optimal, 2 infeasible, 3 unbounded, 4 error in the model,
+// 5 stopped at a limit (time, iteration or the branch-and-bound node limit) and 1 for any other error.
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/animalat/Simplex-Algorithm/backend/service/solve"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/linear"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/parse_sef"
+)
+
+const (
+	exitOptimal    = 0
+	exitError      = 1
+	exitInfeasible = 2
+	exitUnbounded  = 3
+	exitParseError = 4
+	exitLimit      = 5
+)
+
+const (
+	formatTable = "table"
+	formatJson  = "json"
+	formatCsv   = "csv"
+)
+
+func formatFloat(num float64) string {
+	return strconv.FormatFloat(num, 'g', -1, 64)
+}
+
+func exitCode(resultType string) int {
+	switch resultType {
+	case "optimal":
+		return exitOptimal
+	case "infeasible":
+		return exitInfeasible
+	case "unbounded":
+		return exitUnbounded
+	default:
+		return exitLimit
+	}
+}
+
+// Value of lp's objective at the solution (nil if there is no solution, or the objective is unbounded)
+func objectiveValue(lp *linear.LinearProgram, res *solve.SimplexResult) *float64 {
+	if len(res.Solution) != len(lp.Variables) || res.ResultType == "unbounded" {
+		return nil
+	}
+
+	value := lp.Objective.Expr.Constant
+	for i, v := range lp.Variables {
+		value += lp.Objective.Expr.Coefficients[v.Name] * res.Solution[i]
+	}
+	return &value
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func writeTable(w io.Writer, lp *linear.LinearProgram, res *solve.SimplexResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Result:\t%s\n", res.ResultType)
+	if value := objectiveValue(lp, res); value != nil {
+		fmt.Fprintf(tw, "Objective:\t%s\n", formatFloat(*value))
+	}
+	if res.Exact != nil && res.Exact.Objective != "" {
+		fmt.Fprintf(tw, "Exact objective:\t%s\n", res.Exact.Objective)
+	}
+	if res.Verification != nil {
+		fmt.Fprintf(tw, "Verified:\t%t\n", res.Verification.Verified)
+	}
+	if len(res.BasisNames) > 0 {
+		fmt.Fprintf(tw, "Basis:\t%s\n", strings.Join(res.BasisNames, " "))
+	}
+
+	if len(res.Solution) == len(lp.Variables) {
+		fmt.Fprintln(tw, "\nVariable\tValue"+exactHeader(res))
+		for i, v := range lp.Variables {
+			fmt.Fprintf(tw, "%s\t%s%s\n", v.Name, formatFloat(res.Solution[i]), exactColumn(res, exactSolution, i))
+		}
+	}
+
+	if res.Ray != nil {
+		fmt.Fprintf(tw, "\nRay rate:\t%s\n", formatFloat(res.Ray.Rate))
+		fmt.Fprintln(tw, "\nVariable\tDirection")
+		for _, v := range lp.Variables {
+			fmt.Fprintf(tw, "%s\t%s\n", v.Name, formatFloat(res.Ray.Direction[v.Name]))
+		}
+	}
+
+	if len(res.Duals) > 0 {
+		fmt.Fprintln(tw, "\nConstraint\tDual")
+		for _, name := range sortedKeys(res.Duals) {
+			fmt.Fprintf(tw, "%s\t%s\n", name, formatFloat(res.Duals[name]))
+		}
+	}
+
+	if len(res.IIS) > 0 {
+		fmt.Fprintln(tw, "\nConflicting constraint\tLine")
+		for _, c := range res.IIS {
+			fmt.Fprintf(tw, "%s\t%s\n", iisName(c), iisLine(c))
+		}
+	}
+
+	if len(res.Certificate) > 0 {
+		fmt.Fprintln(tw, "\nCertificate\tValue"+exactHeader(res))
+		for i, value := range res.Certificate {
+			fmt.Fprintf(tw, "%d\t%s%s\n", i, formatFloat(value), exactColumn(res, exactCertificate, i))
+		}
+	}
+
+	return tw.Flush()
+}
+
+const (
+	exactSolution    = "solution"
+	exactCertificate = "certificate"
+)
+
+// Header of the Exact column of the table, empty outside exact mode
+func exactHeader(res *solve.SimplexResult) string {
+	if res.Exact == nil {
+		return ""
+	}
+	return "\tExact"
+}
+
+// Entry i of the exact solution or certificate as a table column, empty outside exact mode
+func exactColumn(res *solve.SimplexResult, kind string, i int) string {
+	if res.Exact == nil {
+		return ""
+	}
+
+	values := res.Exact.Solution
+	if kind == exactCertificate {
+		values = res.Exact.Certificate
+	}
+	if i >= len(values) {
+		return "\t"
+	}
+	return "\t" + values[i]
+}
+
+// Name of a constraint of the IIS, its index if it has no name (like the duals)
+func iisName(c solve.IISConstraint) string {
+	if c.Name != "" {
+		return c.Name
+	}
+	if c.Range {
+		return strconv.Itoa(c.Index) + ".range"
+	}
+	return strconv.Itoa(c.Index)
+}
+
+// Source line of a constraint of the IIS, empty if it has none
+func iisLine(c solve.IISConstraint) string {
+	if c.Line == 0 {
+		return ""
+	}
+	return strconv.Itoa(c.Line)
+}
+
+// Writes rows of kind,name,value (kinds are result, objective, verified, basis, variable, rayRate, ray, dual, iis and certificate,
+// iis rows have the line of the constraint as their value). In exact mode there are also exactObjective, exactVariable
+// and exactCertificate rows with fractions.
+func writeCsv(w io.Writer, lp *linear.LinearProgram, res *solve.SimplexResult) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"kind", "name", "value"})
+	cw.Write([]string{"result", "", res.ResultType})
+	if value := objectiveValue(lp, res); value != nil {
+		cw.Write([]string{"objective", "", formatFloat(*value)})
+	}
+	if res.Verification != nil {
+		cw.Write([]string{"verified", "", strconv.FormatBool(res.Verification.Verified)})
+	}
+	for _, name := range res.BasisNames {
+		cw.Write([]string{"basis", name, ""})
+	}
+
+	if len(res.Solution) == len(lp.Variables) {
+		for i, v := range lp.Variables {
+			cw.Write([]string{"variable", v.Name, formatFloat(res.Solution[i])})
+		}
+	}
+	if res.Ray != nil {
+		cw.Write([]string{"rayRate", "", formatFloat(res.Ray.Rate)})
+		for _, v := range lp.Variables {
+			cw.Write([]string{"ray", v.Name, formatFloat(res.Ray.Direction[v.Name])})
+		}
+	}
+	for _, name := range sortedKeys(res.Duals) {
+		cw.Write([]string{"dual", name, formatFloat(res.Duals[name])})
+	}
+	for _, c := range res.IIS {
+		cw.Write([]string{"iis", iisName(c), iisLine(c)})
+	}
+	for i, value := range res.Certificate {
+		cw.Write([]string{"certificate", strconv.Itoa(i), formatFloat(value)})
+	}
+	if res.Exact != nil {
+		if res.Exact.Objective != "" {
+			cw.Write([]string{"exactObjective", "", res.Exact.Objective})
+		}
+		if len(res.Exact.Solution) == len(lp.Variables) {
+			for i, v := range lp.Variables {
+				cw.Write([]string{"exactVariable", v.Name, res.Exact.Solution[i]})
+			}
+		}
+		for i, value := range res.Exact.Certificate {
+			cw.Write([]string{"exactCertificate", strconv.Itoa(i), value})
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func readModel(path string, stdin io.Reader) ([]byte, error) {
+	if path == "" || path == "-" {
+		return io.ReadAll(stdin)
+	}
+
+	return os.ReadFile(path)
+}
+
+// Runs lpsolve with args (not including the program name), returning the exit code
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lpsolve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", formatTable, "output format: table, json or csv")
+	solverName := flags.String("solver", solve.DefaultSolverName, "solver: go or cpp")
+	cppSolverPath := flags.String("cpp-solver", os.Getenv(solve.CppSolverPathEnv), "path to the C++ simplex_solver binary (used with -solver cpp), defaults to $"+solve.CppSolverPathEnv)
+	var opts solve.SolveOptions
+	flags.DurationVar(&opts.TimeLimit, "time-limit", 0, "stop after this long, e.g. 10s (0 for no limit)")
+	flags.IntVar(&opts.IterationLimit, "iteration-limit", 0, "stop after this many simplex pivots (0 for no limit)")
+	flags.BoolVar(&opts.Exact, "exact", false, "fold and solve with rationals, also showing fractions (uses its own solver, so not with -solver cpp)")
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+	if opts.TimeLimit < 0 || opts.IterationLimit < 0 {
+		fmt.Fprintln(stderr, "lpsolve: limits can't be negative")
+		return exitError
+	}
+	if flags.NArg() > 1 {
+		fmt.Fprintln(stderr, "lpsolve: expected at most one model file")
+		return exitError
+	}
+	if opts.Exact && *solverName != solve.DefaultSolverName {
+		// exact mode has its own rational solver (as for /solve?exact=true)
+		fmt.Fprintf(stderr, "lpsolve: -exact can't be used with -solver %s\n", *solverName)
+		return exitError
+	}
+
+	var solver solve.Solver
+	switch *solverName {
+	case solve.DefaultSolverName:
+		solver = solve.GoSolver{}
+	case "cpp":
+		if *cppSolverPath == "" {
+			fmt.Fprintf(stderr, "lpsolve: -solver cpp needs -cpp-solver or $%s\n", solve.CppSolverPathEnv)
+			return exitError
+		}
+		solver = solve.CppSolver{Path: *cppSolverPath}
+	default:
+		fmt.Fprintf(stderr, "lpsolve: unknown solver %q\n", *solverName)
+		return exitError
+	}
+
+	var write func(io.Writer, *linear.LinearProgram, *solve.SimplexResult) error
+	switch *format {
+	case formatTable:
+		write = writeTable
+	case formatCsv:
+		write = writeCsv
+	case formatJson:
+		write = func(w io.Writer, _ *linear.LinearProgram, res *solve.SimplexResult) error {
+			return json.NewEncoder(w).Encode(res)
+		}
+	default:
+		fmt.Fprintf(stderr, "lpsolve: unknown format %q\n", *format)
+		return exitError
+	}
+
+	model, err := readModel(flags.Arg(0), stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "lpsolve: %v\n", err)
+		return exitError
+	}
+
+	// the time limit covers expanding the model too, as for /solve
+	ctx := context.Background()
+	if opts.TimeLimit > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.TimeLimit)
+		defer cancel()
+	}
+
+	lp, err := parse_sef.ParseSEFContext(ctx, string(model), opts.Exact)
+	if errors.Is(err, context.DeadlineExceeded) {
+		fmt.Fprintln(stderr, "lpsolve: time limit reached while expanding the model")
+		return exitLimit
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "lpsolve: %v\n", err)
+		var stageErr *parse_sef.StageError
+		if errors.As(err, &stageErr) {
+			return exitParseError
+		}
+		return exitError
+	}
+
+	res, err := solve.SolveProgram(ctx, solver, lp, opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "lpsolve: %v\n", err)
+		return exitError
+	}
+
+	if err := write(stdout, lp, res); err != nil {
+		fmt.Fprintf(stderr, "lpsolve: %v\n", err)
+		return exitError
+	}
+
+	return exitCode(res.ResultType)
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
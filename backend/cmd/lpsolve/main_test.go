@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/animalat/Simplex-Algorithm/backend/service/solve"
+)
+
+func runString(t *testing.T, input string, args ...string) (int, string, string) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(input), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_ExitCodes(t *testing.T) {
+	tests := []struct {
+		input string
+		code  int
+	}{
+		{"let x1 >= 0; max x1; s.t. x1 <= 5;", exitOptimal},
+		{"let x1 >= 0; max x1; s.t. x1 <= -1;", exitInfeasible},
+		{"let x1 >= 0; max x1; s.t. x1 >= 1;", exitUnbounded},
+		{"let x1 >= 0; max x1 s.t. x1 <= 5;", exitParseError},
+		{"let x1; max x1; s.t. x1 + y <= 5;", exitParseError},
+	}
+
+	for _, test := range tests {
+		if code, _, stderr := runString(t, test.input); code != test.code {
+			t.Errorf("%q: exit code %d, want %d (%s)", test.input, code, test.code, stderr)
+		}
+	}
+
+	input := "let x1 >= 0; let x2 >= 0; max x1 + 2 * x2; s.t. x1 + x2 <= 4; x2 <= 3;"
+	if code, out, _ := runString(t, input, "-iteration-limit", "1"); code != exitLimit || !strings.Contains(out, "iterationLimit") {
+		t.Errorf("iteration limit: exit code %d, want %d\n%s", code, exitLimit, out)
+	}
+
+	large := "let x[1..1000]; max x[1]; s.t. forall{i in 1..1000}: sum{j in 1..999} x[j] <= i;"
+	if code, _, stderr := runString(t, large, "-time-limit", "1ms"); code != exitLimit {
+		t.Errorf("time limit while expanding: exit code %d, want %d (%s)", code, exitLimit, stderr)
+	}
+
+	if code, _, _ := runString(t, "", "-format", "xml"); code != exitError {
+		t.Errorf("unknown format: exit code %d, want %d", code, exitError)
+	}
+
+	if code, out, stderr := runString(t, input, "-exact", "-solver", "cpp", "-cpp-solver", "simplex_solver"); code != exitError || out != "" || !strings.Contains(stderr, "-exact") {
+		t.Errorf("-exact with the C++ solver: exit code %d, want %d (%s)", code, exitError, stderr)
+	}
+
+	if code, _, stderr := runString(t, input, "-solver", "cpp", "-cpp-solver", ""); code != exitError || !strings.Contains(stderr, solve.CppSolverPathEnv) {
+		t.Errorf("C++ solver without a path: exit code %d, want %d (%s)", code, exitError, stderr)
+	}
+}
+
+func TestRun_Formats(t *testing.T) {
+	input := "let x1 >= 0; let x2 >= 0; max x1 + 2 * x2 + 1; s.t. cap: x1 + x2 <= 4; limit: x2 <= 3;"
+
+	_, table, _ := runString(t, input)
+	for _, want := range []string{"Result:     optimal", "Objective:  8", "x1        1", "x2        3", "cap         1"} {
+		if !strings.Contains(table, want) {
+			t.Errorf("table output missing %q:\n%s", want, table)
+		}
+	}
+
+	_, csv, _ := runString(t, input, "-format", "csv")
+	want := "kind,name,value\nresult,,optimal\nobjective,,8\nverified,,true\nvariable,x1,1\nvariable,x2,3\ndual,cap,1\ndual,limit,1\n"
+	if !strings.HasPrefix(csv, want) {
+		t.Errorf("csv output mismatch:\nGot:\n%s\nWant prefix:\n%s", csv, want)
+	}
+
+	_, out, _ := runString(t, input, "-format", "json")
+	var res solve.SimplexResult
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatalf("failed to decode json output: %v", err)
+	}
+	if res.ResultType != "optimal" || len(res.Solution) != 2 {
+		t.Errorf("json output mismatch: %+v", res)
+	}
+
+	exact := "let x1 >= 0; max x1 + 1 / 3; s.t. 4 * x1 <= 5;"
+	_, table, _ = runString(t, exact, "-exact")
+	for _, want := range []string{"Exact objective:  19/12", "x1        1.25   5/4", "0            0.25   1/4"} {
+		if !strings.Contains(table, want) {
+			t.Errorf("exact table output missing %q:\n%s", want, table)
+		}
+	}
+
+	_, csv, _ = runString(t, exact, "-exact", "-format", "csv")
+	for _, want := range []string{"exactObjective,,19/12\n", "exactVariable,x1,5/4\n", "exactCertificate,0,1/4\n"} {
+		if !strings.Contains(csv, want) {
+			t.Errorf("exact csv output missing %q:\n%s", want, csv)
+		}
+	}
+}
+
+func TestRun_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.lp")
+	if err := os.WriteFile(path, []byte("let x1 >= 0; min x1; s.t. x1 >= 2;"), 0o644); err != nil {
+		t.Fatalf("failed to write model: %v", err)
+	}
+
+	code, out, stderr := runString(t, "", "-format", "csv", path)
+	if code != exitOptimal || !strings.Contains(out, "objective,,2") {
+		t.Errorf("exit code %d, output:\n%s%s", code, out, stderr)
+	}
+
+	if code, _, _ := runString(t, "", filepath.Join(t.TempDir(), "missing.lp")); code != exitError {
+		t.Errorf("missing file: exit code %d, want %d", code, exitError)
+	}
+}
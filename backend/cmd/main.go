@@ -1,13 +1,23 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"net/http"
+	"os"
 
 	"github.com/animalat/Simplex-Algorithm/backend/service/solve"
 )
 
 func main() {
+	cppSolverPath := flag.String("cpp-solver", os.Getenv(solve.CppSolverPathEnv), "path to the C++ simplex_solver binary (used with /solve?solver=cpp), defaults to $"+solve.CppSolverPathEnv)
+	flag.Parse()
+
+	if *cppSolverPath == "" {
+		log.Printf("no C++ solver path (-cpp-solver or $%s), /solve?solver=cpp will fail", solve.CppSolverPathEnv)
+	}
+	solve.RegisterSolver("cpp", solve.CppSolver{Path: *cppSolverPath})
+
 	log.Println("Server starting...")
 	http.HandleFunc("/solve", solve.HandleSolve)
 
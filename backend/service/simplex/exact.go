@@ -0,0 +1,400 @@
+package simplex
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+)
+
+// ExactResult is Result with rational values, returned by TwoPhaseExact
+type ExactResult struct {
+	Type        ResultType
+	Solution    []*big.Rat
+	Certificate []*big.Rat
+	Basis       []int
+	Iterations  int
+	Value       *big.Rat
+}
+
+// program with rational values
+type exactProgram struct {
+	objective    []*big.Rat
+	constantTerm *big.Rat
+	lhs          [][]*big.Rat
+	rhs          []*big.Rat
+	numEnterable int
+}
+
+func zeroVector(size int) []*big.Rat {
+	vec := make([]*big.Rat, size)
+	for i := range vec {
+		vec[i] = new(big.Rat)
+	}
+
+	return vec
+}
+
+func exactSubMatrix(matrix [][]*big.Rat, basis []int) [][]*big.Rat {
+	sub := make([][]*big.Rat, len(matrix))
+	for y := range matrix {
+		sub[y] = make([]*big.Rat, len(basis))
+		for x, col := range basis {
+			sub[y][x] = new(big.Rat).Set(matrix[y][col])
+		}
+	}
+
+	return sub
+}
+
+// Gauss-Jordan elimination like inverse, any non-zero pivot will do since there is no rounding
+func exactInverse(matrix [][]*big.Rat) ([][]*big.Rat, error) {
+	size := len(matrix)
+	matrixCopy := exactSubMatrix(matrix, identityColumns(size))
+	result := make([][]*big.Rat, size)
+	for i := range result {
+		result[i] = zeroVector(size)
+		result[i][i].SetInt64(1)
+	}
+
+	term := new(big.Rat)
+	for x := 0; x < size; x++ {
+		if matrixCopy[x][x].Sign() == 0 {
+			swapped := false
+			for y := x + 1; y < size; y++ {
+				if matrixCopy[y][x].Sign() != 0 {
+					matrixCopy[x], matrixCopy[y] = matrixCopy[y], matrixCopy[x]
+					result[x], result[y] = result[y], result[x]
+					swapped = true
+					break
+				}
+			}
+
+			if !swapped {
+				return nil, errSingular
+			}
+		}
+
+		pivot := new(big.Rat).Inv(matrixCopy[x][x])
+		for i := 0; i < size; i++ {
+			matrixCopy[x][i].Mul(matrixCopy[x][i], pivot)
+			result[x][i].Mul(result[x][i], pivot)
+		}
+
+		for y := 0; y < size; y++ {
+			if y == x || matrixCopy[y][x].Sign() == 0 {
+				continue
+			}
+
+			factor := new(big.Rat).Set(matrixCopy[y][x])
+			for i := 0; i < size; i++ {
+				matrixCopy[y][i].Sub(matrixCopy[y][i], term.Mul(factor, matrixCopy[x][i]))
+				result[y][i].Sub(result[y][i], term.Mul(factor, result[x][i]))
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// The columns 0, ..., size - 1
+func identityColumns(size int) []int {
+	cols := make([]int, size)
+	for i := range cols {
+		cols[i] = i
+	}
+
+	return cols
+}
+
+// matrix * column (column j of other)
+func exactMultiplyColumn(matrix [][]*big.Rat, other [][]*big.Rat, j int) []*big.Rat {
+	res := zeroVector(len(matrix))
+	term := new(big.Rat)
+	for y := range matrix {
+		for k := range matrix[y] {
+			res[y].Add(res[y], term.Mul(matrix[y][k], other[k][j]))
+		}
+	}
+
+	return res
+}
+
+// matrix * vec
+func exactMultiplyVector(matrix [][]*big.Rat, vec []*big.Rat) []*big.Rat {
+	res := zeroVector(len(matrix))
+	term := new(big.Rat)
+	for y := range matrix {
+		for k := range matrix[y] {
+			res[y].Add(res[y], term.Mul(matrix[y][k], vec[k]))
+		}
+	}
+
+	return res
+}
+
+// row^T * matrix
+func exactMultiplyRow(row []*big.Rat, matrix [][]*big.Rat) []*big.Rat {
+	if len(matrix) == 0 {
+		return []*big.Rat{}
+	}
+
+	res := zeroVector(len(matrix[0]))
+	term := new(big.Rat)
+	for y := range matrix {
+		for x := range matrix[y] {
+			res[x].Add(res[x], term.Mul(row[y], matrix[y][x]))
+		}
+	}
+
+	return res
+}
+
+func exactDot(a []*big.Rat, b []*big.Rat) *big.Rat {
+	res := new(big.Rat)
+	term := new(big.Rat)
+	for i := range a {
+		res.Add(res, term.Mul(a[i], b[i]))
+	}
+
+	return res
+}
+
+// simplex with rational values, so no tolerances are needed
+func exactSimplex(p exactProgram, basis []int, l *limiter) (ExactResult, error) {
+	numCols := len(p.objective)
+
+	for {
+		basisInverse, err := exactInverse(exactSubMatrix(p.lhs, basis))
+		if err != nil {
+			return ExactResult{}, fmt.Errorf("invalid basis %v: %w", basis, err)
+		}
+
+		basicValues := exactMultiplyVector(basisInverse, p.rhs)
+		currentSolution := zeroVector(numCols)
+		for i, col := range basis {
+			currentSolution[col] = basicValues[i]
+		}
+		value := new(big.Rat).Add(p.constantTerm, exactDot(p.objective, currentSolution))
+
+		// y = c_B^T * A_B^{-1}
+		objectiveBasis := make([]*big.Rat, len(basis))
+		for i, col := range basis {
+			objectiveBasis[i] = p.objective[col]
+		}
+		y := exactMultiplyRow(objectiveBasis, basisInverse)
+
+		// Bland's rule
+		enteringVariableCol := -1
+		term := new(big.Rat)
+		for j := 0; j < p.numEnterable; j++ {
+			if inBasis(basis, j) {
+				continue
+			}
+
+			reducedCost := new(big.Rat).Set(p.objective[j])
+			for i := range p.lhs {
+				reducedCost.Sub(reducedCost, term.Mul(y[i], p.lhs[i][j]))
+			}
+			if reducedCost.Sign() > 0 {
+				enteringVariableCol = j
+				break
+			}
+		}
+
+		if enteringVariableCol == -1 {
+			return ExactResult{Type: Optimal, Solution: currentSolution, Certificate: y, Basis: basis, Value: value}, nil
+		}
+
+		enteringColumn := exactMultiplyColumn(basisInverse, p.lhs, enteringVariableCol)
+		var curMinValue *big.Rat
+		curMinIndex := -1
+		for i := range enteringColumn {
+			if enteringColumn[i].Sign() <= 0 {
+				continue
+			}
+
+			currentValue := new(big.Rat).Quo(basicValues[i], enteringColumn[i])
+			if curMinValue == nil || currentValue.Cmp(curMinValue) < 0 {
+				curMinIndex = i
+				curMinValue = currentValue
+			}
+		}
+
+		if curMinIndex == -1 {
+			certificateUnbounded := zeroVector(numCols)
+			certificateUnbounded[enteringVariableCol].SetInt64(1)
+			for i, col := range basis {
+				certificateUnbounded[col].Neg(enteringColumn[i])
+			}
+
+			return ExactResult{Type: Unbounded, Solution: currentSolution, Certificate: certificateUnbounded, Basis: basis, Value: value}, nil
+		}
+
+		limit, err := l.reached()
+		if err != nil {
+			return ExactResult{}, err
+		}
+		if limit != "" {
+			return ExactResult{Type: limit, Solution: currentSolution, Basis: basis, Value: value}, nil
+		}
+
+		basis = replaceInBasis(basis, curMinIndex, enteringVariableCol)
+		l.iterations++
+	}
+}
+
+// phaseI with rational values
+func exactPhaseI(constraintsLHS [][]*big.Rat, constraintsRHS []*big.Rat, l *limiter) (phaseIResult, []*big.Rat, error) {
+	numRows := len(constraintsLHS)
+	numCols := 0
+	if numRows > 0 {
+		numCols = len(constraintsLHS[0])
+	}
+
+	auxiliaryLHS := make([][]*big.Rat, numRows)
+	auxiliaryRHS := make([]*big.Rat, numRows)
+	rowSigns := make([]float64, numRows)
+	for y := range constraintsLHS {
+		rowSigns[y] = 1
+		// like phaseI, rows with a zero RHS are negated too
+		if constraintsRHS[y].Sign() <= 0 {
+			rowSigns[y] = -1
+		}
+		sign := big.NewRat(int64(rowSigns[y]), 1)
+
+		auxiliaryLHS[y] = zeroVector(numCols + numRows)
+		for x := range constraintsLHS[y] {
+			auxiliaryLHS[y][x].Mul(sign, constraintsLHS[y][x])
+		}
+		auxiliaryLHS[y][numCols+y].SetInt64(1)
+		auxiliaryRHS[y] = new(big.Rat).Mul(sign, constraintsRHS[y])
+	}
+
+	basis := make([]int, 0, numRows)
+	auxiliaryObjective := zeroVector(numCols + numRows)
+	for i := 0; i < numRows; i++ {
+		basis = append(basis, numCols+i)
+		auxiliaryObjective[numCols+i].SetInt64(-1)
+	}
+
+	aux := exactProgram{
+		objective:    auxiliaryObjective,
+		constantTerm: new(big.Rat),
+		lhs:          auxiliaryLHS,
+		rhs:          auxiliaryRHS,
+		numEnterable: numCols + numRows,
+	}
+	tempResult, err := exactSimplex(aux, basis, l)
+	if err != nil {
+		return phaseIResult{}, nil, err
+	}
+	basis = tempResult.Basis
+
+	if tempResult.Type != Optimal {
+		return phaseIResult{basis: basis, rowSigns: rowSigns, limit: tempResult.Type}, nil, nil
+	}
+
+	if tempResult.Value.Sign() < 0 {
+		certificate := make([]*big.Rat, numRows)
+		for i := range certificate {
+			certificate[i] = new(big.Rat).Mul(big.NewRat(int64(rowSigns[i]), 1), tempResult.Certificate[i])
+		}
+
+		return phaseIResult{feasible: false, basis: basis, rowSigns: rowSigns}, certificate, nil
+	}
+
+	// pivot out the artificial columns that can be
+	for {
+		basisInverse, err := exactInverse(exactSubMatrix(auxiliaryLHS, basis))
+		if err != nil {
+			return phaseIResult{}, nil, fmt.Errorf("invalid basis %v: %w", basis, err)
+		}
+
+		leaving, entering := -1, -1
+		for i, col := range basis {
+			if col < numCols {
+				continue
+			}
+
+			row := exactMultiplyRow(basisInverse[i], auxiliaryLHS)
+			for j := 0; j < numCols; j++ {
+				if !inBasis(basis, j) && row[j].Sign() != 0 {
+					entering = j
+					break
+				}
+			}
+
+			if entering != -1 {
+				leaving = i
+				break
+			}
+		}
+
+		if leaving == -1 {
+			break
+		}
+		basis = replaceInBasis(basis, leaving, entering)
+	}
+
+	return phaseIResult{feasible: true, basis: basis, rowSigns: rowSigns}, nil, nil
+}
+
+// TwoPhaseExact is TwoPhaseContext with rational values, for exact vertices and certificates.
+// It follows the same pivots as TwoPhase where TwoPhase's tolerances don't change a decision.
+func TwoPhaseExact(ctx context.Context, maxIterations int, objective []*big.Rat, constantTerm *big.Rat, constraintsLHS [][]*big.Rat, constraintsRHS []*big.Rat) (ExactResult, error) {
+	if len(constraintsLHS) != len(constraintsRHS) {
+		return ExactResult{}, fmt.Errorf("constraintsLHS height %d does not match constraintsRHS height %d", len(constraintsLHS), len(constraintsRHS))
+	}
+	for i, row := range constraintsLHS {
+		if len(row) != len(objective) {
+			return ExactResult{}, fmt.Errorf("constraintsLHS row %d has %d columns, objective has %d", i, len(row), len(objective))
+		}
+	}
+	l := &limiter{ctx: ctx, maxIterations: maxIterations}
+
+	phaseIRes, certificate, err := exactPhaseI(constraintsLHS, constraintsRHS, l)
+	if err != nil {
+		return ExactResult{}, err
+	}
+	if phaseIRes.limit != "" {
+		return ExactResult{Type: phaseIRes.limit, Basis: phaseIRes.basis, Iterations: l.iterations}, nil
+	}
+	if !phaseIRes.feasible {
+		return ExactResult{Type: Infeasible, Certificate: certificate, Basis: phaseIRes.basis, Iterations: l.iterations}, nil
+	}
+
+	numRows := len(constraintsLHS)
+	numCols := len(objective)
+	extendedLHS := make([][]*big.Rat, numRows)
+	for y := range constraintsLHS {
+		extendedLHS[y] = zeroVector(numCols + numRows)
+		for x := range constraintsLHS[y] {
+			extendedLHS[y][x].Set(constraintsLHS[y][x])
+		}
+		extendedLHS[y][numCols+y].SetInt64(int64(phaseIRes.rowSigns[y]))
+	}
+	extendedObjective := zeroVector(numCols + numRows)
+	for x := range objective {
+		extendedObjective[x].Set(objective[x])
+	}
+
+	p := exactProgram{
+		objective:    extendedObjective,
+		constantTerm: constantTerm,
+		lhs:          extendedLHS,
+		rhs:          constraintsRHS,
+		numEnterable: numCols,
+	}
+	res, err := exactSimplex(p, phaseIRes.basis, l)
+	if err != nil {
+		return ExactResult{}, err
+	}
+
+	res.Solution = res.Solution[:numCols]
+	if res.Type == Unbounded {
+		res.Certificate = res.Certificate[:numCols]
+	}
+	res.Iterations = l.iterations
+
+	return res, nil
+}
@@ -0,0 +1,98 @@
+package simplex
+
+import (
+	"context"
+	"math/big"
+	"testing"
+)
+
+func rats(nums ...string) []*big.Rat {
+	res := make([]*big.Rat, len(nums))
+	for i, num := range nums {
+		res[i], _ = new(big.Rat).SetString(num)
+	}
+
+	return res
+}
+
+func assertRats(t *testing.T, name string, got []*big.Rat, want []string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("%s wanted of length %d, received length %d", name, len(want), len(got))
+	}
+	for i := range want {
+		if got[i].RatString() != want[i] {
+			t.Fatalf("%s not equal at index %d: wanted %s, received %s", name, i, want[i], got[i].RatString())
+		}
+	}
+}
+
+func TestExact_Optimal(t *testing.T) {
+	// max 4x1 s.t. 4x1 <= 5, x1 >= 0 (x1 split into x1a - x1b)
+	A := [][]*big.Rat{
+		rats("4", "-4", "1", "0"),
+		rats("1", "-1", "0", "-1"),
+	}
+	res, err := TwoPhaseExact(context.Background(), 0, rats("4", "-4", "0", "0"), new(big.Rat), A, rats("5", "0"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.Type != Optimal {
+		t.Fatalf("expected result type %s, received %s", Optimal, res.Type)
+	}
+	assertRats(t, "solution", res.Solution, []string{"5/4", "0", "0", "5/4"})
+	assertRats(t, "certificate", res.Certificate, []string{"1", "0"})
+	if res.Value.RatString() != "5" {
+		t.Fatalf("expected objective value 5, received %s", res.Value.RatString())
+	}
+}
+
+func TestExact_Thirds(t *testing.T) {
+	// max x1 + x2 s.t. 3x1 + x3 = 1, 3x2 + x4 = 2, where floats only reach 1/3 and 2/3 approximately
+	A := [][]*big.Rat{
+		rats("3", "0", "1", "0"),
+		rats("0", "3", "0", "1"),
+	}
+	res, err := TwoPhaseExact(context.Background(), 0, rats("1", "1", "0", "0"), new(big.Rat), A, rats("1", "2"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assertRats(t, "solution", res.Solution, []string{"1/3", "2/3", "0", "0"})
+	assertRats(t, "certificate", res.Certificate, []string{"1/3", "1/3"})
+	if res.Value.RatString() != "1" {
+		t.Fatalf("expected objective value 1, received %s", res.Value.RatString())
+	}
+}
+
+func TestExact_MatchesTwoPhase(t *testing.T) {
+	// the unbounded and infeasible programs of TestSimplex_Unbounded and TestSimplex_Infeasible
+	A := [][]*big.Rat{
+		rats("5", "-5", "3", "-3", "0", "0", "0", "0", "1", "0"),
+		rats("1", "-1", "1", "-1", "3", "-3", "0", "0", "0", "-1"),
+	}
+	res, err := TwoPhaseExact(context.Background(), 0, rats("4", "-4", "1", "-1", "0", "0", "5", "-5", "0", "0"), big.NewRat(100, 1), A, rats("3", "5"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Type != Unbounded {
+		t.Fatalf("expected result type %s, received %s", Unbounded, res.Type)
+	}
+	assertRats(t, "solution", res.Solution, []string{"3/5", "0", "0", "0", "22/15", "0", "0", "0", "0", "0"})
+	assertRats(t, "certificate", res.Certificate, []string{"3/5", "0", "0", "1", "2/15", "0", "0", "0", "0", "0"})
+
+	res, err = TwoPhaseExact(context.Background(), 0, rats("1", "0"), new(big.Rat), [][]*big.Rat{rats("1", "1")}, rats("-1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Type != Infeasible || res.Solution != nil {
+		t.Fatalf("expected result type %s without a solution, received %s %v", Infeasible, res.Type, res.Solution)
+	}
+	assertRats(t, "certificate", res.Certificate, []string{"1"})
+
+	if _, err := TwoPhaseExact(context.Background(), 0, rats("1", "2"), new(big.Rat), [][]*big.Rat{rats("1")}, rats("1")); err == nil {
+		t.Fatalf("expected error for mismatched dimensions")
+	}
+}
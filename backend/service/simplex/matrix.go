@@ -0,0 +1,134 @@
+package simplex
+
+import (
+	"errors"
+	"math"
+)
+
+var errSingular = errors.New("matrix has no inverse")
+
+// Copies the columns of matrix listed in basis into a new (rows x len(basis)) matrix
+func subMatrix(matrix [][]float64, basis []int) [][]float64 {
+	sub := make([][]float64, len(matrix))
+	for y := range matrix {
+		sub[y] = make([]float64, len(basis))
+		for x, col := range basis {
+			sub[y][x] = matrix[y][col]
+		}
+	}
+
+	return sub
+}
+
+func identityMatrix(size int) [][]float64 {
+	identity := make([][]float64, size)
+	for i := range identity {
+		identity[i] = make([]float64, size)
+		identity[i][i] = 1
+	}
+
+	return identity
+}
+
+// Gauss-Jordan elimination, same approach as leftInverse in ExtraMatrixFunctions.cpp
+func inverse(matrix [][]float64) ([][]float64, error) {
+	size := len(matrix)
+	matrixCopy := make([][]float64, size)
+	for i := range matrix {
+		matrixCopy[i] = append([]float64(nil), matrix[i]...)
+	}
+	result := identityMatrix(size)
+
+	for x := 0; x < size; x++ {
+		// make sure matrixCopy[x][x] is non-zero
+		if math.Abs(matrixCopy[x][x]) < EPSILON {
+			swapped := false
+			for y := x + 1; y < size; y++ {
+				if math.Abs(matrixCopy[y][x]) >= EPSILON {
+					matrixCopy[x], matrixCopy[y] = matrixCopy[y], matrixCopy[x]
+					result[x], result[y] = result[y], result[x]
+					swapped = true
+					break
+				}
+			}
+
+			if !swapped {
+				return nil, errSingular
+			}
+		}
+
+		// normalize the pivot row
+		pivot := matrixCopy[x][x]
+		for i := 0; i < size; i++ {
+			matrixCopy[x][i] /= pivot
+			result[x][i] /= pivot
+		}
+
+		// row elimination
+		for y := 0; y < size; y++ {
+			if y == x {
+				continue
+			}
+
+			factor := matrixCopy[y][x]
+			if factor == 0 {
+				continue
+			}
+			for i := 0; i < size; i++ {
+				matrixCopy[y][i] -= factor * matrixCopy[x][i]
+				result[y][i] -= factor * result[x][i]
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// matrix * column (column j of other), i.e. A_j premultiplied by matrix
+func multiplyColumn(matrix [][]float64, other [][]float64, j int) []float64 {
+	res := make([]float64, len(matrix))
+	for y := range matrix {
+		for k := range matrix[y] {
+			res[y] += matrix[y][k] * other[k][j]
+		}
+	}
+
+	return res
+}
+
+// matrix * vec
+func multiplyVector(matrix [][]float64, vec []float64) []float64 {
+	res := make([]float64, len(matrix))
+	for y := range matrix {
+		for k := range matrix[y] {
+			res[y] += matrix[y][k] * vec[k]
+		}
+	}
+
+	return res
+}
+
+// row^T * matrix
+func multiplyRow(row []float64, matrix [][]float64) []float64 {
+	if len(matrix) == 0 {
+		return []float64{}
+	}
+
+	res := make([]float64, len(matrix[0]))
+	for y := range matrix {
+		for x := range matrix[y] {
+			res[x] += row[y] * matrix[y][x]
+		}
+	}
+
+	return res
+}
+
+func dot(a []float64, b []float64) float64 {
+	res := 0.0
+	for i := range a {
+		res += a[i] * b[i]
+	}
+
+	return res
+}
@@ -0,0 +1,225 @@
+package simplex
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+var errNotOptimal = errors.New("basis is not optimal")
+
+// Sensitivity answers how far an optimal basis stays optimal as the objective or the right hand side changes.
+// Columns at or past len(objective) in the basis are artificial (left from Phase I on redundant rows),
+// these are kept at 0 and never enter.
+type Sensitivity struct {
+	numCols int
+	lhs     [][]float64
+	basis   []int
+	// basisInverse is A_B^{-1}
+	basisInverse [][]float64
+	basicValues  []float64
+	reducedCosts []float64
+}
+
+// NewSensitivity prepares the sensitivity analysis of the SEF program for an optimal basis (e.g. Result.Basis)
+func NewSensitivity(objective []float64, constraintsLHS [][]float64, constraintsRHS []float64, basis []int) (*Sensitivity, error) {
+	if err := validate(objective, constraintsLHS, constraintsRHS); err != nil {
+		return nil, err
+	}
+	if len(basis) != len(constraintsLHS) {
+		return nil, errors.New("basis must have one column for each constraint")
+	}
+
+	basis = append([]int(nil), basis...)
+	sort.Ints(basis)
+
+	numRows := len(constraintsLHS)
+	numCols := len(objective)
+	extendedLHS := make([][]float64, numRows)
+	for y := range constraintsLHS {
+		extendedLHS[y] = make([]float64, numCols+numRows)
+		copy(extendedLHS[y], constraintsLHS[y])
+		extendedLHS[y][numCols+y] = 1
+	}
+
+	s := &Sensitivity{numCols: numCols, lhs: extendedLHS, basis: basis, basisInverse: [][]float64{}}
+	if numRows > 0 {
+		basisInverse, err := inverse(subMatrix(extendedLHS, basis))
+		if err != nil {
+			return nil, err
+		}
+		s.basisInverse = basisInverse
+	}
+
+	s.basicValues = multiplyVector(s.basisInverse, constraintsRHS)
+	for i, col := range basis {
+		if s.basicValues[i] < -EPSILON || (col >= numCols && math.Abs(s.basicValues[i]) > EPSILON) {
+			return nil, errNotOptimal
+		}
+	}
+
+	// c - y^TA where y = c_B^T * A_B^{-1}
+	objectiveBasis := make([]float64, numRows)
+	for i, col := range basis {
+		if col < numCols {
+			objectiveBasis[i] = objective[col]
+		}
+	}
+	y := multiplyRow(objectiveBasis, s.basisInverse)
+	s.reducedCosts = make([]float64, numCols)
+	for j := range objective {
+		s.reducedCosts[j] = objective[j]
+		for i := range constraintsLHS {
+			s.reducedCosts[j] -= y[i] * constraintsLHS[i][j]
+		}
+		if inBasis(basis, j) {
+			s.reducedCosts[j] = 0
+		} else if s.reducedCosts[j] > EPSILON {
+			return nil, errNotOptimal
+		}
+	}
+
+	return s, nil
+}
+
+// ReducedCosts is c - y^TA for every (non-artificial) column, 0 for basic columns
+func (s *Sensitivity) ReducedCosts() []float64 {
+	return s.reducedCosts
+}
+
+// ObjectiveRange gives [lower, upper] such that the basis stays optimal for objective c + t * direction
+// whenever lower <= t <= upper (lower <= 0 <= upper, either may be infinite)
+func (s *Sensitivity) ObjectiveRange(direction []float64) (float64, float64) {
+	directionBasis := make([]float64, len(s.basis))
+	for i, col := range s.basis {
+		if col < s.numCols {
+			directionBasis[i] = direction[col]
+		}
+	}
+	// reduced costs change by direction - (direction_B^T * A_B^{-1}) A
+	yDirection := multiplyRow(directionBasis, s.basisInverse)
+
+	lower, upper := math.Inf(-1), math.Inf(1)
+	for j := 0; j < s.numCols; j++ {
+		if inBasis(s.basis, j) {
+			continue
+		}
+
+		change := direction[j]
+		for i := range s.lhs {
+			change -= yDirection[i] * s.lhs[i][j]
+		}
+
+		// reducedCost + t * change <= 0
+		switch {
+		case change > EPSILON:
+			upper = math.Min(upper, -s.reducedCosts[j]/change)
+		case change < -EPSILON:
+			lower = math.Max(lower, -s.reducedCosts[j]/change)
+		}
+	}
+
+	return math.Min(lower, 0), math.Max(upper, 0)
+}
+
+// RHSRange gives [lower, upper] such that the basis stays feasible (so optimal) for right hand side b + t * direction
+// whenever lower <= t <= upper (lower <= 0 <= upper, either may be infinite)
+func (s *Sensitivity) RHSRange(direction []float64) (float64, float64) {
+	change := multiplyVector(s.basisInverse, direction)
+
+	lower, upper := math.Inf(-1), math.Inf(1)
+	for i, col := range s.basis {
+		if col >= s.numCols {
+			// artificial columns must stay at 0
+			if math.Abs(change[i]) > EPSILON {
+				return 0, 0
+			}
+			continue
+		}
+
+		// basicValue + t * change >= 0
+		switch {
+		case change[i] > EPSILON:
+			lower = math.Max(lower, -s.basicValues[i]/change[i])
+		case change[i] < -EPSILON:
+			upper = math.Min(upper, -s.basicValues[i]/change[i])
+		}
+	}
+
+	return math.Min(lower, 0), math.Max(upper, 0)
+}
+
+// BasisFromSolution finds a basis for a basic solution x of Ax = b (for solvers that don't report their basis).
+// Columns with x_j > 0 come first, then other columns are added in order until the basis is full,
+// using artificial columns (see Sensitivity) for redundant rows.
+func BasisFromSolution(constraintsLHS [][]float64, solution []float64) ([]int, error) {
+	numRows := len(constraintsLHS)
+	if numRows == 0 {
+		return []int{}, nil
+	}
+	numCols := len(constraintsLHS[0])
+	if len(solution) != numCols {
+		return nil, errors.New("solution size must match constraint columns")
+	}
+
+	var basis []int
+	chosen := make(map[int]bool)
+	// reduced holds the chosen columns, reduced against each other (to check new columns are independent)
+	var reduced [][]float64
+	tryColumn := func(col int) {
+		if len(basis) == numRows || chosen[col] {
+			return
+		}
+
+		v := make([]float64, numRows)
+		for y := range constraintsLHS {
+			if col < numCols {
+				v[y] = constraintsLHS[y][col]
+			} else if y == col-numCols {
+				v[y] = 1
+			}
+		}
+		for _, u := range reduced {
+			pivot := pivotIndex(u)
+			factor := v[pivot] / u[pivot]
+			for y := range v {
+				v[y] -= factor * u[y]
+			}
+		}
+		if pivotIndex(v) == -1 {
+			return
+		}
+
+		reduced = append(reduced, v)
+		basis = append(basis, col)
+		chosen[col] = true
+	}
+
+	for j, val := range solution {
+		if val > EPSILON {
+			tryColumn(j)
+		}
+	}
+	for j := 0; j < numCols+numRows; j++ {
+		tryColumn(j)
+	}
+
+	if len(basis) != numRows {
+		return nil, errSingular
+	}
+	sort.Ints(basis)
+
+	return basis, nil
+}
+
+// Index of the entry of v largest in absolute value, or -1 if v is 0
+func pivotIndex(v []float64) int {
+	idx := -1
+	for i, val := range v {
+		if math.Abs(val) > EPSILON && (idx == -1 || math.Abs(val) > math.Abs(v[idx])) {
+			idx = i
+		}
+	}
+
+	return idx
+}
@@ -0,0 +1,78 @@
+package simplex
+
+import (
+	"math"
+	"testing"
+)
+
+func assertRange(t *testing.T, name string, lower float64, upper float64, lowerWanted float64, upperWanted float64) {
+	t.Helper()
+
+	equal := func(a float64, b float64) bool {
+		return (math.IsInf(a, 0) && a == b) || math.Abs(a-b) < PRECISIONERROR
+	}
+	if !equal(lower, lowerWanted) || !equal(upper, upperWanted) {
+		t.Fatalf("%s range wanted [%.4f, %.4f], received [%.4f, %.4f]", name, lowerWanted, upperWanted, lower, upper)
+	}
+}
+
+func TestSensitivity_Ranges(t *testing.T) {
+	// max 3x1 + 5x2 s.t. x1 <= 4, 2x2 <= 12, 3x1 + 2x2 <= 18, optimal at (2, 6)
+	c := []float64{3, 5, 0, 0, 0}
+	A := [][]float64{
+		{1, 0, 1, 0, 0},
+		{0, 2, 0, 1, 0},
+		{3, 2, 0, 0, 1},
+	}
+	b := []float64{4, 12, 18}
+	res := assertTwoPhase(t, c, 0, A, b, Optimal, []float64{2, 6, 2, 0, 0}, []float64{0, 1.5, 1})
+
+	s, err := NewSensitivity(c, A, b, res.Basis)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertVector(t, "reduced costs", s.ReducedCosts(), []float64{0, 0, 0, -1.5, -1})
+
+	lower, upper := s.ObjectiveRange([]float64{1, 0, 0, 0, 0})
+	assertRange(t, "c1", lower, upper, -3, 4.5)
+	lower, upper = s.ObjectiveRange([]float64{0, 1, 0, 0, 0})
+	assertRange(t, "c2", lower, upper, -3, math.Inf(1))
+	lower, upper = s.ObjectiveRange([]float64{0, 0, 0, 1, 0})
+	assertRange(t, "c4", lower, upper, math.Inf(-1), 1.5)
+
+	lower, upper = s.RHSRange([]float64{1, 0, 0})
+	assertRange(t, "b1", lower, upper, -2, math.Inf(1))
+	lower, upper = s.RHSRange([]float64{0, 1, 0})
+	assertRange(t, "b2", lower, upper, -6, 6)
+	lower, upper = s.RHSRange([]float64{0, 0, 1})
+	assertRange(t, "b3", lower, upper, -6, 6)
+
+	if _, err := NewSensitivity(c, A, b, []int{2, 3, 4}); err == nil {
+		t.Fatalf("expected error for a basis that is not optimal")
+	}
+}
+
+func TestSensitivity_BasisFromSolution(t *testing.T) {
+	A := [][]float64{
+		{1, 0, 1, 0, 0},
+		{0, 2, 0, 1, 0},
+		{3, 2, 0, 0, 1},
+	}
+
+	basis, err := BasisFromSolution(A, []float64{2, 6, 2, 0, 0})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(basis) != 3 || basis[0] != 0 || basis[1] != 1 || basis[2] != 2 {
+		t.Fatalf("expected basis [0 1 2], received %v", basis)
+	}
+
+	// the second row is redundant, so an artificial column fills the basis
+	basis, err = BasisFromSolution([][]float64{{1, 1}, {2, 2}}, []float64{1, 0})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(basis) != 2 || basis[0] != 0 || basis[1] < 2 {
+		t.Fatalf("expected basis with column 0 and an artificial column, received %v", basis)
+	}
+}
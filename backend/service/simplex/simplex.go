@@ -0,0 +1,477 @@
+package simplex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+const EPSILON = 1e-9
+
+// Represents the corresponding possible outcomes for a linear program
+// (Fundamental Theorem of Linear Programming)
+type ResultType string
+
+const (
+	Optimal    ResultType = "optimal"
+	Unbounded  ResultType = "unbounded"
+	Infeasible ResultType = "infeasible"
+	// The algorithm was stopped early (see TwoPhaseContext)
+	TimeLimit      ResultType = "timeLimit"
+	IterationLimit ResultType = "iterationLimit"
+)
+
+// Result is used to return the outcome
+type Result struct {
+	Type ResultType
+	// Solution is the optimal solution if Optimal,
+	// feasible (r) if Unbounded,
+	// and empty if Infeasible.
+	// If stopped at a limit it is the basic feasible solution reached in Phase II (empty if stopped in Phase I).
+	Solution []float64
+	// Certificate is y if Optimal (s.t. (c - y^TA) <= 0),
+	// d (s.t. Ad = 0, d >= 0, c^Td > 0) if Unbounded,
+	// y s.t. y^TA >= 0 but y^Tb < 0 if Infeasible,
+	// and empty if stopped at a limit.
+	Certificate []float64
+	// Basis is the final basis (sorted column indices). If stopped at a limit it is the basis reached, where columns
+	// from len(objective) on are the artificial columns of each row.
+	Basis []int
+	// Iterations is the number of pivots (over both phases)
+	Iterations int
+	// Value is the objective value (c^Tx + z) of Solution
+	Value float64
+}
+
+// Step is the tableau at one pivot of TwoPhaseTrace (or at the end of a phase)
+type Step struct {
+	// Phase is 1 or 2
+	Phase int
+	// Basis are the basic columns in the order of the tableau's rows. Both phases have the columns of the program,
+	// then an artificial column for each row (from len(objective) on).
+	Basis []int
+	// Entering is the column entering the basis, -1 if the phase ended optimal
+	Entering int
+	// Leaving is the column leaving the basis, -1 if none does (the phase ended)
+	Leaving int
+	// Ratios are the ratio test values (b_i / a_i for the entering column) of each row,
+	// nil for rows that don't limit it and for pivots that drive artificial columns out after Phase I
+	Ratios []*float64
+	// Tableau is A_B^{-1}[A | b], its last column is the value of each basic column
+	Tableau [][]float64
+	// ReducedCosts are c - y^TA for each column
+	ReducedCosts []float64
+	// Value is the objective value of the phase (for Phase I, minus the sum of the artificial columns)
+	Value float64
+}
+
+// Records the steps of TwoPhaseTrace, a nil tracer records nothing
+type tracer struct {
+	phase int
+	steps []Step
+}
+
+// Records the tableau of p for basis (with inverse basisInverse) and the pivot chosen
+func (t *tracer) record(p program, basis []int, basisInverse [][]float64, entering int, leaving int, ratios []*float64) {
+	if t == nil {
+		return
+	}
+
+	basicValues := multiplyVector(basisInverse, p.rhs)
+	tableau := make([][]float64, len(basis))
+	for i := range basis {
+		tableau[i] = append(multiplyRow(basisInverse[i], p.lhs), basicValues[i])
+	}
+
+	objectiveBasis := make([]float64, len(basis))
+	for i, col := range basis {
+		objectiveBasis[i] = p.objective[col]
+	}
+	y := multiplyRow(objectiveBasis, basisInverse)
+	reducedCosts := append([]float64(nil), p.objective...)
+	for j := range reducedCosts {
+		for i := range p.lhs {
+			reducedCosts[j] -= y[i] * p.lhs[i][j]
+		}
+	}
+
+	t.steps = append(t.steps, Step{
+		Phase:        t.phase,
+		Basis:        append([]int(nil), basis...),
+		Entering:     entering,
+		Leaving:      leaving,
+		Ratios:       ratios,
+		Tableau:      tableau,
+		ReducedCosts: reducedCosts,
+		Value:        p.constantTerm + dot(objectiveBasis, basicValues),
+	})
+}
+
+// A linear program in standard equality form (SEF):
+//
+//	maximize    c^Tx + z
+//	subject to  Ax = b
+//	            x >= 0
+type program struct {
+	objective    []float64
+	constantTerm float64
+	lhs          [][]float64
+	rhs          []float64
+	// only columns [0, numEnterable) may enter the basis (the rest are artificial)
+	numEnterable int
+}
+
+// Stops the algorithm early, counting the pivots made over both phases
+type limiter struct {
+	ctx context.Context
+	// maxIterations is the most pivots to make, 0 for no limit
+	maxIterations int
+	iterations    int
+}
+
+// Returns the limit reached (or "" if none has been). A cancelled ctx is returned as an error, not a limit.
+func (l *limiter) reached() (ResultType, error) {
+	if err := l.ctx.Err(); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return TimeLimit, nil
+		}
+		return "", err
+	}
+	if l.maxIterations > 0 && l.iterations >= l.maxIterations {
+		return IterationLimit, nil
+	}
+
+	return "", nil
+}
+
+func validate(objective []float64, constraintsLHS [][]float64, constraintsRHS []float64) error {
+	if len(constraintsLHS) != len(constraintsRHS) {
+		return fmt.Errorf("constraintsLHS height %d does not match constraintsRHS height %d", len(constraintsLHS), len(constraintsRHS))
+	}
+
+	for i, row := range constraintsLHS {
+		if len(row) != len(objective) {
+			return fmt.Errorf("constraintsLHS row %d has %d columns, objective has %d", i, len(row), len(objective))
+		}
+	}
+
+	return nil
+}
+
+func inBasis(basis []int, col int) bool {
+	idx := sort.SearchInts(basis, col)
+	return idx < len(basis) && basis[idx] == col
+}
+
+// Removes basis[leaving] and inserts entering in sorted order
+func replaceInBasis(basis []int, leaving int, entering int) []int {
+	basis = append(basis[:leaving], basis[leaving+1:]...)
+	idx := sort.SearchInts(basis, entering)
+	basis = append(basis, 0)
+	copy(basis[idx+1:], basis[idx:])
+	basis[idx] = entering
+	return basis
+}
+
+// Runs the simplex algorithm (Phase II) given a feasible basis, using Bland's rule.
+// basis must be sorted, and is updated to the final basis used.
+// If a limit is reached it returns the current basic feasible solution with that limit as its type.
+// Each pivot (and the final tableau) is recorded by t.
+func simplex(p program, basis []int, l *limiter, t *tracer) (Result, error) {
+	numCols := len(p.objective)
+
+	for {
+		basisInverse, err := inverse(subMatrix(p.lhs, basis))
+		if err != nil {
+			return Result{}, fmt.Errorf("invalid basis %v: %w", basis, err)
+		}
+
+		// Set new basic feasible solution
+		basicValues := multiplyVector(basisInverse, p.rhs)
+		currentSolution := make([]float64, numCols)
+		for i, col := range basis {
+			currentSolution[col] = basicValues[i]
+		}
+		value := p.constantTerm + dot(p.objective, currentSolution)
+
+		// y = c_B^T * A_B^{-1}
+		objectiveBasis := make([]float64, len(basis))
+		for i, col := range basis {
+			objectiveBasis[i] = p.objective[col]
+		}
+		y := multiplyRow(objectiveBasis, basisInverse)
+
+		// Find first positive element in the reduced objective function (Bland's rule)
+		enteringVariableCol := -1
+		for j := 0; j < p.numEnterable; j++ {
+			if inBasis(basis, j) {
+				continue
+			}
+
+			reducedCost := p.objective[j]
+			for i := range p.lhs {
+				reducedCost -= y[i] * p.lhs[i][j]
+			}
+			if reducedCost > EPSILON {
+				enteringVariableCol = j
+				break
+			}
+		}
+
+		if enteringVariableCol == -1 {
+			// We've found an optimal solution
+			t.record(p, basis, basisInverse, -1, -1, nil)
+			return Result{Type: Optimal, Solution: currentSolution, Certificate: y, Basis: basis, Value: value}, nil
+		}
+
+		// leaving variable is curMinIndex
+		enteringColumn := multiplyColumn(basisInverse, p.lhs, enteringVariableCol)
+		curMinValue := math.Inf(1)
+		const unboundedIndex = -1
+		curMinIndex := unboundedIndex
+		var ratios []*float64
+		if t != nil {
+			ratios = make([]*float64, len(basis))
+		}
+		for i := range enteringColumn {
+			if enteringColumn[i] < EPSILON {
+				continue
+			}
+
+			// rows are visited in basis order, so ties keep the smallest index (Bland's rule)
+			currentValue := basicValues[i] / enteringColumn[i]
+			if ratios != nil {
+				ratios[i] = &currentValue
+			}
+			if currentValue < curMinValue-EPSILON {
+				curMinIndex = i
+				curMinValue = currentValue
+			}
+		}
+
+		if curMinIndex == unboundedIndex {
+			t.record(p, basis, basisInverse, enteringVariableCol, -1, ratios)
+
+			// Unbounded case, the certificate is -t*A_{enteringVariableCol} on the basis
+			certificateUnbounded := make([]float64, numCols)
+			certificateUnbounded[enteringVariableCol] = 1
+			for i, col := range basis {
+				entry := enteringColumn[i]
+				if math.Abs(entry) < EPSILON {
+					entry = 0
+				}
+				certificateUnbounded[col] = -entry
+			}
+
+			return Result{Type: Unbounded, Solution: currentSolution, Certificate: certificateUnbounded, Basis: basis, Value: value}, nil
+		}
+
+		limit, err := l.reached()
+		if err != nil {
+			return Result{}, err
+		}
+		if limit != "" {
+			return Result{Type: limit, Solution: currentSolution, Basis: basis, Value: value}, nil
+		}
+
+		t.record(p, basis, basisInverse, enteringVariableCol, basis[curMinIndex], ratios)
+		basis = replaceInBasis(basis, curMinIndex, enteringVariableCol)
+		l.iterations++
+	}
+}
+
+type phaseIResult struct {
+	feasible bool
+	// basis over the columns of auxiliaryLHS (artificial columns may remain on redundant rows)
+	basis []int
+	// rowSigns[i] is -1 if row i was negated to make the RHS nonnegative
+	rowSigns []float64
+	// certificate is y if infeasible, otherwise not meaningful
+	certificate []float64
+	// limit is the limit Phase I stopped at ("" if it finished)
+	limit ResultType
+}
+
+// Phase I of the algorithm (determine a feasible basis, or a certificate of infeasibility)
+func phaseI(constraintsLHS [][]float64, constraintsRHS []float64, l *limiter, t *tracer) (phaseIResult, error) {
+	numRows := len(constraintsLHS)
+	numCols := 0
+	if numRows > 0 {
+		numCols = len(constraintsLHS[0])
+	}
+
+	// auxiliaryLHS will be augmented matrix in form [A | I], rows multiplied s.t. auxiliaryRHS >= 0
+	auxiliaryLHS := make([][]float64, numRows)
+	auxiliaryRHS := make([]float64, numRows)
+	rowSigns := make([]float64, numRows)
+	for y := range constraintsLHS {
+		rowSigns[y] = 1
+		if constraintsRHS[y] < EPSILON {
+			rowSigns[y] = -1
+		}
+
+		auxiliaryLHS[y] = make([]float64, numCols+numRows)
+		for x := range constraintsLHS[y] {
+			auxiliaryLHS[y][x] = rowSigns[y] * constraintsLHS[y][x]
+		}
+		auxiliaryLHS[y][numCols+y] = 1
+		auxiliaryRHS[y] = rowSigns[y] * constraintsRHS[y]
+	}
+
+	// Populate basis with the auxiliary variables, and the auxiliary objective -1 on each
+	basis := make([]int, 0, numRows)
+	auxiliaryObjective := make([]float64, numCols+numRows)
+	for i := 0; i < numRows; i++ {
+		basis = append(basis, numCols+i)
+		auxiliaryObjective[numCols+i] = -1
+	}
+
+	aux := program{
+		objective:    auxiliaryObjective,
+		lhs:          auxiliaryLHS,
+		rhs:          auxiliaryRHS,
+		numEnterable: numCols + numRows,
+	}
+	tempResult, err := simplex(aux, basis, l, t)
+	if err != nil {
+		return phaseIResult{}, err
+	}
+	basis = tempResult.Basis
+
+	if tempResult.Type != Optimal {
+		return phaseIResult{basis: basis, rowSigns: rowSigns, limit: tempResult.Type}, nil
+	}
+
+	if tempResult.Value < -EPSILON {
+		// y certifies infeasibility of the row-negated system, undo the negation for the original
+		certificate := make([]float64, numRows)
+		for i := range certificate {
+			certificate[i] = rowSigns[i] * tempResult.Certificate[i]
+		}
+
+		return phaseIResult{feasible: false, basis: basis, rowSigns: rowSigns, certificate: certificate}, nil
+	}
+
+	// Feasible, but degenerate artificial variables may remain in the basis. Pivot them out where possible,
+	// the ones that can't be pivoted out sit on redundant rows and stay at zero.
+	for {
+		basisInverse, err := inverse(subMatrix(auxiliaryLHS, basis))
+		if err != nil {
+			return phaseIResult{}, fmt.Errorf("invalid basis %v: %w", basis, err)
+		}
+
+		leaving, entering := -1, -1
+		for i, col := range basis {
+			if col < numCols {
+				continue
+			}
+
+			row := multiplyRow(basisInverse[i], auxiliaryLHS)
+			for j := 0; j < numCols; j++ {
+				if !inBasis(basis, j) && math.Abs(row[j]) > EPSILON {
+					entering = j
+					break
+				}
+			}
+
+			if entering != -1 {
+				leaving = i
+				break
+			}
+		}
+
+		if leaving == -1 {
+			break
+		}
+		t.record(aux, basis, basisInverse, entering, basis[leaving], nil)
+		basis = replaceInBasis(basis, leaving, entering)
+	}
+
+	return phaseIResult{feasible: true, basis: basis, rowSigns: rowSigns}, nil
+}
+
+// TwoPhase runs the 2-Phase algorithm on an LP in SEF (maximize c^Tx + z s.t. Ax = b, x >= 0).
+// It mirrors twoPhase in simplex_core/simplex/Simplex.cpp.
+func TwoPhase(objective []float64, constantTerm float64, constraintsLHS [][]float64, constraintsRHS []float64) (Result, error) {
+	return TwoPhaseContext(context.Background(), 0, objective, constantTerm, constraintsLHS, constraintsRHS)
+}
+
+// TwoPhaseContext is TwoPhase, stopped early with TimeLimit once ctx's deadline passes,
+// or with IterationLimit after maxIterations pivots (0 for no limit). If ctx is cancelled, its error is returned.
+func TwoPhaseContext(ctx context.Context, maxIterations int, objective []float64, constantTerm float64, constraintsLHS [][]float64, constraintsRHS []float64) (Result, error) {
+	return twoPhase(ctx, maxIterations, nil, objective, constantTerm, constraintsLHS, constraintsRHS)
+}
+
+// TwoPhaseTrace is TwoPhaseContext, also giving the tableau at every pivot of both phases (see Step).
+// The simplex method of each phase ends with a step of its final tableau, unless it stopped at a limit
+// (after Phase I, steps may follow that pivot artificial columns out of the basis).
+func TwoPhaseTrace(ctx context.Context, maxIterations int, objective []float64, constantTerm float64, constraintsLHS [][]float64, constraintsRHS []float64) (Result, []Step, error) {
+	t := &tracer{}
+	res, err := twoPhase(ctx, maxIterations, t, objective, constantTerm, constraintsLHS, constraintsRHS)
+	if err != nil {
+		return Result{}, nil, err
+	}
+
+	return res, t.steps, nil
+}
+
+func twoPhase(ctx context.Context, maxIterations int, t *tracer, objective []float64, constantTerm float64, constraintsLHS [][]float64, constraintsRHS []float64) (Result, error) {
+	if err := validate(objective, constraintsLHS, constraintsRHS); err != nil {
+		return Result{}, err
+	}
+	l := &limiter{ctx: ctx, maxIterations: maxIterations}
+
+	// Run Phase I
+	if t != nil {
+		t.phase = 1
+	}
+	phaseIRes, err := phaseI(constraintsLHS, constraintsRHS, l, t)
+	if err != nil {
+		return Result{}, err
+	}
+	if phaseIRes.limit != "" {
+		// no feasible solution has been found yet
+		return Result{Type: phaseIRes.limit, Basis: phaseIRes.basis, Iterations: l.iterations}, nil
+	}
+	if !phaseIRes.feasible {
+		// Infeasible case, solution is not meaningful
+		return Result{Type: Infeasible, Certificate: phaseIRes.certificate, Basis: phaseIRes.basis, Iterations: l.iterations}, nil
+	}
+
+	// Run Phase II, artificial columns that stayed in the basis are kept (but never re-enter)
+	numRows := len(constraintsLHS)
+	numCols := len(objective)
+	extendedLHS := make([][]float64, numRows)
+	for y := range constraintsLHS {
+		extendedLHS[y] = make([]float64, numCols+numRows)
+		copy(extendedLHS[y], constraintsLHS[y])
+		extendedLHS[y][numCols+y] = phaseIRes.rowSigns[y]
+	}
+	extendedObjective := make([]float64, numCols+numRows)
+	copy(extendedObjective, objective)
+
+	p := program{
+		objective:    extendedObjective,
+		constantTerm: constantTerm,
+		lhs:          extendedLHS,
+		rhs:          constraintsRHS,
+		numEnterable: numCols,
+	}
+	if t != nil {
+		t.phase = 2
+	}
+	res, err := simplex(p, phaseIRes.basis, l, t)
+	if err != nil {
+		return Result{}, err
+	}
+
+	res.Solution = res.Solution[:numCols]
+	if res.Type == Unbounded {
+		res.Certificate = res.Certificate[:numCols]
+	}
+	res.Iterations = l.iterations
+
+	return res, nil
+}
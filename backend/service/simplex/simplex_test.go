@@ -0,0 +1,208 @@
+package simplex
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+const PRECISIONERROR = 1e-6
+
+func assertVector(t *testing.T, name string, got []float64, want []float64) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("%s wanted of length %d, received length %d", name, len(want), len(got))
+	}
+
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > PRECISIONERROR {
+			t.Fatalf("%s not equal at index %d: wanted %.4f, received %.4f", name, i, want[i], got[i])
+		}
+	}
+}
+
+func assertTwoPhase(t *testing.T, c []float64, z float64, A [][]float64, b []float64, typeWanted ResultType, solutionWanted []float64, certificateWanted []float64) Result {
+	t.Helper()
+
+	res, err := TwoPhase(c, z, A, b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.Type != typeWanted {
+		t.Fatalf("expected result type %s, received %s", typeWanted, res.Type)
+	}
+
+	if typeWanted != Infeasible {
+		assertVector(t, "solution", res.Solution, solutionWanted)
+	}
+	assertVector(t, "certificate", res.Certificate, certificateWanted)
+
+	return res
+}
+
+func TestSimplex_Optimal(t *testing.T) {
+	// max 4x1 s.t. 4x1 <= 5, x1 >= 0 (x1 split into x1a - x1b)
+	A := [][]float64{
+		{4, -4, 1, 0},
+		{1, -1, 0, -1},
+	}
+	res := assertTwoPhase(t, []float64{4, -4, 0, 0}, 0, A, []float64{5, 0}, Optimal, []float64{1.25, 0, 0, 1.25}, []float64{1, 0})
+	if math.Abs(res.Value-5) > PRECISIONERROR {
+		t.Fatalf("expected objective value 5, received %.4f", res.Value)
+	}
+}
+
+func TestSimplex_Unbounded(t *testing.T) {
+	A := [][]float64{
+		{5, -5, 3, -3, 0, 0, 0, 0, 1, 0},
+		{1, -1, 1, -1, 3, -3, 0, 0, 0, -1},
+	}
+	c := []float64{4, -4, 1, -1, 0, 0, 5, -5, 0, 0}
+	res := assertTwoPhase(t, c, 100, A, []float64{3, 5}, Unbounded,
+		[]float64{0.6, 0, 0, 0, 22.0 / 15.0, 0, 0, 0, 0, 0},
+		[]float64{0.6, 0, 0, 1, 2.0 / 15.0, 0, 0, 0, 0, 0})
+
+	// Ad = 0 and c^Td > 0
+	for i, row := range A {
+		if math.Abs(dot(row, res.Certificate)) > PRECISIONERROR {
+			t.Fatalf("certificate is not in the null space of A at row %d", i)
+		}
+	}
+	if dot(c, res.Certificate) <= 0 {
+		t.Fatalf("certificate does not increase the objective")
+	}
+}
+
+func TestSimplex_Infeasible(t *testing.T) {
+	// x1 + x2 = -1 has no nonnegative solution
+	res := assertTwoPhase(t, []float64{1, 0}, 0, [][]float64{{1, 1}}, []float64{-1}, Infeasible, nil, []float64{1})
+	if res.Solution != nil {
+		t.Fatalf("expected no solution, received %v", res.Solution)
+	}
+}
+
+func TestSimplex_RedundantRows(t *testing.T) {
+	// x1 + x2 = 2 stated twice, max x1
+	A := [][]float64{
+		{1, 1},
+		{1, 1},
+		{2, 2},
+	}
+	assertTwoPhase(t, []float64{1, 0}, 0, A, []float64{2, 2, 4}, Optimal, []float64{2, 0}, []float64{1, 0, 0})
+}
+
+func TestSimplex_NoConstraints(t *testing.T) {
+	assertTwoPhase(t, []float64{-1, 0}, 3, [][]float64{}, []float64{}, Optimal, []float64{0, 0}, []float64{})
+	assertTwoPhase(t, []float64{0, 1}, 0, [][]float64{}, []float64{}, Unbounded, []float64{0, 0}, []float64{0, 1})
+}
+
+func TestSimplex_InvalidDimensions(t *testing.T) {
+	if _, err := TwoPhase([]float64{1, 2}, 0, [][]float64{{1}}, []float64{1}); err == nil {
+		t.Fatalf("expected error for mismatched dimensions")
+	}
+}
+
+func TestSimplex_Limits(t *testing.T) {
+	// max x1 + 2x2 + 3x3 s.t. x1 + x2 + x3 + s1 = 3, x3 + s2 = 2
+	c := []float64{1, 2, 3, 0, 0}
+	A := [][]float64{
+		{1, 1, 1, 1, 0},
+		{0, 0, 1, 0, 1},
+	}
+	b := []float64{3, 2}
+
+	res, err := TwoPhaseContext(context.Background(), 0, c, 0, A, b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Type != Optimal {
+		t.Fatalf("expected result type %s, received %s", Optimal, res.Type)
+	}
+
+	// stopping one pivot early gives the basic feasible solution before the last pivot
+	res, err = TwoPhaseContext(context.Background(), res.Iterations-1, c, 0, A, b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Type != IterationLimit {
+		t.Fatalf("expected result type %s, received %s", IterationLimit, res.Type)
+	}
+	assertVector(t, "solution", res.Solution, []float64{1, 0, 2, 0, 0})
+	if len(res.Basis) != len(b) || res.Certificate != nil {
+		t.Fatalf("expected a basis of %d columns and no certificate, received %v and %v", len(b), res.Basis, res.Certificate)
+	}
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	res, err = TwoPhaseContext(expired, 0, c, 0, A, b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Type != TimeLimit || res.Solution != nil {
+		t.Fatalf("expected result type %s without a solution (stopped in Phase I), received %s %v", TimeLimit, res.Type, res.Solution)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := TwoPhaseContext(cancelled, 0, c, 0, A, b); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, received %v", err)
+	}
+}
+
+func TestSimplex_Trace(t *testing.T) {
+	// max x1 + 2x2 + 3x3 s.t. x1 + x2 + x3 + s1 = 3, x3 + s2 = 2
+	c := []float64{1, 2, 3, 0, 0}
+	A := [][]float64{
+		{1, 1, 1, 1, 0},
+		{0, 0, 1, 0, 1},
+	}
+	b := []float64{3, 2}
+
+	res, steps, err := TwoPhaseTrace(context.Background(), 0, c, 0, A, b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Type != Optimal || math.Abs(res.Value-8) > PRECISIONERROR {
+		t.Fatalf("expected optimal value 8, received %s %.4f", res.Type, res.Value)
+	}
+
+	// every pivot, and a final tableau for each phase
+	if len(steps) != res.Iterations+2 {
+		t.Fatalf("expected %d steps, received %d", res.Iterations+2, len(steps))
+	}
+	if steps[0].Phase != 1 || steps[0].Value != -5 {
+		t.Fatalf("expected Phase I to start at -5 (the artificial columns), received phase %d value %.4f", steps[0].Phase, steps[0].Value)
+	}
+
+	for i, step := range steps[:len(steps)-1] {
+		next := steps[i+1]
+		if step.Entering == -1 {
+			continue
+		}
+		if !inBasis(next.Basis, step.Entering) || inBasis(next.Basis, step.Leaving) {
+			t.Fatalf("step %d: pivot %d for %d not reflected in next basis %v", i, step.Entering, step.Leaving, next.Basis)
+		}
+		if step.Ratios == nil || len(step.Tableau) != len(b) || len(step.Tableau[0]) != len(c)+len(b)+1 {
+			t.Fatalf("step %d: expected ratios and a %dx%d tableau, received %v", i, len(b), len(c)+len(b)+1, step.Tableau)
+		}
+	}
+
+	last := steps[len(steps)-1]
+	if last.Phase != 2 || last.Entering != -1 || last.Leaving != -1 || math.Abs(last.Value-8) > PRECISIONERROR {
+		t.Fatalf("expected a final Phase II step with value 8, received %+v", last)
+	}
+	for i, col := range last.Basis {
+		if math.Abs(last.Tableau[i][len(c)+len(b)]-res.Solution[col]) > PRECISIONERROR || math.Abs(last.Tableau[i][col]-1) > PRECISIONERROR {
+			t.Fatalf("final tableau row %d doesn't match basic column %d: %v", i, col, last.Tableau[i])
+		}
+	}
+	for _, cost := range last.ReducedCosts[:len(c)] {
+		if cost > EPSILON {
+			t.Fatalf("expected no positive reduced costs at the optimum, received %v", last.ReducedCosts)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package solve
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"github.com/animalat/Simplex-Algorithm/backend/service/simplex"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/linear"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/parser"
+)
+
+// Values within INTEGERTOLERANCE of an integer are taken to be that integer
+const INTEGERTOLERANCE = 1e-6
+
+// MaxBranchAndBoundNodes is the most LP relaxations branch-and-bound solves before stopping with "nodeLimit"
+const MaxBranchAndBoundNodes = 10000
+
+// Result type when branch-and-bound stops at MaxBranchAndBoundNodes, the solution is the incumbent (if there is one)
+const nodeLimit = "nodeLimit"
+
+// Branch-and-bound details of a result, objective values are in terms of the original objective (not negated for MIN)
+type BranchAndBoundResult struct {
+	// Incumbent is the objective value of the best integer solution found (the result's solution)
+	Incumbent *float64 `json:"incumbent,omitempty"`
+	// BestBound is the best objective value an integer solution could have
+	BestBound *float64 `json:"bestBound,omitempty"`
+	// Gap is |BestBound - Incumbent| / (1e-10 + |Incumbent|), 0 once the incumbent is proven optimal
+	Gap *float64 `json:"gap,omitempty"`
+	// Nodes is the number of LP relaxations solved
+	Nodes int `json:"nodes"`
+}
+
+// A subproblem of branch-and-bound: the root program with extra variable bounds
+type branchNode struct {
+	bounds []linear.Constraint
+	// bound is the LP value of the parent (maximized), no integer solution in this node can beat it
+	bound float64
+}
+
+// The program of node, the root program with the node's bounds added on
+func (node branchNode) program(root *linear.LinearProgram) *linear.LinearProgram {
+	lp := *root
+	lp.Constraints = append(root.Constraints[:len(root.Constraints):len(root.Constraints)], node.bounds...)
+	return &lp
+}
+
+// A single variable bound (variable op rhs) to add to a node
+func variableBound(variable linear.Variable, op lexer.TokenType, rhs float64) linear.Constraint {
+	left := linear.NewLinearExpr()
+	left.Add(variable.Name, 1, variable.Span)
+	return linear.Constraint{Left: left, Operator: op, Right: rhs, Span: variable.Span}
+}
+
+// Copy of lp where binary variables are bounded by 0 <= x <= 1
+func withBinaryBounds(lp *linear.LinearProgram) *linear.LinearProgram {
+	root := *lp
+	root.Variables = append([]linear.Variable(nil), lp.Variables...)
+	root.Constraints = append([]linear.Constraint(nil), lp.Constraints...)
+	for i, v := range root.Variables {
+		if v.Type != parser.VarBinary {
+			continue
+		}
+
+		root.Variables[i].NonNegative = true
+		root.Constraints = append(root.Constraints, variableBound(v, lexer.TokenLessEqual, 1))
+	}
+
+	return &root
+}
+
+// Objective value of solution (in terms of lp's variables)
+func objectiveValue(lp *linear.LinearProgram, solution []float64) float64 {
+	value := lp.Objective.Expr.Constant
+	for i, coefficient := range lp.Objective.Expr.Dense(lp.IdTable()) {
+		value += coefficient * solution[i]
+	}
+
+	return value
+}
+
+// Index of the first integer variable with a fractional value in solution, or -1 if there is none
+func fractionalVariable(lp *linear.LinearProgram, solution []float64) int {
+	for i, v := range lp.Variables {
+		if v.IsInteger() && math.Abs(solution[i]-math.Round(solution[i])) > INTEGERTOLERANCE {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Solves lp, which has integer variables, by solving LP relaxations and branching on fractional variables (depth first).
+// If the root relaxation is infeasible or unbounded, that result is returned as is.
+// If a relaxation stops at a time or iteration limit, the search stops with that result type (like "nodeLimit").
+func branchAndBound(ctx context.Context, solver Solver, lp *linear.LinearProgram, opts SolveOptions) (*SimplexResult, error) {
+	// search as a maximization problem
+	sign := 1.0
+	if !lp.Objective.IsMax {
+		sign = -1.0
+	}
+
+	root := withBinaryBounds(lp)
+	stack := []branchNode{{bound: math.Inf(1)}}
+	var incumbent *SimplexResult
+	incumbentValue := math.Inf(-1)
+	info := &BranchAndBoundResult{}
+	var rootTrace *Trace
+	limit := ""
+	for len(stack) > 0 && info.Nodes < MaxBranchAndBoundNodes && limit == "" {
+		if err := ctx.Err(); err != nil {
+			if !errors.Is(err, context.DeadlineExceeded) {
+				return nil, err
+			}
+			limit = string(simplex.TimeLimit)
+			break
+		}
+
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if node.bound <= incumbentValue+EPSILON {
+			// can't beat the incumbent
+			continue
+		}
+
+		info.Nodes++
+		res, err := solveLinearProgram(ctx, solver, node.program(root), opts)
+		if err != nil {
+			return nil, err
+		}
+		if info.Nodes == 1 {
+			// only the root relaxation is traced
+			rootTrace = res.Trace
+			opts.Trace = false
+		}
+
+		switch res.ResultType {
+		case string(simplex.TimeLimit), string(simplex.IterationLimit):
+			if info.Nodes == 1 {
+				res.BranchAndBound = info
+				return res, nil
+			}
+			// the node wasn't solved, so its bound still counts
+			stack = append(stack, node)
+			limit = res.ResultType
+			continue
+		case "infeasible":
+			if info.Nodes == 1 {
+				res.BranchAndBound = info
+				return res, nil
+			}
+			continue
+		case "unbounded":
+			res.BranchAndBound = info
+			return res, nil
+		}
+
+		value := sign * objectiveValue(lp, res.Solution)
+		if value <= incumbentValue+EPSILON {
+			continue
+		}
+
+		idx := fractionalVariable(lp, res.Solution)
+		if idx == -1 {
+			incumbent = res
+			incumbentValue = value
+			continue
+		}
+
+		// x <= floor(x*) is searched first, so it goes on top
+		variable := lp.Variables[idx]
+		up := append(node.bounds[:len(node.bounds):len(node.bounds)], variableBound(variable, lexer.TokenGreaterEqual, math.Ceil(res.Solution[idx])))
+		down := append(node.bounds[:len(node.bounds):len(node.bounds)], variableBound(variable, lexer.TokenLessEqual, math.Floor(res.Solution[idx])))
+		stack = append(stack, branchNode{bounds: up, bound: value}, branchNode{bounds: down, bound: value})
+	}
+
+	bestBound := incumbentValue
+	for _, node := range stack {
+		bestBound = math.Max(bestBound, node.bound)
+	}
+
+	res := &SimplexResult{ResultType: "optimal", Mapping: getTableInverse(lp.IdTable()), BranchAndBound: info, Trace: rootTrace}
+	if limit != "" {
+		res.ResultType = limit
+	} else if len(stack) > 0 {
+		res.ResultType = nodeLimit
+	} else if incumbent == nil {
+		res.ResultType = "infeasible"
+	}
+
+	if !math.IsInf(bestBound, 0) {
+		bestBound *= sign
+		info.BestBound = &bestBound
+	}
+
+	if incumbent == nil {
+		return res, nil
+	}
+
+	res.Solution = incumbent.Solution
+	res.Verification = verifyIncumbent(root, incumbent.Solution)
+	if incumbent.Exact != nil {
+		// like the float certificate, the node's certificate is only about its LP relaxation
+		res.Exact = &ExactResult{Solution: incumbent.Exact.Solution, Objective: incumbent.Exact.Objective}
+	}
+	for i, v := range lp.Variables {
+		if v.IsInteger() {
+			res.Solution[i] = cleanZero(math.Round(res.Solution[i]))
+		}
+	}
+
+	incumbentObjective := sign * incumbentValue
+	gap := math.Abs(*info.BestBound-incumbentObjective) / (1e-10 + math.Abs(incumbentObjective))
+	info.Incumbent = &incumbentObjective
+	info.Gap = &gap
+	return res, nil
+}
@@ -0,0 +1,46 @@
+package solve
+
+import (
+	"testing"
+)
+
+func assertBranchAndBound(t *testing.T, output SimplexResult, incumbentWanted float64, gapWanted float64) {
+	t.Helper()
+
+	info := output.BranchAndBound
+	if info == nil || info.Incumbent == nil || info.BestBound == nil || info.Gap == nil {
+		t.Fatalf("expected incumbent, best bound and gap, received %+v", info)
+	}
+
+	if !floatsEqualWithError(*info.Incumbent, incumbentWanted, PRECISIONERROR) {
+		t.Fatalf("incumbent wanted %.2f, received %.2f", incumbentWanted, *info.Incumbent)
+	}
+	if !floatsEqualWithError(*info.Gap, gapWanted, PRECISIONERROR) {
+		t.Fatalf("gap wanted %.2f, received %.2f", gapWanted, *info.Gap)
+	}
+	if info.Nodes < 1 {
+		t.Fatalf("expected at least one node, received %d", info.Nodes)
+	}
+}
+
+func TestBranchAndBound_PostRequest(t *testing.T) {
+	// the LP relaxation is optimal at (3, 1.5)
+	output := assertPostRequest(t, []byte("let int x1 >= 0; let int x2 >= 0; max 5 * x1 + 4 * x2; s.t. 6 * x1 + 4 * x2 <= 24; x1 + 2 * x2 <= 6;"), []float64{4, 0}, "optimal", nil)
+	assertBranchAndBound(t, output, 20, 0)
+	if !floatsEqualWithError(*output.BranchAndBound.BestBound, 20, PRECISIONERROR) {
+		t.Fatalf("best bound wanted 20, received %.2f", *output.BranchAndBound.BestBound)
+	}
+
+	output = assertPostRequest(t, []byte("let bin a; let bin b; let bin c; max 10 * a + 13 * b + 7 * c; s.t. 4 * a + 6 * b + 3 * c <= 9;"), []float64{0, 1, 1}, "optimal", nil)
+	assertBranchAndBound(t, output, 20, 0)
+
+	output = assertPostRequest(t, []byte("let int x; let y >= 0; min x + 3 * y; s.t. x + y >= 2.5; x >= 0; x <= 10;"), []float64{3, 0}, "optimal", nil)
+	assertBranchAndBound(t, output, 3, 0)
+
+	// no integer solution, though the LP relaxation has one
+	assertPostRequest(t, []byte("let int x; max x; s.t. 2 * x = 1;"), []float64{}, "infeasible", nil)
+	// the root relaxation keeps its certificate
+	assertPostRequest(t, []byte("let int x; let y; max x + y; s.t. x + y <= 2; x >= 0; y >= 0; x + y >= 3;"), []float64{}, "infeasible", []float64{1, -1})
+	// without a name after it, bin is the name of a continuous variable
+	assertPostRequest(t, []byte("let bin; max bin; s.t. bin <= 2.5;"), []float64{2.5}, "optimal", []float64{1})
+}
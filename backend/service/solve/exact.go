@@ -0,0 +1,166 @@
+package solve
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/animalat/Simplex-Algorithm/backend/service/simplex"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/linear"
+)
+
+// ExactResult gives the result of exact mode as fractions (e.g. "5/4"), laid out like the floats of SimplexResult
+type ExactResult struct {
+	Solution []string `json:"solution"`
+	// Certificate is nil for programs with integer variables, like SimplexResult.Certificate
+	Certificate []string `json:"certificate"`
+	// Objective is the value of the original objective at Solution (empty if there is none, or the result is unbounded)
+	Objective string `json:"objective,omitempty"`
+}
+
+// StandardForm with rational values
+type exactStandardForm struct {
+	objective      []*big.Rat
+	objectiveConst *big.Rat
+	constraintsLHS [][]*big.Rat
+	constraintsRHS []*big.Rat
+}
+
+// Appends the SEF columns of e's variables to row (free variables take two columns), negated if negate is set
+func exactRowInput(row []*big.Rat, e linear.LinearExpr, layout *sefLayout, negate bool) []*big.Rat {
+	for i := 0; i < len(layout.idTableInverse); i++ {
+		name := layout.idTableInverse[i]
+		coefficient := e.ExactCoefficient(name)
+		if negate {
+			coefficient.Neg(coefficient)
+		}
+
+		row = append(row, coefficient)
+		if _, ok := layout.toPositive[name]; ok {
+			row = append(row, new(big.Rat).Neg(coefficient))
+		}
+	}
+
+	return row
+}
+
+// Builds the standard equality form of lp with its exact values (see linear.LinearExpr.Exact), in the layout standardForm chose
+func exactStandardFormOf(lp *linear.LinearProgram, layout *sefLayout) (*exactStandardForm, error) {
+	isMin := !lp.Objective.IsMax
+	objective := exactRowInput(nil, lp.Objective.Expr, layout, isMin)
+	for i := 0; i < layout.numSlack; i++ {
+		objective = append(objective, new(big.Rat))
+	}
+	objectiveConst := lp.Objective.Expr.ExactConstant()
+	if isMin {
+		objectiveConst.Neg(objectiveConst)
+	}
+
+	sef := &exactStandardForm{objective: objective, objectiveConst: objectiveConst}
+	numSlackAdded := 0
+	for _, i := range layout.rowConstraints {
+		constraint := lp.Constraints[i]
+		row := exactRowInput(nil, constraint.Left, layout, false)
+		slack := make([]*big.Rat, layout.numSlack)
+		for j := range slack {
+			slack[j] = new(big.Rat)
+		}
+
+		switch constraint.Operator {
+		case lexer.TokenLessEqual:
+			slack[numSlackAdded].SetInt64(1)
+			numSlackAdded++
+		case lexer.TokenGreaterEqual:
+			slack[numSlackAdded].SetInt64(-1)
+			numSlackAdded++
+		case lexer.TokenEqual:
+		default:
+			return nil, lexer.Errorf(constraint.Span, "invalid comparison operator on constraint %d", i)
+		}
+
+		sef.constraintsLHS = append(sef.constraintsLHS, append(row, slack...))
+		sef.constraintsRHS = append(sef.constraintsRHS, constraint.ExactRightValue())
+	}
+
+	return sef, nil
+}
+
+// Values of lp's variables from values of the SEF columns (undoing x = a - b, slack columns are dropped)
+func exactOriginalVariables(values []*big.Rat, layout *sefLayout) []*big.Rat {
+	original := make([]*big.Rat, 0, len(layout.idTableInverse))
+	col := 0
+	for i := 0; i < len(layout.idTableInverse); i++ {
+		if _, ok := layout.toPositive[layout.idTableInverse[i]]; ok {
+			original = append(original, new(big.Rat).Sub(values[col], values[col+1]))
+			col += 2
+		} else {
+			original = append(original, values[col])
+			col++
+		}
+	}
+
+	return original
+}
+
+func ratStrings(rats []*big.Rat) []string {
+	strs := make([]string, len(rats))
+	for i, rat := range rats {
+		strs[i] = rat.RatString()
+	}
+
+	return strs
+}
+
+// floats converts rationals to the nearest floats (nil stays nil)
+func floats(rats []*big.Rat) []float64 {
+	if rats == nil {
+		return nil
+	}
+
+	res := make([]float64, len(rats))
+	for i, rat := range rats {
+		res[i], _ = rat.Float64()
+	}
+
+	return res
+}
+
+// Solves lp with the rational simplex method (see simplex.TwoPhaseExact), giving a result in terms of sef like
+// Solver.Solve (with floats converted from the exact values) and the exact values in terms of lp.
+func solveExact(ctx context.Context, lp *linear.LinearProgram, layout *sefLayout, opts SolveOptions) (*SimplexResult, error) {
+	sef, err := exactStandardFormOf(lp, layout)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := simplex.TwoPhaseExact(ctx, opts.IterationLimit, sef.objective, sef.objectiveConst, sef.constraintsLHS, sef.constraintsRHS)
+	if err != nil {
+		return nil, fmt.Errorf("error running exact solver: %w", err)
+	}
+
+	result := &SimplexResult{ResultType: string(res.Type), Certificate: floats(res.Certificate), Basis: res.Basis}
+	exact := &ExactResult{Certificate: ratStrings(res.Certificate)}
+	if res.Type != simplex.Infeasible {
+		result.Solution = floats(res.Solution)
+	}
+	if res.Type == simplex.Infeasible || res.Solution == nil {
+		result.Exact = exact
+		return result, nil
+	}
+
+	solution := exactOriginalVariables(res.Solution, layout)
+	exact.Solution = ratStrings(solution)
+	if res.Type == simplex.Unbounded {
+		exact.Certificate = ratStrings(exactOriginalVariables(res.Certificate, layout))
+	} else {
+		value := lp.Objective.Expr.ExactConstant()
+		for i, v := range lp.Variables {
+			value.Add(value, new(big.Rat).Mul(lp.Objective.Expr.ExactCoefficient(v.Name), solution[i]))
+		}
+		exact.Objective = value.RatString()
+	}
+	result.Exact = exact
+
+	return result, nil
+}
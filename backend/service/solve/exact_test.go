@@ -0,0 +1,89 @@
+package solve
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func postExact(t *testing.T, query string, input string) (int, SimplexResult) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, solvePath+query, bytes.NewReader([]byte(input)))
+	req.Header.Set(contentType, textPlain)
+	w := httptest.NewRecorder()
+
+	HandleSolve(w, req)
+
+	var output SimplexResult
+	if w.Code == http.StatusOK {
+		if err := json.NewDecoder(w.Result().Body).Decode(&output); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+	}
+
+	return w.Code, output
+}
+
+func assertExact(t *testing.T, input string, resultTypeWanted string, solutionWanted string, certificateWanted string, objectiveWanted string) SimplexResult {
+	t.Helper()
+
+	code, output := postExact(t, "?exact=true", input)
+	if code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+	}
+	if output.ResultType != resultTypeWanted {
+		t.Fatalf("expected resultType %s, received type %v", resultTypeWanted, output.ResultType)
+	}
+	if output.Exact == nil {
+		t.Fatalf("expected an exact result")
+	}
+
+	if got := fmt.Sprint(output.Exact.Solution); got != solutionWanted {
+		t.Errorf("expected exact solution %s, received %s", solutionWanted, got)
+	}
+	if got := fmt.Sprint(output.Exact.Certificate); got != certificateWanted {
+		t.Errorf("expected exact certificate %s, received %s", certificateWanted, got)
+	}
+	if output.Exact.Objective != objectiveWanted {
+		t.Errorf("expected exact objective %q, received %q", objectiveWanted, output.Exact.Objective)
+	}
+	assertVerified(t, output)
+
+	return output
+}
+
+func TestExact_PostRequest(t *testing.T) {
+	assertExact(t, "let x1; max 4 * x1; s.t. 4 * x1 <= 5;", "optimal", "[5/4]", "[1]", "5")
+	assertExact(t, "let x >= 0; let y >= 0; max x + y; s.t. 3 * x <= 1; 3 * y <= 2;", "optimal", "[1/3 2/3]", "[1/3 1/3]", "1")
+	assertExact(t, "let x1 >= 0; max x1; s.t. x1 <= -1 / 3;", "infeasible", "[]", "[1]", "")
+	assertExact(t, "let x1 >= 0; let x2 >= 0; min -x1 / 3 - x2; s.t. x2 >= 1; x1 - x2 >= 0;", "unbounded", "[1 1]", "[1 1]", "")
+	// the certificate of the incumbent's node is only about its LP relaxation, so it isn't given
+	assertExact(t, "let int x1 >= 0; max x1 / 2; s.t. 3 * x1 <= 7;", "optimal", "[2]", "[]", "1")
+	assertExact(t, "param p = 1/3; let int x >= 0; max x; s.t. 3 * x <= 10 * p + 7;", "optimal", "[3]", "[]", "3")
+
+	// 0.1 + 0.2 folds to 3/10 exactly, so x1 reaches 1 (floats give 0.9999999999999999)
+	output := assertExact(t, "let x1 >= 0; max x1; s.t. (0.1 + 0.2) * x1 <= 0.3;", "optimal", "[1]", "[10/3]", "1")
+	if output.Solution[0] != 1 {
+		t.Fatalf("expected the float solution to be exactly 1, received %v", output.Solution[0])
+	}
+}
+
+func TestExact_Options(t *testing.T) {
+	input := "let x1 >= 0; max x1; s.t. x1 <= 1;"
+	if _, output := postExact(t, "", input); output.Exact != nil {
+		t.Fatalf("expected no exact result without exact=true, received %+v", output.Exact)
+	}
+	if _, output := postExact(t, "?exact=false", input); output.Exact != nil {
+		t.Fatalf("expected no exact result with exact=false, received %+v", output.Exact)
+	}
+
+	for _, query := range []string{"?exact=maybe", "?exact=true&solver=cpp"} {
+		if code, _ := postExact(t, query, input); code != http.StatusBadRequest {
+			t.Fatalf("%s: expected status %d, got %d", query, http.StatusBadRequest, code)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package solve
+
+import (
+	"context"
+
+	"github.com/animalat/Simplex-Algorithm/lp_parser/linear"
+)
+
+// A constraint of an irreducible infeasible subsystem (IIS): the constraints together are infeasible,
+// but removing any one of them makes the rest feasible
+type IISConstraint struct {
+	// Index of the constraint in the source (the key of unnamed constraints in the duals), both halves of a ranged
+	// constraint like "lower <= expr <= upper" have the same index
+	Index int    `json:"index"`
+	Name  string `json:"name,omitempty"`
+	// Range is whether this is the second half of a ranged constraint (see linear.Constraint.IsRange)
+	Range bool `json:"range,omitempty"`
+	// Line of the source the constraint is on (left out if the model has no source lines, e.g. JSON)
+	Line int `json:"line,omitempty"`
+}
+
+// Copy of lp with only the constraints at indices, followed by extra
+func withConstraints(lp *linear.LinearProgram, indices []int, extra []linear.Constraint) *linear.LinearProgram {
+	sub := *lp
+	sub.Constraints = make([]linear.Constraint, 0, len(indices)+len(extra))
+	for _, i := range indices {
+		sub.Constraints = append(sub.Constraints, lp.Constraints[i])
+	}
+	sub.Constraints = append(sub.Constraints, extra...)
+
+	return &sub
+}
+
+// Finds an IIS of lp, whose LP relaxation is infeasible, with a deletion filter: each constraint is dropped in turn,
+// and left out for good if the rest are still infeasible. Variable bounds from declarations (e.g. "let x >= 0;" and
+// binary variables) are always kept. It returns nil if a solve stops at a time or iteration limit.
+func irreducibleInfeasibleSubsystem(ctx context.Context, solver Solver, lp *linear.LinearProgram, opts SolveOptions) ([]IISConstraint, error) {
+	root := withBinaryBounds(lp)
+	binaryBounds := root.Constraints[len(lp.Constraints):]
+	// the subproblems aren't traced
+	opts.Trace = false
+
+	kept := make([]int, len(lp.Constraints))
+	for i := range kept {
+		kept[i] = i
+	}
+	for i := 0; i < len(kept); {
+		without := append(kept[:i:i], kept[i+1:]...)
+		res, err := solveLinearProgram(ctx, solver, withConstraints(root, without, binaryBounds), opts)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case res.ResultType == "infeasible":
+			// kept[i] isn't needed for the conflict
+			kept = without
+		case stoppedAtLimit(res.ResultType):
+			return nil, nil
+		default:
+			i++
+		}
+	}
+
+	indices := lp.SourceIndices()
+	iis := make([]IISConstraint, 0, len(kept))
+	for _, i := range kept {
+		constraint := lp.Constraints[i]
+		iis = append(iis, IISConstraint{Index: indices[i], Name: constraint.Name, Range: constraint.IsRange, Line: constraint.Span.Start.Line})
+	}
+
+	return iis, nil
+}
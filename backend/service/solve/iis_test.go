@@ -0,0 +1,47 @@
+package solve
+
+import (
+	"testing"
+)
+
+func assertIIS(t *testing.T, output SimplexResult, iisWanted []IISConstraint) {
+	t.Helper()
+
+	if len(output.IIS) != len(iisWanted) {
+		t.Fatalf("IIS wanted %+v, received %+v", iisWanted, output.IIS)
+	}
+	for i := range iisWanted {
+		if output.IIS[i] != iisWanted[i] {
+			t.Fatalf("IIS not equal at index %d: wanted %+v, received %+v", i, iisWanted[i], output.IIS[i])
+		}
+	}
+}
+
+func TestIIS_PostRequest(t *testing.T) {
+	input := "let x1 >= 0; let x2 >= 0;\nmax x1 + x2;\ns.t. total: x1 + x2 <= 10;\nx1 >= 6;\ncap: x2 <= 8;\nmin2: x2 >= 5;\nx1 <= 100;"
+	output := assertPostRequest(t, []byte(input), nil, "infeasible", []float64{1, -1, 0, -1, 0})
+	assertIIS(t, output, []IISConstraint{{Index: 0, Name: "total", Line: 3}, {Index: 1, Line: 4}, {Index: 3, Name: "min2", Line: 6}})
+
+	// binary variables keep their bounds, so a + b >= 3 conflicts on its own
+	output = assertPostRequest(t, []byte("let bin a; let bin b; max a + b;\ns.t. pick: a + b >= 3;\nloose: a <= 5;"), nil, "infeasible", []float64{-1, 0, 1, 1})
+	assertIIS(t, output, []IISConstraint{{Index: 0, Name: "pick", Line: 2}})
+
+	// a chained constraint is one constraint of the source, so the indices after it aren't shifted
+	output = assertPostRequest(t, []byte("let x; let y; max x;\ns.t. 0 <= x <= 1;\ny >= 5;\ny <= 1;"), nil, "infeasible", []float64{0, -1, 1})
+	assertIIS(t, output, []IISConstraint{{Index: 1, Line: 3}, {Index: 2, Line: 4}})
+	output = assertPostRequest(t, []byte("let x; max x;\ns.t. 2 <= x <= 3;\nx >= 5;"), nil, "infeasible", []float64{0, 1, -1})
+	assertIIS(t, output, []IISConstraint{{Index: 0, Range: true, Line: 2}, {Index: 1, Line: 3}})
+
+	// the LP relaxation is feasible (x = 0.5), so there is no IIS
+	output = assertPostRequest(t, []byte("let int x >= 0; max x; s.t. 2 * x = 1;"), nil, "infeasible", nil)
+	assertIIS(t, output, nil)
+
+	output = assertPostRequestAs(t, []byte(`{"variables": [{"name": "x", "lower": 0, "upper": 1}], "objective": {"sense": "max", "coefficients": {"x": 1}},
+		"constraints": [{"name": "low", "coefficients": {"x": 1}, "operator": ">=", "rhs": 2}]}`), applicationJson, nil, "infeasible", []float64{-1, 1})
+	assertIIS(t, output, []IISConstraint{{Index: 0, Name: "low"}, {Index: 1, Name: "x.up"}})
+
+	// an MPS bound is on the line of its BOUNDS record (line 10), not the COLUMNS entry of its variable
+	mps := "NAME t\nROWS\n N obj\n G low\nCOLUMNS\n x obj 1 low 1\nRHS\n rhs low 5\nBOUNDS\n UP bnd x 3\nENDATA\n"
+	output = assertPostRequestAs(t, []byte(mps), applicationMps, nil, "infeasible", []float64{-1, 1})
+	assertIIS(t, output, []IISConstraint{{Index: 0, Name: "low", Line: 4}, {Index: 1, Name: "x.up", Line: 10}})
+}
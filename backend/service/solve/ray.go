@@ -0,0 +1,56 @@
+package solve
+
+import (
+	"fmt"
+
+	"github.com/animalat/Simplex-Algorithm/lp_parser/linear"
+)
+
+// Ray of an unbounded result in terms of the original variables: every point Start + t * Direction (t >= 0) is
+// feasible, and the objective changes by Rate * t along it (the result's Verification checks this)
+type UnboundedRay struct {
+	// Direction of the ray by variable name
+	Direction map[string]float64 `json:"direction"`
+	// Rate is the change in the objective per unit along Direction (positive for MAX, negative for MIN)
+	Rate float64 `json:"rate"`
+	// Start is the feasible point the ray starts from, by variable name
+	Start map[string]float64 `json:"start"`
+}
+
+// Values of lp's variables by name
+func byVariable(lp *linear.LinearProgram, values []float64) map[string]float64 {
+	named := make(map[string]float64, len(lp.Variables))
+	for i, v := range lp.Variables {
+		named[v.Name] = cleanZero(values[i])
+	}
+
+	return named
+}
+
+// Builds the ray of an unbounded result from its certificate d and solution (both over the columns of sef)
+func unboundedRay(lp *linear.LinearProgram, sef *StandardForm, layout *sefLayout, d []float64, solution []float64) (*UnboundedRay, error) {
+	if len(d) != len(sef.Objective) || len(solution) != len(sef.Objective) {
+		return nil, fmt.Errorf("certificate has %d columns and solution has %d, standard form has %d", len(d), len(solution), len(sef.Objective))
+	}
+
+	direction, err := retrieveOriginalVariables(layout.numSlack, d, layout.toPositive, layout.idTableInverse)
+	if err != nil {
+		return nil, err
+	}
+	start, err := retrieveOriginalVariables(layout.numSlack, solution, layout.toPositive, layout.idTableInverse)
+	if err != nil {
+		return nil, err
+	}
+
+	// the objective of sef is negated for MIN
+	rate := dotProduct(sef.Objective, d)
+	if !lp.Objective.IsMax {
+		rate = -rate
+	}
+
+	return &UnboundedRay{
+		Direction: byVariable(lp, direction),
+		Rate:      cleanZero(rate),
+		Start:     byVariable(lp, start),
+	}, nil
+}
@@ -0,0 +1,69 @@
+package solve
+
+import (
+	"math"
+	"testing"
+
+	"github.com/animalat/Simplex-Algorithm/lp_parser/parse_sef"
+)
+
+func assertRay(t *testing.T, output SimplexResult, directionWanted map[string]float64, rateWanted float64, startWanted map[string]float64) {
+	t.Helper()
+
+	ray := output.Ray
+	if ray == nil {
+		t.Fatalf("expected a ray")
+	}
+	if output.Verification == nil || !output.Verification.Verified || output.Verification.Ray == nil {
+		t.Fatalf("expected the ray to be verified, received %+v", output.Verification)
+	}
+	if !floatsEqualWithError(ray.Rate, rateWanted, PRECISIONERROR) {
+		t.Fatalf("rate wanted %.2f, received %.2f", rateWanted, ray.Rate)
+	}
+	if check := output.Verification.Ray; check.Rate != ray.Rate || math.Signbit(check.MinEntry) {
+		t.Fatalf("expected the verified rate %v to match the ray's rate %v, with a minEntry %v that isn't -0", check.Rate, ray.Rate, check.MinEntry)
+	}
+
+	for name, values := range map[string][2]map[string]float64{"direction": {directionWanted, ray.Direction}, "start": {startWanted, ray.Start}} {
+		want, got := values[0], values[1]
+		if len(got) != len(want) {
+			t.Fatalf("%s wanted %v, received %v", name, want, got)
+		}
+		for key := range want {
+			if !floatsEqualWithError(want[key], got[key], PRECISIONERROR) {
+				t.Fatalf("%s of %q not equal: wanted %.2f, received %.2f", name, key, want[key], got[key])
+			}
+		}
+	}
+}
+
+func TestRay_PostRequest(t *testing.T) {
+	output := assertPostRequest(t, []byte("let x1 >= 0; let x2 >= 0; max x1 + x2; s.t. x1 - x2 <= 1;"), []float64{1, 0}, "unbounded", []float64{1, 1})
+	assertRay(t, output, map[string]float64{"x1": 1, "x2": 1}, 2, map[string]float64{"x1": 1, "x2": 0})
+
+	// x1 is free (two columns in standard form), the objective decreases along the ray for MIN
+	output = assertPostRequest(t, []byte("let x1; let x2 >= 0; min x1 - 2 * x2 + 1; s.t. c: x1 - x2 >= -3;"), []float64{0, 3}, "unbounded", []float64{1, 1})
+	assertRay(t, output, map[string]float64{"x1": 1, "x2": 1}, -1, map[string]float64{"x1": 0, "x2": 3})
+
+	output = assertPostRequest(t, []byte("let x1 >= 0; max x1; s.t. x1 <= 1;"), []float64{1}, "optimal", []float64{1})
+	if output.Ray != nil {
+		t.Fatalf("expected no ray for an optimal result, received %+v", output.Ray)
+	}
+}
+
+func TestRay_Verify(t *testing.T) {
+	lp, err := parse_sef.ParseSEF("let x1 >= 0; let x2 >= 0; max x1 + x2; s.t. x1 - x2 <= 1;")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rows := rowsOf(lp)
+
+	if check := verifyRay(lp, rows, []float64{1, 1}); !check.Verified {
+		t.Fatalf("expected a verified ray, received %+v", check)
+	}
+	for _, d := range [][]float64{{1, 0}, {-1, -1}, {0, 0}} {
+		if check := verifyRay(lp, rows, d); check.Verified {
+			t.Fatalf("expected %v not to be verified, received %+v", d, check)
+		}
+	}
+}
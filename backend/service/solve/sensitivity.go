@@ -0,0 +1,104 @@
+package solve
+
+import (
+	"math"
+
+	"github.com/animalat/Simplex-Algorithm/backend/service/simplex"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/linear"
+)
+
+// A range of values, nil ends are unbounded (-infinity for Lower, infinity for Upper)
+type ValueRange struct {
+	Lower *float64 `json:"lower"`
+	Upper *float64 `json:"upper"`
+}
+
+// Sensitivity analysis of an optimal result, in terms of the original program (objective not negated for MIN)
+type SensitivityResult struct {
+	// ReducedCosts of each variable (indexed like the solution)
+	ReducedCosts []float64 `json:"reducedCosts"`
+	// ObjectiveRanges are the values each variable's objective coefficient can take with the solution staying optimal
+	ObjectiveRanges []ValueRange `json:"objectiveRanges"`
+	// RHSRanges are the values each constraint's right hand side can take with the basis staying optimal
+	// (by name, or index if unnamed, like the duals)
+	RHSRanges map[string]ValueRange `json:"rhsRanges"`
+}
+
+// The range value + [lower, upper]
+func valueRange(value float64, lower float64, upper float64) ValueRange {
+	var r ValueRange
+	if !math.IsInf(lower, 0) {
+		l := cleanZero(value + lower)
+		r.Lower = &l
+	}
+	if !math.IsInf(upper, 0) {
+		u := cleanZero(value + upper)
+		r.Upper = &u
+	}
+
+	return r
+}
+
+// Determines the reduced costs, objective ranges and right hand side ranges of an optimal result from its basis.
+// solution is the solution of sef (before retrieveOriginalVariables), used to find the basis if the solver gave none.
+// Returns nil if the basis can't be found.
+func sensitivityAnalysis(lp *linear.LinearProgram, sef *StandardForm, layout *sefLayout, solution []float64, basis []int) *SensitivityResult {
+	if basis == nil {
+		var err error
+		if basis, err = simplex.BasisFromSolution(sef.ConstraintsLHS, solution); err != nil {
+			return nil
+		}
+	}
+
+	s, err := simplex.NewSensitivity(sef.Objective, sef.ConstraintsLHS, sef.ConstraintsRHS, basis)
+	if err != nil {
+		return nil
+	}
+
+	sign := 1.0
+	if !lp.Objective.IsMax {
+		sign = -1.0
+	}
+
+	objective := lp.Objective.Expr.Dense(lp.IdTable())
+	reducedCosts := s.ReducedCosts()
+	res := &SensitivityResult{
+		ReducedCosts:    make([]float64, len(lp.Variables)),
+		ObjectiveRanges: make([]ValueRange, len(lp.Variables)),
+		RHSRanges:       make(map[string]ValueRange, len(lp.Constraints)),
+	}
+	for i, v := range lp.Variables {
+		col := sefColumn(i, layout.toPositive, layout.idTableInverse)
+		res.ReducedCosts[i] = cleanZero(sign * reducedCosts[col])
+
+		// the SEF objective is sign * c, free variables change both of their columns (x = a - b)
+		direction := make([]float64, len(sef.Objective))
+		direction[col] = sign
+		if _, ok := layout.toPositive[v.Name]; ok {
+			direction[col+1] = -sign
+		}
+		lower, upper := s.ObjectiveRange(direction)
+		res.ObjectiveRanges[i] = valueRange(objective[i], lower, upper)
+	}
+
+	keys := constraintKeys(lp)
+	for r, i := range layout.rowConstraints {
+		direction := make([]float64, len(sef.ConstraintsRHS))
+		direction[r] = 1
+		lower, upper := s.RHSRange(direction)
+		res.RHSRanges[keys[i]] = valueRange(lp.Constraints[i].Right, lower, upper)
+	}
+
+	for _, bound := range layout.bounds {
+		// moving the bound to coefficient * x >= t is x = x' + t / coefficient, so b changes by -t / coefficient * A_x
+		col := sefColumn(bound.variable, layout.toPositive, layout.idTableInverse)
+		direction := make([]float64, len(sef.ConstraintsRHS))
+		for r := range sef.ConstraintsLHS {
+			direction[r] = -sef.ConstraintsLHS[r][col] / bound.coefficient
+		}
+		lower, upper := s.RHSRange(direction)
+		res.RHSRanges[keys[bound.constraint]] = valueRange(lp.Constraints[bound.constraint].Right, lower, upper)
+	}
+
+	return res
+}
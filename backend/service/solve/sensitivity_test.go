@@ -0,0 +1,48 @@
+package solve
+
+import (
+	"math"
+	"testing"
+)
+
+// math.Inf(-1) / math.Inf(1) stand for unbounded ends
+func assertValueRange(t *testing.T, name string, got ValueRange, lowerWanted float64, upperWanted float64) {
+	t.Helper()
+
+	check := func(end *float64, want float64) bool {
+		if math.IsInf(want, 0) {
+			return end == nil
+		}
+		return end != nil && floatsEqualWithError(*end, want, PRECISIONERROR)
+	}
+	if !check(got.Lower, lowerWanted) || !check(got.Upper, upperWanted) {
+		t.Fatalf("%s range wanted [%.2f, %.2f], received %+v", name, lowerWanted, upperWanted, got)
+	}
+}
+
+func TestSensitivity_PostRequest(t *testing.T) {
+	output := assertPostRequest(t, []byte("let x1 >= 0; let x2 >= 0; max 3 * x1 + 5 * x2; s.t. plant1: x1 <= 4; plant2: 2 * x2 <= 12; plant3: 3 * x1 + 2 * x2 <= 18;"), []float64{2, 6}, "optimal", []float64{0, 1.5, 1})
+	if output.Sensitivity == nil {
+		t.Fatalf("expected sensitivity analysis for optimal result")
+	}
+	s := output.Sensitivity
+	if len(s.ReducedCosts) != 2 || s.ReducedCosts[0] != 0 || s.ReducedCosts[1] != 0 {
+		t.Fatalf("expected reduced costs [0 0], received %v", s.ReducedCosts)
+	}
+	assertValueRange(t, "x1", s.ObjectiveRanges[0], 0, 7.5)
+	assertValueRange(t, "x2", s.ObjectiveRanges[1], 2, math.Inf(1))
+	assertValueRange(t, "plant1", s.RHSRanges["plant1"], 2, math.Inf(1))
+	assertValueRange(t, "plant2", s.RHSRanges["plant2"], 6, 18)
+	assertValueRange(t, "plant3", s.RHSRanges["plant3"], 12, 24)
+
+	// x2 is free, its bound b is left out of the SEF
+	output = assertPostRequest(t, []byte("let x1 >= 0; let x2; min x2 - x1; s.t. c: x1 + x2 <= 4; b: x2 >= 0;"), []float64{4, 0}, "optimal", []float64{1})
+	s = output.Sensitivity
+	if s == nil || !floatsEqualWithError(s.ReducedCosts[1], 2, PRECISIONERROR) {
+		t.Fatalf("expected reduced cost 2 for x2, received %+v", s)
+	}
+	assertValueRange(t, "x1", s.ObjectiveRanges[0], math.Inf(-1), 0)
+	assertValueRange(t, "x2", s.ObjectiveRanges[1], -1, math.Inf(1))
+	assertValueRange(t, "c", s.RHSRanges["c"], 0, math.Inf(1))
+	assertValueRange(t, "b", s.RHSRanges["b"], math.Inf(-1), 4)
+}
@@ -1,36 +1,66 @@
 package solve
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"fmt"
 	"io"
+	"math"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
-	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
+	"github.com/animalat/Simplex-Algorithm/backend/service/simplex"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/cplex"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/json_model"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/linear"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/mps"
 	"github.com/animalat/Simplex-Algorithm/lp_parser/parse_sef"
-	"github.com/animalat/Simplex-Algorithm/lp_parser/parser"
 )
 
 const EPSILON = 1e-9
 const PRECISIONERROR = 1e-2
 
 const solvePath = "/solve"
+const timeLimitQuery = "timeLimit"
+const iterationLimitQuery = "iterationLimit"
+const exactQuery = "exact"
+const traceQuery = "trace"
 const textPlain = "text/plain"
 const applicationJson = "application/json"
+const applicationMps = "application/x-mps"
+const applicationCplexLp = "application/x-cplex-lp"
 const methodPost = "POST"
 const contentType = "Content-Type"
 
+// The "format" parameter of application/x-mps (e.g. "application/x-mps; format=fixed"), free MPS by default
+const mpsFormatParam = "format"
+
+var mpsFormats = map[string]mps.Format{"": mps.FormatFree, "free": mps.FormatFree, "fixed": mps.FormatFixed}
+
 const pageNotFound = "404 PAGE NOT FOUND"
 const methodNotAllowed = "405 METHOD NOT ALLOWED"
 const unsupportedMediaType = "415 UNSUPPORTED MEDIA TYPE"
 const internalServerError = "500 INTERNAL SERVER ERROR"
 
-const enableObjective = true
-const disableObjective = false
-
 // HandleSolve accepts (plain text) an LP in form like: "let x1; let x2; max x1 + x2 + 3; s.t. x1 <= 5;"
+// an MPS file (application/x-mps, see mps.ParseMPS), a model in CPLEX LP format (application/x-cplex-lp, see cplex.ParseLP)
+// or a JSON model (application/json, see json_model.Model).
+// The solver can be chosen with the "solver" query parameter (e.g. /solve?solver=cpp), see RegisterSolver.
+// The "timeLimit" (in seconds) and "iterationLimit" query parameters stop the solve early (see SolveOptions),
+// with the result type "timeLimit" or "iterationLimit", the solution reached so far and its basis (by column name).
+// The time limit also covers expanding the model, which is limited to expand.MaxExpansion terms.
 // It returns (JSON format) the solution (if one exists) and certificate, along with
 // a string specifying the output type, and a map that details what variables is at each index.
+// Programs with integer or binary variables are solved with branch-and-bound (see BranchAndBoundResult).
+// Infeasible programs also get the constraints that conflict, by index, name and source line (see IISConstraint),
+// and unbounded programs the ray the objective improves along, by variable name (see UnboundedRay).
+// Every result's solution and certificate are checked against the model (see Verification).
+// With exact=true the program is folded and solved with rationals, and the result is also given as fractions (see ExactResult).
+// With trace=true the result has every pivot of the simplex method, with named columns (see Trace).
+// Errors are returned as JSON (see ErrorResponse): 422 for errors in the LP, 500 if the solver fails.
 func HandleSolve(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
@@ -39,7 +69,7 @@ func HandleSolve(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 
 	if r.URL.Path != solvePath {
-		http.Error(w, pageNotFound, http.StatusNotFound)
+		writeRequestError(w, http.StatusNotFound, codeNotFound, pageNotFound)
 		return
 	}
 
@@ -49,127 +79,146 @@ func HandleSolve(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if r.Method != methodPost {
-		http.Error(w, methodNotAllowed, http.StatusMethodNotAllowed)
+		writeRequestError(w, http.StatusMethodNotAllowed, codeMethodNotAllowed, methodNotAllowed)
 		return
 	}
 
-	if r.Header.Get(contentType) != textPlain {
-		http.Error(w, unsupportedMediaType, http.StatusUnsupportedMediaType)
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get(contentType))
+	if err != nil || !supportedMediaType(mediaType, params) {
+		writeRequestError(w, http.StatusUnsupportedMediaType, codeUnsupportedMediaType, unsupportedMediaType)
 		return
 	}
 
-	progBytes, err := io.ReadAll(r.Body)
+	solver, err := getSolver(r.URL.Query().Get(solverQuery))
 	if err != nil {
-		http.Error(w, internalServerError, http.StatusInternalServerError)
+		writeRequestError(w, http.StatusBadRequest, codeUnknownSolver, err.Error())
 		return
 	}
 
-	progStr := string(progBytes)
-	prog, idTable, err := parse_sef.ParseSEF(progStr)
+	opts, err := solveOptions(r.URL.Query())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeRequestError(w, http.StatusBadRequest, codeInvalidOption, err.Error())
 		return
 	}
-
-	objectiveConst, objective, err := getExprArr(prog.Objective.Expr, idTable, enableObjective)
-	if err != nil {
-		http.Error(w, "error converting objective into array: "+err.Error(), http.StatusBadRequest)
+	if name := r.URL.Query().Get(solverQuery); opts.Exact && name != "" && name != DefaultSolverName {
+		// exact mode has its own rational solver
+		writeRequestError(w, http.StatusBadRequest, codeInvalidOption, fmt.Sprintf("%s can't be used with %s=%s", exactQuery, solverQuery, name))
 		return
 	}
-
-	if !prog.Objective.IsMax {
-		// flip sign to make it a maximization problem
-		for i := range objective {
-			objective[i] *= -1
-		}
-		objectiveConst *= -1
-	}
-
-	constraintsLHS := make([][]float64, 0, len(prog.Constraints))
-	constraintsRHS := make([]float64, 0, len(prog.Constraints))
-	constraintsSlack := make([]float64, len(prog.Constraints))
-	numSlack := 0
-	for i, constraint := range prog.Constraints {
-		_, curConstraintArr, err := getExprArr(constraint.Left, idTable, disableObjective)
-		if err != nil {
-			http.Error(w, "error converting constraint row "+strconv.Itoa(i)+" into array: "+err.Error(), http.StatusBadRequest)
-			return
-		}
-		constraintsLHS = append(constraintsLHS, curConstraintArr)
-
-		nl, ok := constraint.Right.(*parser.NumberLiteral)
-		if !ok {
-			http.Error(w, "right hand side is not NumberLiteral on constraint row"+strconv.Itoa(i)+": "+err.Error(), http.StatusBadRequest)
-			return
-		}
-		constraintsRHS = append(constraintsRHS, nl.Value)
-
-		switch constraint.Operator.Type {
-		case lexer.TokenLessEqual:
-			numSlack++
-			// 1 because we're adding on just a ("1 * s") slack variable
-			constraintsSlack[i] = 1
-		case lexer.TokenEqual:
-			continue
-		case lexer.TokenGreaterEqual:
-			numSlack++
-			// -1 because we're subtracting a slack variable ("-1 * s")
-			constraintsSlack[i] = -1
-		default:
-			// shouldn't have any other operator types
-			http.Error(w, "invalid comparison operator on constraint row"+strconv.Itoa(i)+": "+err.Error(), http.StatusBadRequest)
-			return
-		}
+	if _, ok := solver.(CppSolver); opts.IterationLimit > 0 && ok {
+		writeRequestError(w, http.StatusBadRequest, codeInvalidOption, fmt.Sprintf("%s is not supported by the %s solver", iterationLimitQuery, r.URL.Query().Get(solverQuery)))
+		return
 	}
-
-	toPositive := allFreeVariables(idTable, make(map[string]struct{}))
-	progArrays := SimplexProgramArrays{
-		objective:        objective,
-		objectiveConst:   objectiveConst,
-		constraintsLHS:   constraintsLHS,
-		constraintsRHS:   constraintsRHS,
-		constraintsSlack: constraintsSlack,
-		numSlack:         numSlack,
+	if _, ok := solver.(GoSolver); opts.Trace && !ok {
+		writeRequestError(w, http.StatusBadRequest, codeInvalidOption, fmt.Sprintf("%s is only supported by the %s solver", traceQuery, DefaultSolverName))
+		return
 	}
 
-	idTableInverse := getTableInverse(idTable)
-	progStrings, err := simplexInput(progArrays, toPositive, idTable, idTableInverse)
+	progBytes, err := io.ReadAll(r.Body)
 	if err != nil {
-		http.Error(w, "error converting arrays into strings: "+err.Error(), http.StatusBadRequest)
+		writeRequestError(w, http.StatusInternalServerError, codeReadFailed, internalServerError)
 		return
 	}
 
-	rowSize := strconv.Itoa(len(constraintsLHS))
-	// before converted colSize + number of slack variables we added + number of complementary variables we added (complementary := a - b for a, b >= 0)
-	colSize := strconv.Itoa(numSlack + len(toPositive) + len(objective))
-	output, err := callSimplex(progStrings, rowSize, colSize)
-	if err != nil {
-		http.Error(w, "error calling simplex method: "+err.Error(), http.StatusBadRequest)
-		return
+	// the time limit covers parsing (expanding) the model as well as solving it, SolveProgram only follows ctx
+	ctx := r.Context()
+	if opts.TimeLimit > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.TimeLimit)
+		defer cancel()
 	}
 
-	res, err := parseResult(output, idTableInverse)
+	lp, err := parseModel(ctx, mediaType, params, string(progBytes), opts.Exact)
+	if errors.Is(err, context.DeadlineExceeded) {
+		// nothing was solved yet
+		writeResult(w, &SimplexResult{ResultType: string(simplex.TimeLimit)})
+		return
+	}
 	if err != nil {
-		http.Error(w, "error parsing simplex method final result: "+err.Error(), http.StatusBadRequest)
+		writeParseError(w, err)
 		return
 	}
-	unsubstitutedSolution, err := retrieveOriginalVariables(numSlack, res.Solution, toPositive, idTableInverse)
+
+	res, err := SolveProgram(ctx, solver, lp, opts)
 	if err != nil {
-		http.Error(w, "error converting final result variables (solution) back to original form: "+err.Error(), http.StatusBadRequest)
+		writeSolveError(w, err)
 		return
 	}
-	res.Solution = unsubstitutedSolution
 
-	if res.ResultType == "unbounded" {
-		unsubstitutedCertificate, err := retrieveOriginalVariables(numSlack, res.Certificate, toPositive, idTableInverse)
+	writeResult(w, res)
+}
+
+// Reads the limits and modes asked for by the request, e.g. /solve?timeLimit=2.5&iterationLimit=1000&exact=true&trace=true
+func solveOptions(query url.Values) (SolveOptions, error) {
+	var opts SolveOptions
+	if value := query.Get(timeLimitQuery); value != "" {
+		seconds, err := strconv.ParseFloat(value, 64)
+		// also rejects NaN, and times too long for a time.Duration
+		if err != nil || !(seconds > 0 && seconds < math.MaxInt64/float64(time.Second)) {
+			return opts, fmt.Errorf("invalid %s: %q (expected a positive number of seconds)", timeLimitQuery, value)
+		}
+		opts.TimeLimit = time.Duration(seconds * float64(time.Second))
+	}
+	if value := query.Get(iterationLimitQuery); value != "" {
+		iterations, err := strconv.Atoi(value)
+		if err != nil || iterations <= 0 {
+			return opts, fmt.Errorf("invalid %s: %q (expected a positive integer)", iterationLimitQuery, value)
+		}
+		opts.IterationLimit = iterations
+	}
+	if value := query.Get(exactQuery); value != "" {
+		exact, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, fmt.Errorf("invalid %s: %q (expected true or false)", exactQuery, value)
+		}
+		opts.Exact = exact
+	}
+	if value := query.Get(traceQuery); value != "" {
+		trace, err := strconv.ParseBool(value)
 		if err != nil {
-			http.Error(w, "error converting final result variables (certificate) back to original form: "+err.Error(), http.StatusBadRequest)
-			return
+			return opts, fmt.Errorf("invalid %s: %q (expected true or false)", traceQuery, value)
 		}
-		res.Certificate = unsubstitutedCertificate
+		opts.Trace = trace
 	}
+	if opts.Exact && opts.Trace {
+		return opts, fmt.Errorf("%s can't be used with %s", traceQuery, exactQuery)
+	}
+
+	return opts, nil
+}
+
+func supportedMediaType(mediaType string, params map[string]string) bool {
+	switch mediaType {
+	case textPlain, applicationCplexLp, applicationJson:
+		return true
+	case applicationMps:
+		_, ok := mpsFormats[params[mpsFormatParam]]
+		return ok
+	default:
+		return false
+	}
+}
 
-	w.Header().Set(contentType, applicationJson)
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(res)
+// Reads the model in the request body by its media type, errors are returned as a *parse_sef.StageError.
+// With exact set, expressions are folded without rounding (MPS and JSON models have no expressions to fold).
+// Expanding the model stops with ctx's error once ctx is done.
+func parseModel(ctx context.Context, mediaType string, params map[string]string, model string, exact bool) (*linear.LinearProgram, error) {
+	switch mediaType {
+	case applicationMps:
+		lp, err := mps.ParseMPS(model, mpsFormats[params[mpsFormatParam]])
+		if err != nil {
+			return nil, &parse_sef.StageError{Stage: parse_sef.StageParse, Err: err}
+		}
+		return lp, nil
+	case applicationCplexLp:
+		prog, err := cplex.ParseLP(model)
+		if err != nil {
+			return nil, &parse_sef.StageError{Stage: parse_sef.StageParse, Err: err}
+		}
+		return parse_sef.LinearizeProgramContext(ctx, prog, exact)
+	case applicationJson:
+		return json_model.ParseJSON([]byte(model))
+	default:
+		return parse_sef.ParseSEFContext(ctx, model, exact)
+	}
 }
@@ -0,0 +1,165 @@
+package solve
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/parse_sef"
+)
+
+// Stages an error response can come from (lex, parse, expand, simplify and semantic match parse_sef.Stage)
+const (
+	stageRequest  = "request"
+	stageLex      = string(parse_sef.StageLex)
+	stageParse    = string(parse_sef.StageParse)
+	stageExpand   = string(parse_sef.StageExpand)
+	stageSimplify = string(parse_sef.StageSimplify)
+	stageSemantic = string(parse_sef.StageSemantic)
+	stageSolve    = "solve"
+)
+
+// Machine-readable error codes
+const (
+	codeNotFound             = "not_found"
+	codeMethodNotAllowed     = "method_not_allowed"
+	codeUnsupportedMediaType = "unsupported_media_type"
+	codeUnknownSolver        = "unknown_solver"
+	codeInvalidOption        = "invalid_option"
+	codeReadFailed           = "read_failed"
+	codeLexError             = "lex_error"
+	codeParseError           = "parse_error"
+	codeExpandError          = "expand_error"
+	codeSimplifyError        = "simplify_error"
+	codeSemanticError        = "semantic_error"
+	codeSolverFailed         = "solver_failed"
+	codeInternalError        = "internal_error"
+)
+
+var stageCodes = map[string]string{
+	stageLex:      codeLexError,
+	stageParse:    codeParseError,
+	stageExpand:   codeExpandError,
+	stageSimplify: codeSimplifyError,
+	stageSemantic: codeSemanticError,
+}
+
+type SourcePosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+// Span of the LP source an error refers to (end is exclusive)
+type SourceSpan struct {
+	Start SourcePosition `json:"start"`
+	End   SourcePosition `json:"end"`
+}
+
+// API error output
+type ErrorResponse struct {
+	Stage   string      `json:"stage"`
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Span    *SourceSpan `json:"span,omitempty"`
+}
+
+func toSourcePosition(p lexer.Position) SourcePosition {
+	return SourcePosition{Line: p.Line, Column: p.Column, Offset: p.Offset}
+}
+
+// Finds the source span of err, if it has one
+func errorSpan(err error) *SourceSpan {
+	var spanErr *lexer.SpanError
+	if !errors.As(err, &spanErr) {
+		return nil
+	}
+
+	return &SourceSpan{Start: toSourcePosition(spanErr.Span.Start), End: toSourcePosition(spanErr.Span.End)}
+}
+
+// Writes body as JSON with status. It is encoded before anything is written, so a body that can't be encoded
+// (e.g. a result with an infinite value) gives an error response instead of an empty one.
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		res := ErrorResponse{Stage: stageSolve, Code: codeInternalError, Message: fmt.Sprintf("error encoding response: %v", err)}
+		buf.Reset()
+		if err := json.NewEncoder(&buf).Encode(res); err != nil {
+			http.Error(w, internalServerError, http.StatusInternalServerError)
+			return
+		}
+		status = http.StatusInternalServerError
+	}
+
+	w.Header().Set(contentType, applicationJson)
+	w.WriteHeader(status)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		// the client is gone, there is no one left to tell
+		log.Printf("error writing response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, res ErrorResponse) {
+	writeJSON(w, status, res)
+}
+
+// Writes a result (200)
+func writeResult(w http.ResponseWriter, res *SimplexResult) {
+	writeJSON(w, http.StatusOK, res)
+}
+
+// Writes an error that isn't about the model itself (e.g. wrong method)
+func writeRequestError(w http.ResponseWriter, status int, code string, message string) {
+	writeError(w, status, ErrorResponse{Stage: stageRequest, Code: code, Message: message})
+}
+
+// Writes an error in the model (422), with the span of source it refers to if there is one
+func writeModelError(w http.ResponseWriter, stage string, err error) {
+	writeError(w, http.StatusUnprocessableEntity, ErrorResponse{
+		Stage:   stage,
+		Code:    stageCodes[stage],
+		Message: err.Error(),
+		Span:    errorSpan(err),
+	})
+}
+
+// Writes an error from ParseSEF, using the stage it failed at
+func writeParseError(w http.ResponseWriter, err error) {
+	var stageErr *parse_sef.StageError
+	if !errors.As(err, &stageErr) {
+		writeError(w, http.StatusInternalServerError, ErrorResponse{Stage: stageParse, Code: codeInternalError, Message: err.Error()})
+		return
+	}
+
+	writeModelError(w, string(stageErr.Stage), stageErr)
+}
+
+// An error from solving a linear program, along with the code to report it with
+type solveError struct {
+	code    string
+	message string
+	err     error
+}
+
+func (e *solveError) Error() string {
+	return e.message + ": " + e.err.Error()
+}
+
+func (e *solveError) Unwrap() error {
+	return e.err
+}
+
+// Writes an error from the solver (or from reading its result), these are not the model's fault (500)
+func writeSolveError(w http.ResponseWriter, err error) {
+	var solveErr *solveError
+	if !errors.As(err, &solveErr) {
+		solveErr = &solveError{code: codeSolverFailed, message: "error calling simplex method", err: err}
+	}
+
+	writeError(w, http.StatusInternalServerError, ErrorResponse{Stage: stageSolve, Code: solveErr.code, Message: solveErr.Error()})
+}
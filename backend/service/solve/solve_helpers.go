@@ -1,15 +1,14 @@
 package solve
 
 import (
-	"bytes"
+	"context"
 	"fmt"
 	"math"
-	"os/exec"
 	"strconv"
-	"strings"
 
+	"github.com/animalat/Simplex-Algorithm/backend/service/simplex"
 	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
-	"github.com/animalat/Simplex-Algorithm/lp_parser/parser"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/linear"
 )
 
 // Array version of linear program (to be converted into input for the Simplex calculator)
@@ -22,12 +21,12 @@ type SimplexProgramArrays struct {
 	numSlack         int
 }
 
-// Input that is passed into the Simplex calculator
-type SimplexProgramStrings struct {
-	objectiveOutput      string
-	objectiveConstOutput string
-	constraintsOutputLHS []string
-	constraintsOutputRHS string
+// Standard equality form of the linear program (maximize c^Tx + z s.t. Ax = b, x >= 0)
+type StandardForm struct {
+	Objective      []float64
+	ObjectiveConst float64
+	ConstraintsLHS [][]float64
+	ConstraintsRHS []float64
 }
 
 // API output
@@ -36,84 +35,90 @@ type SimplexResult struct {
 	ResultType  string         `json:"resultType"`
 	Certificate []float64      `json:"certificate"`
 	Mapping     map[int]string `json:"mapping"`
+	// Duals are the shadow prices of each constraint (by name, or index if unnamed), only when optimal
+	Duals map[string]float64 `json:"duals,omitempty"`
+	// Sensitivity is only set when optimal (and the solver's basis can be found)
+	Sensitivity *SensitivityResult `json:"sensitivity,omitempty"`
+	// BranchAndBound is only set for programs with integer variables
+	BranchAndBound *BranchAndBoundResult `json:"branchAndBound,omitempty"`
+	// Basis is the optimal basis of the standard equality form, if the solver reports it
+	Basis []int `json:"-"`
+	// BasisNames are the columns of Basis by name (see columnNames), only when stopped at a time or iteration limit
+	BasisNames []string `json:"basis,omitempty"`
+	// IIS is a smallest set of constraints that conflict, only when infeasible (and the LP relaxation is infeasible)
+	IIS []IISConstraint `json:"iis,omitempty"`
+	// Ray is only set when unbounded
+	Ray *UnboundedRay `json:"ray,omitempty"`
+	// Verification checks the solver's solution and certificate, it isn't set for results stopped at a limit
+	Verification *Verification `json:"verification,omitempty"`
+	// Exact is only set in exact mode (see SolveOptions.Exact)
+	Exact *ExactResult `json:"exact,omitempty"`
+	// Steps are the solver's pivots in terms of the standard equality form, if asked for (see SolveOptions.Trace)
+	Steps []simplex.Step `json:"-"`
+	// Trace is Steps with named columns
+	Trace *Trace `json:"trace,omitempty"`
 }
 
-// Insert element from an Expr to an array version of that Expr
-func insertElem(objective []float64, objectiveConst *float64, e parser.Expr, idTable map[string]int, isObjective bool) error {
-	switch expr := e.(type) {
-	case *parser.NumberLiteral:
-		if !isObjective {
-			return fmt.Errorf("unexpected NumberLiteral in non-objective Expr: %v", expr)
-		}
-
-		*objectiveConst = expr.Value
-	case *parser.Variable:
-		idx, ok := idTable[expr.ID.Value]
-		if !ok {
-			return fmt.Errorf("undeclared variable: %v", expr)
-		}
-		// 1 because just a variable would be "1"
-		objective[idx] = 1
-	case *parser.BinaryExpr:
-		if expr.Operator.Type != lexer.TokenAsterisk {
-			return fmt.Errorf("unexpected term: %v", expr)
-		}
-
-		// this is the last expr
-		v, ok := expr.Right.(*parser.Variable)
-		if !ok {
-			return fmt.Errorf("unexpected type: %T", expr.Right)
-		}
+// A single variable bound (e.g. x1 >= 0) that was left out of the standard equality form
+type boundRow struct {
+	constraint  int
+	variable    int
+	coefficient float64
+}
 
-		nl, ok := expr.Left.(*parser.NumberLiteral)
-		if !ok {
-			return fmt.Errorf("unexpected type: %T", expr.Left)
-		}
+// How a LinearProgram was laid out in standard equality form, used to map results back to it
+type sefLayout struct {
+	idTableInverse map[int]string
+	// free variables, these take two columns (x = a - b)
+	toPositive map[string]struct{}
+	numSlack   int
+	// rowConstraints[r] is the index of the constraint SEF row r came from
+	rowConstraints []int
+	bounds         []boundRow
+}
 
-		idx, ok := idTable[v.ID.Value]
-		if !ok {
-			return fmt.Errorf("undeclared variable: %v", expr)
+// Variables declared with "let x >= 0;"
+func declaredNonNegative(lp *linear.LinearProgram) map[string]struct{} {
+	nonNegative := make(map[string]struct{})
+	for _, v := range lp.Variables {
+		if v.NonNegative {
+			nonNegative[v.Name] = struct{}{}
 		}
-		objective[idx] = nl.Value
-	default:
-		return fmt.Errorf("unexpected type: %T", expr)
 	}
 
-	return nil
+	return nonNegative
 }
 
-// This function takes an Expr and turns it into an array (and possibly an extra constant for the objective function)
-// Requires: semantics must be run on the program before passing (this is not a semantics check)
-func getExprArr(e parser.Expr, idTable map[string]int, isObjective bool) (float64, []float64, error) {
-	objective := make([]float64, len(idTable))
-	objectiveConst := 0.0
-
-	curExpr := e
+// Determines if a constraint row (row op rhs) bounds a single variable. It returns the variable's index,
+// whether the bound implies the variable is nonnegative, and whether the row says nothing more than that (e.g. x1 >= 0).
+func nonNegativeBound(row []float64, rhs float64, op lexer.TokenType) (int, bool, bool) {
+	idx := -1
+	for i, val := range row {
+		if math.Abs(val) < EPSILON {
+			continue
+		}
+		if idx != -1 {
+			// more than one variable
+			return -1, false, false
+		}
+		idx = i
+	}
 
-	for {
-		switch expr := curExpr.(type) {
-		case *parser.NumberLiteral, *parser.Variable:
-			if err := insertElem(objective, &objectiveConst, expr, idTable, isObjective); err != nil {
-				return 0, nil, err
-			}
-			return objectiveConst, objective, nil
-		case *parser.BinaryExpr:
-			if expr.Operator.Type == lexer.TokenAsterisk {
-				if err := insertElem(objective, &objectiveConst, expr, idTable, isObjective); err != nil {
-					return 0, nil, err
-				}
-				return objectiveConst, objective, nil
-			}
+	if idx == -1 {
+		return -1, false, false
+	}
 
-			// otherwise, we grab the expr, and then check left
-			if err := insertElem(objective, &objectiveConst, expr.Right, idTable, isObjective); err != nil {
-				return 0, nil, err
-			}
+	// the bound is x op' rhs / coefficient, where op' is op flipped for negative coefficients
+	bound := rhs / row[idx]
+	isLowerBound := (op == lexer.TokenGreaterEqual && row[idx] > 0) || (op == lexer.TokenLessEqual && row[idx] < 0)
 
-			curExpr = expr.Left
-		default:
-			return 0, nil, fmt.Errorf("unexpected type: %T", expr)
-		}
+	switch {
+	case isLowerBound:
+		return idx, bound > -EPSILON, math.Abs(bound) < EPSILON
+	case op == lexer.TokenEqual:
+		return idx, bound > -EPSILON, false
+	default:
+		return idx, false, false
 	}
 }
 
@@ -130,154 +135,136 @@ func allFreeVariables(idTable map[string]int, alreadyPositive map[string]struct{
 }
 
 // Uses x := a - b for a, b >= 0 to help turn the LP into standard equality form
-func rowInput(row []float64, toPositive map[string]struct{}, idTableInverse map[int]string) (string, error) {
-	output := ""
+func rowInput(row []float64, toPositive map[string]struct{}, idTableInverse map[int]string) ([]float64, error) {
+	output := make([]float64, 0, len(row)+len(toPositive))
 	for i := 0; i < len(row); i++ {
 		variable, ok := idTableInverse[i]
 		if !ok {
-			return "", fmt.Errorf("invalid variable found at index %d", i)
+			return nil, fmt.Errorf("invalid variable found at index %d", i)
 		}
 
-		output += ftos(row[i]) + " "
+		output = append(output, row[i])
 
 		if _, ok = toPositive[variable]; ok {
 			// we need to add on the subtract too
-			output += ftos(-row[i]) + " "
+			output = append(output, -row[i])
 		}
 	}
 	return output, nil
 }
 
 // Add on slack variables to the linear program (help turn it into standard equality form)
-func getSlackOutput(numSlackAdded *int, constraintSlack float64, numSlack int) (string, error) {
+func getSlackOutput(numSlackAdded *int, constraintSlack float64, numSlack int) ([]float64, error) {
+	slack := make([]float64, numSlack)
 	if math.Abs(constraintSlack) < EPSILON {
 		// no slack variable
-		return strings.Repeat("0 ", numSlack), nil
+		return slack, nil
 	}
 
 	if *numSlackAdded >= numSlack {
-		return "", fmt.Errorf("extra unexpected slack variable: %.2f", constraintSlack)
+		return nil, fmt.Errorf("extra unexpected slack variable: %.2f", constraintSlack)
 	}
 
-	rightZeroAmount := numSlack - 1 - (*numSlackAdded)
-	slackStr := strings.Repeat("0 ", *numSlackAdded) + ftos(constraintSlack) + " " + strings.Repeat("0 ", rightZeroAmount)
+	slack[*numSlackAdded] = constraintSlack
 	*numSlackAdded++
-	return slackStr, nil
+	return slack, nil
 }
 
-// Prepares linear program to be passed into Simplex calculator (gets string to input)
-func simplexInput(progArrays SimplexProgramArrays, toPositive map[string]struct{}, idTable map[string]int, idTableInverse map[int]string) (SimplexProgramStrings, error) {
-	objective := progArrays.objective
-	objectiveConst := progArrays.objectiveConst
-	constraintsLHS := progArrays.constraintsLHS
-	constraintsRHS := progArrays.constraintsRHS
-	constraintsSlack := progArrays.constraintsSlack
-	numSlack := progArrays.numSlack
-
-	objectiveOutput, err := rowInput(objective, toPositive, idTableInverse)
+// Prepares linear program to be passed into Simplex calculator (gets standard equality form)
+func simplexInput(progArrays SimplexProgramArrays, toPositive map[string]struct{}, idTableInverse map[int]string) (*StandardForm, error) {
+	objectiveOutput, err := rowInput(progArrays.objective, toPositive, idTableInverse)
 	if err != nil {
-		return SimplexProgramStrings{}, err
+		return nil, err
 	}
-	objectiveOutput += strings.Repeat("0 ", numSlack)
-	objectiveConstOutput := ftos(objectiveConst)
+	objectiveOutput = append(objectiveOutput, make([]float64, progArrays.numSlack)...)
 
 	numSlackAdded := 0
-	constraintsOutputLHS := make([]string, 0, len(constraintsLHS))
-	for i := range constraintsLHS {
-		curRowOutput, err := rowInput(constraintsLHS[i], toPositive, idTableInverse)
+	constraintsOutputLHS := make([][]float64, 0, len(progArrays.constraintsLHS))
+	for i := range progArrays.constraintsLHS {
+		curRowOutput, err := rowInput(progArrays.constraintsLHS[i], toPositive, idTableInverse)
 		if err != nil {
-			return SimplexProgramStrings{}, err
+			return nil, err
 		}
-		endingStr, err := getSlackOutput(&numSlackAdded, constraintsSlack[i], numSlack)
+		slackOutput, err := getSlackOutput(&numSlackAdded, progArrays.constraintsSlack[i], progArrays.numSlack)
 		if err != nil {
-			return SimplexProgramStrings{}, err
+			return nil, err
 		}
-		curRowOutput += endingStr + "\n"
-		constraintsOutputLHS = append(constraintsOutputLHS, curRowOutput)
+		constraintsOutputLHS = append(constraintsOutputLHS, append(curRowOutput, slackOutput...))
 	}
 
-	constraintsOutputRHS := ""
-	for _, val := range constraintsRHS {
-		constraintsOutputRHS += ftos(val) + " "
+	return &StandardForm{
+		Objective:      objectiveOutput,
+		ObjectiveConst: progArrays.objectiveConst,
+		ConstraintsLHS: constraintsOutputLHS,
+		ConstraintsRHS: append([]float64(nil), progArrays.constraintsRHS...),
+	}, nil
+}
+
+// Key of each constraint in the duals, its name or its index in the source if it has none
+// (with ".range" for the second half of a ranged constraint, see linear.Constraint.IsRange)
+func constraintKeys(lp *linear.LinearProgram) []string {
+	keys := make([]string, len(lp.Constraints))
+	indices := lp.SourceIndices()
+	for i, constraint := range lp.Constraints {
+		switch {
+		case constraint.Name != "":
+			keys[i] = constraint.Name
+		case constraint.IsRange:
+			keys[i] = strconv.Itoa(indices[i]) + ".range"
+		default:
+			keys[i] = strconv.Itoa(indices[i])
+		}
 	}
 
-	return SimplexProgramStrings{
-		objectiveOutput:      objectiveOutput,
-		objectiveConstOutput: objectiveConstOutput,
-		constraintsOutputLHS: constraintsOutputLHS,
-		constraintsOutputRHS: constraintsOutputRHS,
-	}, nil
+	return keys
 }
 
-// Calls the Simplex calculator (C++)
-func callSimplex(progStrings SimplexProgramStrings, rowSize string, colSize string) (string, error) {
-	objectiveOutput := progStrings.objectiveOutput
-	objectiveConstOutput := progStrings.objectiveConstOutput
-	constraintsOutputLHS := progStrings.constraintsOutputLHS
-	constraintsOutputRHS := progStrings.constraintsOutputRHS
-
-	cmd := exec.Command("../../simplex_core/simplex_solver")
-
-	input := ""
-	// main matrix, LHS constraints (A)
-	input += rowSize + "\n" + colSize + "\n"
-	for _, curStr := range constraintsOutputLHS {
-		input += curStr + "\n"
-	}
-	// RHS constraints (B)
-	input += rowSize + "\n1\n"
-	input += constraintsOutputRHS + "\n"
-	// objective (C)
-	input += "1\n" + colSize + "\n"
-	input += objectiveOutput + "\n"
-	// objective constant (z)
-	input += objectiveConstOutput + "\n"
-
-	cmd.Stdin = bytes.NewBufferString(input)
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("error running solver: %v\noutput: %s", err, string(output))
+// Index of the SEF column for variable idx (free variables take two columns)
+func sefColumn(idx int, toPositive map[string]struct{}, idTableInverse map[int]string) int {
+	col := 0
+	for i := 0; i < idx; i++ {
+		col++
+		if _, ok := toPositive[idTableInverse[i]]; ok {
+			col++
+		}
 	}
 
-	return string(output), nil
+	return col
 }
 
-// Gets the raw result from the Simplex calculator
-func parseResult(output string, idTableInverse map[int]string) (SimplexResult, error) {
-	tokens := strings.Fields(output)
+// Determines the shadow price of every constraint from the certificate y (c - y^TA <= 0) of an optimal result.
+// Prices are in terms of the original objective, so they are negated for MIN.
+// Bounds that were left out of the SEF get theirs from the reduced cost of their variable,
+// which goes to the first such bound of each variable (the rest are 0, as they only repeat it).
+func constraintDuals(lp *linear.LinearProgram, sef *StandardForm, y []float64, layout *sefLayout) map[string]float64 {
+	sign := 1.0
+	if !lp.Objective.IsMax {
+		sign = -1.0
+	}
 
-	var solution []float64
-	var resultType string
-	var certificate []float64
+	keys := constraintKeys(lp)
+	duals := make(map[string]float64, len(lp.Constraints))
+	for r, i := range layout.rowConstraints {
+		duals[keys[i]] = cleanZero(sign * y[r])
+	}
 
-	readTypeAlready := false
-	for _, token := range tokens {
-		if token == "optimal" || token == "infeasible" || token == "unbounded" {
-			resultType = token
-			readTypeAlready = true
+	priced := make(map[int]struct{}, len(layout.bounds))
+	for _, bound := range layout.bounds {
+		if _, ok := priced[bound.variable]; ok {
+			duals[keys[bound.constraint]] = 0
 			continue
 		}
+		priced[bound.variable] = struct{}{}
 
-		const doubleSize = 64
-		numToAppend, err := strconv.ParseFloat(token, doubleSize)
-		if err != nil {
-			return SimplexResult{}, fmt.Errorf("error converting output into token: %v", err)
-		}
-
-		if !readTypeAlready {
-			solution = append(solution, numToAppend)
-		} else {
-			certificate = append(certificate, numToAppend)
+		col := sefColumn(bound.variable, layout.toPositive, layout.idTableInverse)
+		reducedCost := sef.Objective[col]
+		for r := range sef.ConstraintsLHS {
+			reducedCost -= y[r] * sef.ConstraintsLHS[r][col]
 		}
+		duals[keys[bound.constraint]] = cleanZero(sign * reducedCost / bound.coefficient)
 	}
 
-	return SimplexResult{
-		Solution:    solution,
-		ResultType:  resultType,
-		Certificate: certificate,
-		Mapping:     idTableInverse,
-	}, nil
+	return duals
 }
 
 // Determines the optimal values of the Linear Program using the output of the Simplex calculator
@@ -311,3 +298,210 @@ func retrieveOriginalVariables(numSlack int, arr []float64, toPositive map[strin
 
 	return newSolution, nil
 }
+
+// Converts a LinearProgram into standard equality form, along with the layout needed to map results back
+func standardForm(lp *linear.LinearProgram) (*StandardForm, *sefLayout, error) {
+	idTable := lp.IdTable()
+	objective := lp.Objective.Expr.Dense(idTable)
+	objectiveConst := lp.Objective.Expr.Constant
+	if !lp.Objective.IsMax {
+		// flip sign to make it a maximization problem
+		for i := range objective {
+			objective[i] *= -1
+		}
+		objectiveConst *= -1
+	}
+
+	layout := &sefLayout{idTableInverse: getTableInverse(idTable)}
+	alreadyPositive := declaredNonNegative(lp)
+	constraintsLHS := make([][]float64, 0, len(lp.Constraints))
+	constraintsRHS := make([]float64, 0, len(lp.Constraints))
+	constraintsSlack := make([]float64, 0, len(lp.Constraints))
+	for i, constraint := range lp.Constraints {
+		row := constraint.Left.Dense(idTable)
+
+		// single variable bounds like x1 >= 0 make the variable nonnegative instead of becoming a row
+		idx, isNonNegative, isRedundant := nonNegativeBound(row, constraint.Right, constraint.Operator)
+		if isNonNegative {
+			alreadyPositive[layout.idTableInverse[idx]] = struct{}{}
+		}
+		if isRedundant {
+			layout.bounds = append(layout.bounds, boundRow{constraint: i, variable: idx, coefficient: row[idx]})
+			continue
+		}
+
+		var slack float64
+		switch constraint.Operator {
+		case lexer.TokenLessEqual:
+			layout.numSlack++
+			// 1 because we're adding on just a ("1 * s") slack variable
+			slack = 1
+		case lexer.TokenEqual:
+			slack = 0
+		case lexer.TokenGreaterEqual:
+			layout.numSlack++
+			// -1 because we're subtracting a slack variable ("-1 * s")
+			slack = -1
+		default:
+			// shouldn't have any other operator types
+			return nil, nil, lexer.Errorf(constraint.Span, "invalid comparison operator on constraint row %d", i)
+		}
+
+		constraintsLHS = append(constraintsLHS, row)
+		constraintsRHS = append(constraintsRHS, constraint.Right)
+		constraintsSlack = append(constraintsSlack, slack)
+		layout.rowConstraints = append(layout.rowConstraints, i)
+	}
+
+	layout.toPositive = allFreeVariables(idTable, alreadyPositive)
+	progArrays := SimplexProgramArrays{
+		objective:        objective,
+		objectiveConst:   objectiveConst,
+		constraintsLHS:   constraintsLHS,
+		constraintsRHS:   constraintsRHS,
+		constraintsSlack: constraintsSlack,
+		numSlack:         layout.numSlack,
+	}
+
+	sef, err := simplexInput(progArrays, layout.toPositive, layout.idTableInverse)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return sef, layout, nil
+}
+
+// Whether the solver stopped at a time or iteration limit
+func stoppedAtLimit(resultType string) bool {
+	return resultType == string(simplex.TimeLimit) || resultType == string(simplex.IterationLimit)
+}
+
+// Names of the columns of sef: variables (a free variable x takes two columns, x.pos and x.neg), then slack variables
+// named after their constraint (e.g. cap.slack), then the artificial columns the solver adds for each row (e.g. cap.artificial)
+func columnNames(lp *linear.LinearProgram, sef *StandardForm, layout *sefLayout) []string {
+	names := make([]string, 0, len(sef.Objective)+len(sef.ConstraintsLHS))
+	for i := 0; i < len(layout.idTableInverse); i++ {
+		name := layout.idTableInverse[i]
+		if _, ok := layout.toPositive[name]; ok {
+			names = append(names, name+".pos", name+".neg")
+		} else {
+			names = append(names, name)
+		}
+	}
+
+	keys := constraintKeys(lp)
+	for _, i := range layout.rowConstraints {
+		if lp.Constraints[i].Operator != lexer.TokenEqual {
+			names = append(names, keys[i]+".slack")
+		}
+	}
+	for _, i := range layout.rowConstraints {
+		names = append(names, keys[i]+".artificial")
+	}
+
+	return names
+}
+
+// Maps a result of solving sef back onto the variables (and constraints) of lp
+func originalResult(lp *linear.LinearProgram, sef *StandardForm, layout *sefLayout, res *SimplexResult) error {
+	res.Mapping = layout.idTableInverse
+
+	if stoppedAtLimit(res.ResultType) && res.Basis != nil {
+		names := columnNames(lp, sef, layout)
+		for _, col := range res.Basis {
+			if col >= len(names) {
+				return fmt.Errorf("basis column %d out of range", col)
+			}
+			res.BasisNames = append(res.BasisNames, names[col])
+		}
+	}
+
+	if res.Steps != nil {
+		trace, err := namedTrace(lp, sef, layout, res.Steps)
+		if err != nil {
+			return err
+		}
+		res.Trace = trace
+	}
+
+	if res.ResultType == "optimal" {
+		res.Duals = constraintDuals(lp, sef, res.Certificate, layout)
+		res.Sensitivity = sensitivityAnalysis(lp, sef, layout, res.Solution, res.Basis)
+	}
+	if res.ResultType == "unbounded" {
+		ray, err := unboundedRay(lp, sef, layout, res.Certificate, res.Solution)
+		if err != nil {
+			return fmt.Errorf("error converting the unbounded ray back to original form: %w", err)
+		}
+		res.Ray = ray
+	}
+	unsubstitutedSolution, err := retrieveOriginalVariables(layout.numSlack, res.Solution, layout.toPositive, layout.idTableInverse)
+	if err != nil {
+		return fmt.Errorf("error converting final result variables (solution) back to original form: %w", err)
+	}
+	res.Solution = unsubstitutedSolution
+
+	if res.ResultType == "unbounded" {
+		unsubstitutedCertificate, err := retrieveOriginalVariables(layout.numSlack, res.Certificate, layout.toPositive, layout.idTableInverse)
+		if err != nil {
+			return fmt.Errorf("error converting final result variables (certificate) back to original form: %w", err)
+		}
+		res.Certificate = unsubstitutedCertificate
+	}
+	res.Verification = verifyResult(lp, layout, res)
+
+	return nil
+}
+
+// Solves the LP (relaxation) lp with solver, giving the result in terms of lp's variables.
+// Errors are returned as a *solveError.
+func solveLinearProgram(ctx context.Context, solver Solver, lp *linear.LinearProgram, opts SolveOptions) (*SimplexResult, error) {
+	sef, layout, err := standardForm(lp)
+	if err != nil {
+		return nil, &solveError{code: codeInternalError, message: "error converting linear program into standard equality form", err: err}
+	}
+
+	var res *SimplexResult
+	if opts.Exact {
+		res, err = solveExact(ctx, lp, layout, opts)
+	} else {
+		res, err = solver.Solve(ctx, sef, opts)
+	}
+	if err != nil {
+		return nil, &solveError{code: codeSolverFailed, message: "error calling simplex method", err: err}
+	}
+
+	if err := originalResult(lp, sef, layout, res); err != nil {
+		return nil, &solveError{code: codeInternalError, message: "error mapping result back to the linear program", err: err}
+	}
+
+	return res, nil
+}
+
+// SolveProgram solves lp with solver, using branch-and-bound if it has integer variables.
+// It stops with "timeLimit" once ctx's deadline passes (the caller sets it from opts.TimeLimit, see SolveOptions)
+// or "iterationLimit" at opts.IterationLimit, giving the best solution found so far (if any).
+// If lp is infeasible, the result has the constraints that conflict (see irreducibleInfeasibleSubsystem).
+// Errors are from the solver (not the model), HandleSolve reports them with a 500.
+func SolveProgram(ctx context.Context, solver Solver, lp *linear.LinearProgram, opts SolveOptions) (*SimplexResult, error) {
+	var res *SimplexResult
+	var err error
+	if lp.HasIntegers() {
+		res, err = branchAndBound(ctx, solver, lp, opts)
+	} else {
+		res, err = solveLinearProgram(ctx, solver, lp, opts)
+	}
+	if err != nil || res.ResultType != "infeasible" {
+		return res, err
+	}
+
+	if res.BranchAndBound != nil && res.BranchAndBound.Nodes > 1 {
+		// the LP relaxation is feasible, only the integer program isn't
+		return res, nil
+	}
+	if res.IIS, err = irreducibleInfeasibleSubsystem(ctx, solver, lp, opts); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
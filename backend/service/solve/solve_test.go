@@ -2,45 +2,31 @@ package solve
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/http/httptest"
-	"strings"
 	"testing"
+	"time"
 
+	"github.com/animalat/Simplex-Algorithm/backend/service/simplex"
 	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
-	"github.com/animalat/Simplex-Algorithm/lp_parser/parser"
-	"github.com/animalat/Simplex-Algorithm/lp_parser/semantics"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/parse_sef"
 )
 
 func assertProg(t *testing.T, s string, objectiveConstWanted float64, objectiveWanted []float64) {
 	t.Helper()
 
-	tokens, err := lexer.Tokenize(strings.NewReader(s))
-	if err != nil {
-		t.Fatalf("Tokenize() error: %v", err)
-	}
-
-	p := &parser.Parser{Tokens: tokens}
-	prog, err := p.ParseProgram()
+	lp, err := parse_sef.ParseSEF(s)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	idTable, err := semantics.SemanticCheck(prog)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-
-	if testing.Verbose() {
-		parser.PrintParse(prog)
-	}
-
-	objectiveConst, objective, err := getExprArr(prog.Objective.Expr, idTable, enableObjective)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
+	objectiveConst := lp.Objective.Expr.Constant
+	objective := lp.Objective.Expr.Dense(lp.IdTable())
 
 	// check const
 	if !floatsEqual(objectiveConst, objectiveConstWanted) {
@@ -59,10 +45,15 @@ func assertProg(t *testing.T, s string, objectiveConstWanted float64, objectiveW
 	}
 }
 
-func assertPostRequest(t *testing.T, body []byte, solutionWanted []float64, resultTypeWanted string, certificateWanted []float64) {
+func assertPostRequest(t *testing.T, body []byte, solutionWanted []float64, resultTypeWanted string, certificateWanted []float64) SimplexResult {
+	t.Helper()
+	return assertPostRequestAs(t, body, textPlain, solutionWanted, resultTypeWanted, certificateWanted)
+}
+
+func assertPostRequestAs(t *testing.T, body []byte, contentTypeSent string, solutionWanted []float64, resultTypeWanted string, certificateWanted []float64) SimplexResult {
 	t.Helper()
 	req := httptest.NewRequest(http.MethodPost, solvePath, bytes.NewReader(body))
-	req.Header.Set(contentType, textPlain)
+	req.Header.Set(contentType, contentTypeSent)
 	w := httptest.NewRecorder()
 
 	HandleSolve(w, req)
@@ -113,9 +104,26 @@ func assertPostRequest(t *testing.T, body []byte, solutionWanted []float64, resu
 			t.Fatalf("certificates not equal at index %d: wanted %.2f, received %.2f", i, certificateWanted[i], output.Certificate[i])
 		}
 	}
+
+	return output
+}
+
+func assertDuals(t *testing.T, output SimplexResult, dualsWanted map[string]float64) {
+	t.Helper()
+
+	if len(output.Duals) != len(dualsWanted) {
+		t.Fatalf("duals wanted %v, received %v", dualsWanted, output.Duals)
+	}
+
+	for key, want := range dualsWanted {
+		got, ok := output.Duals[key]
+		if !ok || !floatsEqualWithError(want, got, PRECISIONERROR) {
+			t.Fatalf("dual of %q not equal: wanted %.2f, received %.2f (found: %v)", key, want, got, ok)
+		}
+	}
 }
 
-func TestSolve_GetExprArr(t *testing.T) {
+func TestSolve_ObjectiveArray(t *testing.T) {
 	assertProg(t, "let x1; let x2; let x3; max x1 + x2 + 3; s.t. x1 + x2 <= 3; x1 + x2 + 3 * x3 >= 5;", 3, []float64{1, 1, 0})
 	assertProg(t, "let x1; let x2; let x3; max 4 * x1 + 5 * x3; s.t. 5 * x1 + 3 * x2 <= 3; x1 + x2 + 3 * x3 >= 5;", 0, []float64{4, 0, 5})
 	assertProg(t, "let x1; let x2; let x3; let x4; max 4 * x1 + x2 + 0 * x3 + 5 * x4 + 100; s.t. 5 * x1 + 3 * x2 <= 3; x1 + x2 + 3 * x3 >= 5;", 100, []float64{4, 1, 0, 5})
@@ -123,6 +131,271 @@ func TestSolve_GetExprArr(t *testing.T) {
 }
 
 func TestSolve_PostRequest(t *testing.T) {
-	assertPostRequest(t, []byte("let x1; max 4 * x1; s.t. 4 * x1 <= 5; x1 >= 0;"), []float64{1.25}, "optimal", []float64{1.00, 0.00})
-	assertPostRequest(t, []byte("let x1; let x2; let x3; let x4; max 4 * x1 + x2 + 0 * x3 + 5 * x4 + 100; s.t. 5 * x1 + 3 * x2 <= 3; x1 + x2 + 3 * x3 >= 5;"), []float64{3.0 / 5.0, 0, 88.0 / 60.0, 0}, "unbounded", []float64{3.0 / 5.0, 0, 0, 1.0, 4.0 / 30.0, 0, 0, 0, 0, 0})
+	assertPostRequest(t, []byte("let x1; max 4 * x1; s.t. 4 * x1 <= 5; x1 >= 0;"), []float64{1.25}, "optimal", []float64{1.00})
+	assertPostRequest(t, []byte("let x1; max 0.5 * x1; s.t. 4e-1 * x1 <= .5; x1 >= 0;"), []float64{1.25}, "optimal", []float64{1.25})
+	assertPostRequest(t, []byte("let x1; let x2; let x3; let x4; max 4 * x1 + x2 + 0 * x3 + 5 * x4 + 100; s.t. 5 * x1 + 3 * x2 <= 3; x1 + x2 + 3 * x3 >= 5;"), []float64{3.0 / 5.0, 0, 88.0 / 60.0, 0}, "unbounded", []float64{3.0 / 5.0, -1.0, 4.0 / 30.0, 0})
+	assertPostRequest(t, []byte("let x1; let x2; max x1 + x2; s.t. x1 + x2 <= 2; x1 >= 0; x2 >= 0; x1 + x2 >= 3;"), []float64{}, "infeasible", []float64{1, -1})
+	assertPostRequest(t, []byte("let x1 >= 0; let x2; max x1 + -x2; s.t. x1 <= 3; -x2 <= -2;"), []float64{3, 2}, "optimal", []float64{1, 1})
+	assertPostRequest(t, []byte("let x1; let x2; max -x1; s.t. x1 >= 2; x2 = 1; x1 + x2 <= 10;"), []float64{2, 1}, "optimal", []float64{-1, 0, 0})
+}
+
+func TestSolve_Indexed(t *testing.T) {
+	output := assertPostRequest(t, []byte("let x[1..3] >= 0; max sum{i in 1..3} i * x[i]; s.t. total: sum{i in 1..3} x[i] <= 3; forall{i in 1..3}: cap: x[i] <= 2;"), []float64{0, 1, 2}, "optimal", []float64{2, 0, 0, 1})
+	for i, name := range []string{"x[1]", "x[2]", "x[3]"} {
+		if output.Mapping[i] != name {
+			t.Fatalf("expected %s at index %d, received %s", name, i, output.Mapping[i])
+		}
+	}
+	assertDuals(t, output, map[string]float64{"total": 2, "cap[1]": 0, "cap[2]": 0, "cap[3]": 1})
+}
+
+func TestSolve_Params(t *testing.T) {
+	input := "param n = 3; param c[1..n] = {1, 2, 3}; param limit = n * 2 / 3; let x[1..n] >= 0; max sum{i in 1..n} c[i] * x[i]; " +
+		"s.t. total: sum{i in 1..n} x[i] <= n; forall{i in 1..n}: cap: x[i] <= limit;"
+	output := assertPostRequest(t, []byte(input), []float64{0, 1, 2}, "optimal", []float64{2, 0, 0, 1})
+	assertDuals(t, output, map[string]float64{"total": 2, "cap[1]": 0, "cap[2]": 0, "cap[3]": 1})
+}
+
+func TestSolve_Chained(t *testing.T) {
+	input := "let x[1..3]; max x[1] + 2 * x[2] + 3 * x[3]; s.t. total: x[1] + x[2] + x[3] <= 3; forall{i in 1..3}: box: 0 <= x[i] <= 2;"
+	output := assertPostRequest(t, []byte(input), []float64{0, 1, 2}, "optimal", []float64{2, 0, 0, 1})
+	assertDuals(t, output, map[string]float64{"total": 2, "box[1]": -1, "box[1].range": 0, "box[2]": 0, "box[2].range": 0, "box[3]": 0, "box[3].range": 1})
+
+	// unnamed chained constraints keep one index, the second half is keyed like "0.range"
+	output = assertPostRequest(t, []byte("let x; let y; max x + y; s.t. 0 <= x <= 1; y <= 2;"), []float64{1, 2}, "optimal", []float64{1, 1})
+	assertDuals(t, output, map[string]float64{"0": 0, "0.range": 1, "1": 1})
+}
+
+func TestSolve_Limits(t *testing.T) {
+	input := "let x1 >= 0; let x2 >= 0; let x3 >= 0; max x1 + 2 * x2 + 3 * x3; s.t. total: x1 + x2 + x3 <= 3; cap: x3 <= 2;"
+	req := httptest.NewRequest(http.MethodPost, solvePath+"?iterationLimit=2&timeLimit=60", bytes.NewReader([]byte(input)))
+	req.Header.Set(contentType, textPlain)
+	w := httptest.NewRecorder()
+
+	HandleSolve(w, req)
+
+	var output SimplexResult
+	if err := json.NewDecoder(w.Result().Body).Decode(&output); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if output.ResultType != string(simplex.IterationLimit) {
+		t.Fatalf("expected resultType %s, received type %v", simplex.IterationLimit, output.ResultType)
+	}
+	if fmt.Sprint(output.Solution) != "[1 0 2]" || fmt.Sprint(output.BasisNames) != "[x1 x3]" {
+		t.Fatalf("expected solution [1 0 2] with basis [x1 x3], received %v with basis %v", output.Solution, output.BasisNames)
+	}
+
+	lp, err := parse_sef.ParseSEF(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	res, err := SolveProgram(expired, GoSolver{}, lp, SolveOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.ResultType != string(simplex.TimeLimit) || res.Solution != nil || len(res.BasisNames) != 2 {
+		t.Fatalf("expected resultType %s without a solution, received %v (solution %v, basis %v)", simplex.TimeLimit, res.ResultType, res.Solution, res.BasisNames)
+	}
+
+	lp, err = parse_sef.ParseSEF("let int x1 >= 0; let int x2 >= 0; max x1 + x2; s.t. 2 * x1 + 2 * x2 <= 3;")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res, err = SolveProgram(context.Background(), GoSolver{}, lp, SolveOptions{IterationLimit: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.ResultType != string(simplex.IterationLimit) || res.BranchAndBound == nil {
+		t.Fatalf("expected branch-and-bound to stop with %s, received %v", simplex.IterationLimit, res.ResultType)
+	}
+
+	// the time limit also stops expanding the model
+	large := "let x[1..1000]; max x[1]; s.t. forall{i in 1..1000}: sum{j in 1..999} x[j] <= i;"
+	req = httptest.NewRequest(http.MethodPost, solvePath+"?timeLimit=0.001", bytes.NewReader([]byte(large)))
+	req.Header.Set(contentType, textPlain)
+	w = httptest.NewRecorder()
+	HandleSolve(w, req)
+	output = SimplexResult{}
+	if err := json.NewDecoder(w.Result().Body).Decode(&output); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if w.Code != http.StatusOK || output.ResultType != string(simplex.TimeLimit) {
+		t.Fatalf("expected resultType %s while expanding, received status %d and type %v", simplex.TimeLimit, w.Code, output.ResultType)
+	}
+
+	for _, query := range []string{"?timeLimit=0", "?timeLimit=abc", "?timeLimit=1e300", "?iterationLimit=-1", "?iterationLimit=1.5"} {
+		req := httptest.NewRequest(http.MethodPost, solvePath+query, bytes.NewReader([]byte(input)))
+		req.Header.Set(contentType, textPlain)
+		w := httptest.NewRecorder()
+
+		HandleSolve(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected status %d, got %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestSolve_MPS(t *testing.T) {
+	free := "NAME example\nOBJSENSE MAX\nROWS\n N profit\n L total\n L cap3\nCOLUMNS\n" +
+		" x1 profit 1 total 1\n x2 profit 2 total 1\n x3 profit 3 total 1\n x3 cap3 1\n" +
+		"RHS\n rhs total 3 cap3 2\nBOUNDS\n UP bnd x1 2\n UP bnd x2 2\nENDATA\n"
+	output := assertPostRequestAs(t, []byte(free), applicationMps, []float64{0, 1, 2}, "optimal", []float64{2, 1, 0, 0})
+	for i, name := range []string{"x1", "x2", "x3"} {
+		if output.Mapping[i] != name {
+			t.Fatalf("expected %s at index %d, received %s", name, i, output.Mapping[i])
+		}
+	}
+	assertDuals(t, output, map[string]float64{"total": 2, "cap3": 1, "x1.up": 0, "x2.up": 0})
+
+	fixed := "NAME          EXAMPLE\nROWS\n N  COST\n G  LIM 1\nCOLUMNS\n    X ONE     COST      1\n    X ONE     LIM 1     1\n" +
+		"RHS\n              LIM 1     4\nENDATA\n"
+	assertPostRequestAs(t, []byte(fixed), applicationMps+"; format=fixed", []float64{4}, "optimal", []float64{-1})
+}
+
+func TestSolve_CplexLP(t *testing.T) {
+	input := "Maximize\n obj: x1 + 2 x2 + 3 x3\nSubject To\n total: x1 + x2 + x3 <= 3\n cap3: x3 <= 2\nBounds\n x1 <= 2\n x2 <= 2\nEnd\n"
+	output := assertPostRequestAs(t, []byte(input), applicationCplexLp, []float64{0, 1, 2}, "optimal", []float64{2, 1, 0, 0})
+	assertDuals(t, output, map[string]float64{"total": 2, "cap3": 1, "x1.up": 0, "x2.up": 0})
+}
+
+func TestSolve_JSON(t *testing.T) {
+	input := `{"variables": [{"name": "x1", "lower": 0, "upper": 2}, {"name": "x2", "lower": 0, "upper": 2}, {"name": "x3", "lower": 0}],
+		"objective": {"sense": "max", "coefficients": {"x1": 1, "x2": 2, "x3": 3}},
+		"constraints": [{"name": "total", "coefficients": {"x1": 1, "x2": 1, "x3": 1}, "operator": "<=", "rhs": 3},
+			{"name": "cap3", "coefficients": {"x3": 1}, "operator": "<=", "rhs": 2}]}`
+	output := assertPostRequestAs(t, []byte(input), applicationJson, []float64{0, 1, 2}, "optimal", []float64{2, 1, 0, 0})
+	assertDuals(t, output, map[string]float64{"total": 2, "cap3": 1, "x1.up": 0, "x2.up": 0})
+}
+
+func assertErrorResponse(t *testing.T, body []byte, contentTypeSent string, statusWanted int, stageWanted string, codeWanted string, spanWanted *SourceSpan) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, solvePath, bytes.NewReader(body))
+	req.Header.Set(contentType, contentTypeSent)
+	w := httptest.NewRecorder()
+
+	HandleSolve(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != statusWanted {
+		t.Fatalf("expected status %d, got %d", statusWanted, res.StatusCode)
+	}
+
+	if res.Header.Get(contentType) != applicationJson {
+		t.Fatalf("expected %s %s, got %s", contentType, applicationJson, res.Header.Get(contentType))
+	}
+
+	var output ErrorResponse
+	if err := json.NewDecoder(res.Body).Decode(&output); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if output.Stage != stageWanted || output.Code != codeWanted {
+		t.Fatalf("expected stage %s and code %s, received stage %s and code %s (%s)", stageWanted, codeWanted, output.Stage, output.Code, output.Message)
+	}
+
+	if output.Message == "" {
+		t.Fatalf("expected an error message")
+	}
+
+	if spanWanted == nil {
+		return
+	}
+	if output.Span == nil {
+		t.Fatalf("expected span %+v, received none", *spanWanted)
+	}
+	if output.Span.Start != spanWanted.Start || output.Span.End.Column != spanWanted.End.Column {
+		t.Fatalf("expected span %+v, received %+v", *spanWanted, *output.Span)
+	}
+}
+
+func TestSolve_Duals(t *testing.T) {
+	output := assertPostRequest(t, []byte("let x1; let x2; max 3 * x1 + 2 * x2; s.t. capacity: x1 + x2 <= 4; labour: x1 + 3 * x2 <= 6; x1 >= 0; x2 >= 0;"), []float64{4, 0}, "optimal", []float64{3, 0})
+	assertDuals(t, output, map[string]float64{"capacity": 3, "labour": 0, "2": 0, "3": -1})
+
+	output = assertPostRequest(t, []byte("let x1 >= 0; let x2 >= 0; min -3 * x1 + -2 * x2; s.t. capacity: x1 + x2 <= 4; x1 + 3 * x2 <= 6;"), []float64{4, 0}, "optimal", []float64{3, 0})
+	assertDuals(t, output, map[string]float64{"capacity": -3, "1": 0})
+
+	// repeated bounds on one variable share its reduced cost rather than each taking all of it
+	output = assertPostRequest(t, []byte("let x; max -x; s.t. a: x >= 0; b: x >= 0; c: 2 * x >= 0;"), []float64{0}, "optimal", []float64{})
+	assertDuals(t, output, map[string]float64{"a": -1, "b": 0, "c": 0})
+	if output.Verification == nil || !output.Verification.Verified {
+		t.Fatalf("expected repeated bounds to be verified, received %+v", output.Verification)
+	}
+
+	output = assertPostRequest(t, []byte("let x1; max x1; s.t. c: x1 <= 1; x1 >= 3;"), []float64{}, "infeasible", []float64{1, -1})
+	if output.Duals != nil {
+		t.Fatalf("expected no duals for infeasible result, received %v", output.Duals)
+	}
+}
+
+func TestSolve_NonNegativeBound(t *testing.T) {
+	tests := []struct {
+		row                      []float64
+		rhs                      float64
+		op                       lexer.TokenType
+		idx                      int
+		isNonNegative, redundant bool
+	}{
+		{[]float64{0, 2}, 0, lexer.TokenGreaterEqual, 1, true, true},
+		{[]float64{-3, 0}, 0, lexer.TokenLessEqual, 0, true, true},
+		{[]float64{1, 0}, 4, lexer.TokenGreaterEqual, 0, true, false},
+		{[]float64{1, 0}, -4, lexer.TokenGreaterEqual, 0, false, false},
+		{[]float64{1, 0}, 0, lexer.TokenLessEqual, 0, false, false},
+		{[]float64{0, -1}, -5, lexer.TokenEqual, 1, true, false},
+		{[]float64{1, 1}, 0, lexer.TokenGreaterEqual, -1, false, false},
+	}
+
+	for i, test := range tests {
+		idx, isNonNegative, redundant := nonNegativeBound(test.row, test.rhs, test.op)
+		if idx != test.idx || isNonNegative != test.isNonNegative || redundant != test.redundant {
+			t.Errorf("case %d: got (%d, %v, %v), want (%d, %v, %v)", i, idx, isNonNegative, redundant, test.idx, test.isNonNegative, test.redundant)
+		}
+	}
+}
+
+func TestSolve_ErrorResponses(t *testing.T) {
+	assertErrorResponse(t, []byte("let x1; max x1; s.t. x1 <= 1;"), "application/xml", http.StatusUnsupportedMediaType, stageRequest, codeUnsupportedMediaType, nil)
+	assertErrorResponse(t, []byte("Maximize\n x\nSubject To\n x <= y\nEnd\n"), applicationCplexLp, http.StatusUnprocessableEntity, stageParse, codeParseError,
+		&SourceSpan{Start: SourcePosition{Line: 4, Column: 7, Offset: 29}, End: SourcePosition{Line: 4, Column: 8, Offset: 30}})
+	assertErrorResponse(t, []byte(`{"variables": [{"name": "x1"}], "objective": {"sense": "max", "coefficients": {"x2": 1}}}`), applicationJson,
+		http.StatusUnprocessableEntity, stageSemantic, codeSemanticError, nil)
+	assertErrorResponse(t, []byte(`{"variables": [`), applicationJson, http.StatusUnprocessableEntity, stageParse, codeParseError, nil)
+	assertErrorResponse(t, []byte("NAME t\nENDATA\n"), applicationMps+"; format=other", http.StatusUnsupportedMediaType, stageRequest, codeUnsupportedMediaType, nil)
+	assertErrorResponse(t, []byte("NAME t\nROWS\n N obj\nCOLUMNS\n x missing 1\nENDATA\n"), applicationMps, http.StatusUnprocessableEntity, stageParse, codeParseError,
+		&SourceSpan{Start: SourcePosition{Line: 5, Column: 4, Offset: 30}, End: SourcePosition{Line: 5, Column: 11, Offset: 37}})
+	assertErrorResponse(t, []byte("let x1;\nmax x1 $;"), textPlain, http.StatusUnprocessableEntity, stageLex, codeLexError,
+		&SourceSpan{Start: SourcePosition{Line: 2, Column: 8, Offset: 15}, End: SourcePosition{Column: 10}})
+	assertErrorResponse(t, []byte("let x1;\nmax x1;\ns.t. x1 <= ;"), textPlain, http.StatusUnprocessableEntity, stageParse, codeParseError,
+		&SourceSpan{Start: SourcePosition{Line: 3, Column: 12, Offset: 27}, End: SourcePosition{Column: 13}})
+	assertErrorResponse(t, []byte("let x1; let x2;\nmax x1;\ns.t. x1 * x2 <= 1;"), textPlain, http.StatusUnprocessableEntity, stageSimplify, codeSimplifyError,
+		&SourceSpan{Start: SourcePosition{Line: 3, Column: 6, Offset: 29}, End: SourcePosition{Column: 13}})
+	assertErrorResponse(t, []byte("param p = 0; let x;\nmax x;\ns.t. x / p <= 1;"), textPlain, http.StatusUnprocessableEntity, stageSimplify, codeSimplifyError,
+		&SourceSpan{Start: SourcePosition{Line: 3, Column: 6, Offset: 32}, End: SourcePosition{Column: 11}})
+	assertErrorResponse(t, []byte("let x[1..2];\nmax sum{i in T} x[i];\ns.t. x[1] <= 1;"), textPlain, http.StatusUnprocessableEntity, stageExpand, codeExpandError,
+		&SourceSpan{Start: SourcePosition{Line: 2, Column: 14, Offset: 26}, End: SourcePosition{Column: 15}})
+	assertErrorResponse(t, []byte("let x;\nmax sum{i in 1..3000000} x;\ns.t. x <= 1;"), textPlain, http.StatusUnprocessableEntity, stageExpand, codeExpandError,
+		&SourceSpan{Start: SourcePosition{Line: 2, Column: 14, Offset: 20}, End: SourcePosition{Column: 24}})
+	assertErrorResponse(t, []byte("let x1;\nmax x1;\ns.t. x1 + y2 <= 3;"), textPlain, http.StatusUnprocessableEntity, stageSemantic, codeSemanticError,
+		&SourceSpan{Start: SourcePosition{Line: 3, Column: 11, Offset: 26}, End: SourcePosition{Column: 13}})
+}
+
+func TestSolve_WriteResult(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeResult(w, &SimplexResult{ResultType: "optimal", Solution: []float64{math.Inf(1)}})
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status %d for a result that can't be encoded, got %d", http.StatusInternalServerError, w.Code)
+	}
+	var output ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&output); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if output.Stage != stageSolve || output.Code != codeInternalError || output.Message == "" {
+		t.Fatalf("expected stage %s and code %s, received %+v", stageSolve, codeInternalError, output)
+	}
 }
@@ -26,3 +26,12 @@ func getTableInverse(table map[string]int) map[int]string {
 
 	return inverse
 }
+
+// Rounds values within EPSILON of 0 to 0 (this also avoids printing -0)
+func cleanZero(num float64) float64 {
+	if math.Abs(num) < EPSILON {
+		return 0
+	}
+
+	return num
+}
@@ -0,0 +1,224 @@
+package solve
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/animalat/Simplex-Algorithm/backend/service/simplex"
+)
+
+const solverQuery = "solver"
+const DefaultSolverName = "go"
+
+// CppSolverPathEnv is the environment variable giving the path to the C++ simplex_solver binary
+// (built from simplex_core) when it isn't given some other way, e.g. with the -cpp-solver flag
+const CppSolverPathEnv = "SIMPLEX_SOLVER_PATH"
+
+// SolveOptions are the limits (and mode) of a solve, the zero value has no limits
+type SolveOptions struct {
+	// TimeLimit stops the solve with "timeLimit" once it has run this long (0 for no limit). It covers parsing the model too,
+	// so the caller applies it as the deadline of the context it parses and solves with (see HandleSolve)
+	TimeLimit time.Duration
+	// IterationLimit stops the simplex method with "iterationLimit" after this many pivots
+	// in one LP (relaxation), 0 for no limit
+	IterationLimit int
+	// Exact solves with the rational simplex method (see simplex.TwoPhaseExact) instead of the solver,
+	// giving the result as fractions too (see ExactResult). The program should come from parse_sef.ParseSEFExact
+	// (or LinearizeProgramExact) so that folding its expressions doesn't round.
+	Exact bool
+	// Trace records every pivot of the simplex method (see Trace), only the go solver supports it.
+	// For integer programs only the root LP relaxation is traced.
+	Trace bool
+}
+
+// Solver solves a linear program given in standard equality form.
+// Mapping is left empty, it is filled in by the caller. Basis may be left nil if the solver does not report it,
+// and Steps if it can't trace (see SolveOptions.Trace).
+// Once ctx's deadline passes, the solver should stop with "timeLimit" (and the best basis it has),
+// if ctx is cancelled it should stop with an error.
+type Solver interface {
+	Solve(ctx context.Context, sef *StandardForm, opts SolveOptions) (*SimplexResult, error)
+}
+
+// GoSolver runs the two-phase simplex method in-process
+type GoSolver struct{}
+
+// CppSolver runs the C++ simplex calculator (simplex_core) as a subprocess
+type CppSolver struct {
+	// Path to the simplex_solver binary, solving fails if it is empty
+	Path string
+}
+
+var solvers = map[string]Solver{
+	DefaultSolverName: GoSolver{},
+	"cpp":             CppSolver{Path: os.Getenv(CppSolverPathEnv)},
+}
+
+// RegisterSolver makes a Solver selectable with ?solver=name (replacing any existing one with that name).
+// It is not safe to call once the server is handling requests.
+func RegisterSolver(name string, s Solver) {
+	solvers[name] = s
+}
+
+// Finds the solver asked for by the request, or the default solver if none was asked for
+func getSolver(name string) (Solver, error) {
+	if name == "" {
+		name = DefaultSolverName
+	}
+
+	s, ok := solvers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown solver: %q", name)
+	}
+
+	return s, nil
+}
+
+func (GoSolver) Solve(ctx context.Context, sef *StandardForm, opts SolveOptions) (*SimplexResult, error) {
+	if err := ctx.Err(); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return nil, err
+	}
+
+	var res simplex.Result
+	var steps []simplex.Step
+	var err error
+	if opts.Trace {
+		res, steps, err = simplex.TwoPhaseTrace(ctx, opts.IterationLimit, sef.Objective, sef.ObjectiveConst, sef.ConstraintsLHS, sef.ConstraintsRHS)
+	} else {
+		res, err = simplex.TwoPhaseContext(ctx, opts.IterationLimit, sef.Objective, sef.ObjectiveConst, sef.ConstraintsLHS, sef.ConstraintsRHS)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error running solver: %w", err)
+	}
+
+	var solution []float64
+	var basis []int
+	if res.Type != simplex.Infeasible {
+		solution = res.Solution
+		basis = res.Basis
+	}
+
+	return &SimplexResult{
+		Solution:    solution,
+		ResultType:  string(res.Type),
+		Certificate: res.Certificate,
+		Basis:       basis,
+		Steps:       steps,
+	}, nil
+}
+
+// Prepares the input for the C++ simplex calculator (A, then b, then c, then z)
+func cppInput(sef *StandardForm) string {
+	rowSize := strconv.Itoa(len(sef.ConstraintsLHS))
+	colSize := strconv.Itoa(len(sef.Objective))
+
+	var input strings.Builder
+	// main matrix, LHS constraints (A)
+	input.WriteString(rowSize + "\n" + colSize + "\n")
+	for _, row := range sef.ConstraintsLHS {
+		for _, val := range row {
+			input.WriteString(ftos(val) + " ")
+		}
+		input.WriteString("\n")
+	}
+	// RHS constraints (B)
+	input.WriteString(rowSize + "\n1\n")
+	for _, val := range sef.ConstraintsRHS {
+		input.WriteString(ftos(val) + " ")
+	}
+	input.WriteString("\n")
+	// objective (C)
+	input.WriteString("1\n" + colSize + "\n")
+	for _, val := range sef.Objective {
+		input.WriteString(ftos(val) + " ")
+	}
+	input.WriteString("\n")
+	// objective constant (z)
+	input.WriteString(ftos(sef.ObjectiveConst) + "\n")
+
+	return input.String()
+}
+
+// Solve runs the C++ solver, which is killed once ctx is done. It doesn't report its basis,
+// so a "timeLimit" result has no solution, and iteration limits aren't supported (HandleSolve rejects them).
+func (c CppSolver) Solve(ctx context.Context, sef *StandardForm, opts SolveOptions) (*SimplexResult, error) {
+	if opts.IterationLimit > 0 {
+		return nil, fmt.Errorf("the C++ solver does not support iteration limits")
+	}
+	if c.Path == "" {
+		return nil, fmt.Errorf("no path to the C++ solver binary, set %s (or -cpp-solver)", CppSolverPathEnv)
+	}
+	if err := ctx.Err(); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return &SimplexResult{ResultType: string(simplex.TimeLimit)}, nil
+		}
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, c.Path)
+	cmd.Stdin = bytes.NewBufferString(cppInput(sef))
+
+	output, err := cmd.CombinedOutput()
+	// a solver that finished (or failed) on its own just as the deadline passed isn't at the time limit
+	killed := cmd.ProcessState != nil && !cmd.ProcessState.Exited()
+	if err != nil && killed && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &SimplexResult{ResultType: string(simplex.TimeLimit)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error running solver: %v\noutput: %s", err, string(output))
+	}
+
+	res, err := parseResult(string(output))
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// Gets the raw result from the C++ Simplex calculator
+func parseResult(output string) (SimplexResult, error) {
+	tokens := strings.Fields(output)
+
+	var solution []float64
+	var resultType string
+	var certificate []float64
+
+	readTypeAlready := false
+	for _, token := range tokens {
+		if token == string(simplex.Optimal) || token == string(simplex.Infeasible) || token == string(simplex.Unbounded) {
+			resultType = token
+			readTypeAlready = true
+			continue
+		}
+
+		const doubleSize = 64
+		numToAppend, err := strconv.ParseFloat(token, doubleSize)
+		if err != nil {
+			return SimplexResult{}, fmt.Errorf("error converting output into token: %v", err)
+		}
+
+		if !readTypeAlready {
+			solution = append(solution, numToAppend)
+		} else {
+			certificate = append(certificate, numToAppend)
+		}
+	}
+
+	if !readTypeAlready {
+		return SimplexResult{}, fmt.Errorf("no result type found in solver output: %q", output)
+	}
+
+	return SimplexResult{
+		Solution:    solution,
+		ResultType:  resultType,
+		Certificate: certificate,
+	}, nil
+}
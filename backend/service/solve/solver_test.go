@@ -0,0 +1,122 @@
+package solve
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/animalat/Simplex-Algorithm/backend/service/simplex"
+)
+
+func TestSolver_ParseResult(t *testing.T) {
+	res, err := parseResult("1.25 0 0 1.25 \noptimal\n1 0 \n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.ResultType != "optimal" {
+		t.Fatalf("expected resultType optimal, received type %v", res.ResultType)
+	}
+	if len(res.Solution) != 4 || len(res.Certificate) != 2 {
+		t.Fatalf("expected solution of length 4 and certificate of length 2, received %v and %v", res.Solution, res.Certificate)
+	}
+
+	if _, err := parseResult("1 2 3"); err == nil {
+		t.Fatalf("expected error for output without result type")
+	}
+}
+
+func TestSolver_UnknownSolver(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, solvePath+"?solver=doesNotExist", bytes.NewReader([]byte("let x1; max x1; s.t. x1 <= 1;")))
+	req.Header.Set(contentType, textPlain)
+	w := httptest.NewRecorder()
+
+	HandleSolve(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// Set SIMPLEX_SOLVER_PATH to a built simplex_core/simplex_solver to compare against the C++ solver
+func TestSolver_CppMatchesGo(t *testing.T) {
+	path := os.Getenv("SIMPLEX_SOLVER_PATH")
+	if path == "" {
+		t.Skip("SIMPLEX_SOLVER_PATH not set")
+	}
+
+	sef := &StandardForm{
+		Objective:      []float64{4, -4, 0, 0},
+		ObjectiveConst: 0,
+		ConstraintsLHS: [][]float64{{4, -4, 1, 0}, {1, -1, 0, -1}},
+		ConstraintsRHS: []float64{5, 0},
+	}
+
+	want, err := GoSolver{}.Solve(context.Background(), sef, SolveOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := CppSolver{Path: path}.Solve(context.Background(), sef, SolveOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got.ResultType != want.ResultType {
+		t.Fatalf("expected resultType %s, received type %v", want.ResultType, got.ResultType)
+	}
+	for i := range want.Solution {
+		if !floatsEqualWithError(want.Solution[i], got.Solution[i], PRECISIONERROR) {
+			t.Fatalf("solutions not equal at index %d: wanted %.2f, received %.2f", i, want.Solution[i], got.Solution[i])
+		}
+	}
+}
+
+// Writes a shell script standing in for the C++ solver
+func fakeCppSolver(t *testing.T, script string) CppSolver {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "simplex_solver")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0o755); err != nil {
+		t.Fatalf("failed to write fake solver: %v", err)
+	}
+
+	return CppSolver{Path: path}
+}
+
+func TestSolver_CppTimeLimit(t *testing.T) {
+	sef := &StandardForm{Objective: []float64{1, 0}, ConstraintsLHS: [][]float64{{1, 1}}, ConstraintsRHS: []float64{1}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	res, err := fakeCppSolver(t, "exec sleep 5").Solve(ctx, sef, SolveOptions{})
+	if err != nil || res.ResultType != string(simplex.TimeLimit) {
+		t.Fatalf("expected resultType %s once the solver is killed, received %v (error %v)", simplex.TimeLimit, res, err)
+	}
+
+	// a solver failing on its own is an error, not the time limit
+	failing := fakeCppSolver(t, "sleep 0.1; exit 1")
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := failing.Solve(ctx, sef, SolveOptions{}); err == nil {
+		t.Fatalf("expected an error from a failing solver")
+	}
+
+	// no path (rather than some guess relative to the working directory) is an error naming how to set it
+	if _, err := (CppSolver{}).Solve(ctx, sef, SolveOptions{}); err == nil || !strings.Contains(err.Error(), CppSolverPathEnv) {
+		t.Fatalf("expected an error naming %s for a C++ solver without a path, got %v", CppSolverPathEnv, err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, solvePath+"?solver=cpp&iterationLimit=2", bytes.NewReader([]byte("let x1; max x1; s.t. x1 <= 1;")))
+	req.Header.Set(contentType, textPlain)
+	w := httptest.NewRecorder()
+	HandleSolve(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d for an iteration limit with the C++ solver, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,82 @@
+package solve
+
+import (
+	"fmt"
+
+	"github.com/animalat/Simplex-Algorithm/backend/service/simplex"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/linear"
+)
+
+// Trace is every pivot of the two-phase simplex method on the standard equality form, for stepping through the algorithm
+type Trace struct {
+	// Columns names the tableau's columns (see columnNames), the same in both phases
+	Columns []string    `json:"columns"`
+	Steps   []TraceStep `json:"steps"`
+}
+
+// TraceStep is the tableau before a pivot, or at the end of a phase (see simplex.Step)
+type TraceStep struct {
+	// Phase is 1 (finding a feasible basis) or 2 (optimizing)
+	Phase int `json:"phase"`
+	// Basis names the basic column of each row of the tableau
+	Basis []string `json:"basis"`
+	// Entering is the column entering the basis, empty once the phase is optimal
+	Entering string `json:"entering,omitempty"`
+	// Leaving is the column leaving the basis, empty if none does (the phase ended)
+	Leaving string `json:"leaving,omitempty"`
+	// Ratios are the ratio test value of each row, null for rows the entering column doesn't limit
+	Ratios []*float64 `json:"ratios,omitempty"`
+	// Tableau has a row for each basic column, with an entry for each of Columns and then the basic column's value
+	Tableau [][]float64 `json:"tableau"`
+	// ReducedCosts are the objective row of the tableau, an entry for each of Columns
+	ReducedCosts []float64 `json:"reducedCosts"`
+	// Objective is the objective value, for Phase I minus the sum of the artificial columns
+	// and for Phase II in terms of the original objective (not negated for MIN)
+	Objective float64 `json:"objective"`
+}
+
+// Names the columns of steps, taken on sef (the standard equality form of lp)
+func namedTrace(lp *linear.LinearProgram, sef *StandardForm, layout *sefLayout, steps []simplex.Step) (*Trace, error) {
+	names := columnNames(lp, sef, layout)
+	name := func(col int) (string, error) {
+		if col >= len(names) {
+			return "", fmt.Errorf("trace column %d out of range", col)
+		}
+		if col < 0 {
+			return "", nil
+		}
+		return names[col], nil
+	}
+
+	sign := 1.0
+	if !lp.Objective.IsMax {
+		sign = -1.0
+	}
+
+	trace := &Trace{Columns: names, Steps: make([]TraceStep, 0, len(steps))}
+	for _, step := range steps {
+		traceStep := TraceStep{Phase: step.Phase, Ratios: step.Ratios, Tableau: step.Tableau, ReducedCosts: step.ReducedCosts, Objective: step.Value}
+		if step.Phase == 2 {
+			traceStep.Objective = cleanZero(sign * step.Value)
+		}
+
+		var err error
+		if traceStep.Entering, err = name(step.Entering); err != nil {
+			return nil, err
+		}
+		if traceStep.Leaving, err = name(step.Leaving); err != nil {
+			return nil, err
+		}
+		for _, col := range step.Basis {
+			basic, err := name(col)
+			if err != nil {
+				return nil, err
+			}
+			traceStep.Basis = append(traceStep.Basis, basic)
+		}
+
+		trace.Steps = append(trace.Steps, traceStep)
+	}
+
+	return trace, nil
+}
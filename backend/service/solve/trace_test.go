@@ -0,0 +1,88 @@
+package solve
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func postTrace(t *testing.T, query string, input string) (int, SimplexResult) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, solvePath+query, bytes.NewReader([]byte(input)))
+	req.Header.Set(contentType, textPlain)
+	w := httptest.NewRecorder()
+
+	HandleSolve(w, req)
+
+	var output SimplexResult
+	if w.Code == http.StatusOK {
+		if err := json.NewDecoder(w.Result().Body).Decode(&output); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+	}
+
+	return w.Code, output
+}
+
+func TestTrace_PostRequest(t *testing.T) {
+	code, output := postTrace(t, "?trace=true", "let x1 >= 0; let x2; min -x1 - x2; s.t. cap: x1 + x2 <= 4; lim: x2 <= 3;")
+	if code != http.StatusOK || output.Trace == nil {
+		t.Fatalf("expected status %d with a trace, got %d %+v", http.StatusOK, code, output)
+	}
+	trace := output.Trace
+
+	if got := fmt.Sprint(trace.Columns); got != "[x1 x2.pos x2.neg cap.slack lim.slack cap.artificial lim.artificial]" {
+		t.Fatalf("unexpected columns %s", got)
+	}
+
+	first, last := trace.Steps[0], trace.Steps[len(trace.Steps)-1]
+	if first.Phase != 1 || fmt.Sprint(first.Basis) != "[cap.artificial lim.artificial]" || first.Objective != -7 {
+		t.Fatalf("expected Phase I to start on the artificial columns at -7, received %+v", first)
+	}
+	if last.Phase != 2 || last.Entering != "" || last.Leaving != "" || last.Objective != -4 {
+		t.Fatalf("expected a final Phase II step at the original objective -4, received %+v", last)
+	}
+
+	pivots := 0
+	for i, step := range trace.Steps {
+		if len(step.Tableau) != len(step.Basis) || len(step.ReducedCosts) != len(trace.Columns) {
+			t.Fatalf("step %d: tableau doesn't match the basis and columns: %+v", i, step)
+		}
+		for _, row := range step.Tableau {
+			if len(row) != len(trace.Columns)+1 {
+				t.Fatalf("step %d: expected tableau rows of %d entries, received %v", i, len(trace.Columns)+1, row)
+			}
+		}
+		if step.Entering != "" && step.Leaving != "" {
+			pivots++
+			if fmt.Sprint(trace.Steps[i+1].Basis) == fmt.Sprint(step.Basis) {
+				t.Fatalf("step %d: pivot %s for %s didn't change the basis %v", i, step.Entering, step.Leaving, step.Basis)
+			}
+		}
+	}
+	if pivots == 0 {
+		t.Fatalf("expected at least one pivot, received %+v", trace.Steps)
+	}
+
+	_, output = postTrace(t, "?trace=true", "let int x1 >= 0; max x1; s.t. 2 * x1 <= 3;")
+	if output.Trace == nil || output.BranchAndBound == nil {
+		t.Fatalf("expected the root relaxation to be traced, received %+v", output)
+	}
+}
+
+func TestTrace_Options(t *testing.T) {
+	input := "let x1 >= 0; max x1; s.t. x1 <= 1;"
+	if _, output := postTrace(t, "", input); output.Trace != nil {
+		t.Fatalf("expected no trace without trace=true, received %+v", output.Trace)
+	}
+
+	for _, query := range []string{"?trace=maybe", "?trace=true&solver=cpp", "?trace=true&exact=true"} {
+		if code, _ := postTrace(t, query, input); code != http.StatusBadRequest {
+			t.Fatalf("%s: expected status %d, got %d", query, http.StatusBadRequest, code)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package solve
+
+import (
+	"math"
+
+	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/linear"
+)
+
+// Results are checked to within VERIFYTOLERANCE, scaled by the size of the vectors involved
+const VERIFYTOLERANCE = 1e-7
+
+// Verification of a result against the model, in terms of its variables and constraints (with the solution and
+// duals mapped back from the standard equality form), so neither the solver's output nor the mapping is trusted as is.
+// Variables are nonnegative if declared so, or if a single variable constraint bounds them below by 0 or more.
+// Only the checks of the result type are set.
+type Verification struct {
+	// Verified is whether every check passed (within VERIFYTOLERANCE)
+	Verified bool `json:"verified"`
+	// PrimalResidual is the largest violation of the constraints and nonnegativity by the solution (optimal and unbounded)
+	PrimalResidual *float64 `json:"primalResidual,omitempty"`
+	// DualResidual is the largest violation of the dual constraints by the duals y: the sign of each constraint's dual,
+	// and c - y^T A, which can't be positive for nonnegative variables and must be 0 for free ones (optimal, c and y as for MAX)
+	DualResidual *float64 `json:"dualResidual,omitempty"`
+	// DualityGap is |c^T x - y^T b| (optimal)
+	DualityGap *float64 `json:"dualityGap,omitempty"`
+	// FarkasResidual is the largest violation of the conditions on a Farkas certificate y (infeasible, with y negated
+	// if y^T b > 0): the sign of each constraint's multiplier, and y^T A, which can't be negative for nonnegative variables
+	// and must be 0 for free ones
+	FarkasResidual *float64 `json:"farkasResidual,omitempty"`
+	// FarkasValue is y^T b, which has to be negative (infeasible, with y negated if y^T b > 0)
+	FarkasValue *float64 `json:"farkasValue,omitempty"`
+	// IntegralityResidual is the largest distance of an integer variable from an integer (programs with integer variables)
+	IntegralityResidual *float64 `json:"integralityResidual,omitempty"`
+	// Ray checks the certificate d of an unbounded result
+	Ray *RayVerification `json:"ray,omitempty"`
+}
+
+// Checks of an unbounded ray d: moving along it keeps every constraint satisfied (A d compared with 0 like A x with b),
+// d >= 0 for nonnegative variables and the objective improves along it (c^T d > 0 for MAX, < 0 for MIN)
+type RayVerification struct {
+	// Residual is the largest violation of the constraints by A d
+	Residual float64 `json:"residual"`
+	// MinEntry is the smallest entry of d for a nonnegative variable (0 if there are none)
+	MinEntry float64 `json:"minEntry"`
+	// Rate is c^T d, the change in the objective per unit along d (negative for MIN, like UnboundedRay.Rate)
+	Rate     float64 `json:"rate"`
+	Verified bool    `json:"verified"`
+}
+
+// The constraints of lp as dense rows over its variables, with their right sides
+type modelRows struct {
+	lhs [][]float64
+	rhs []float64
+	// nonNegative[j] is whether variable j must be nonnegative
+	nonNegative []bool
+}
+
+func rowsOf(lp *linear.LinearProgram) *modelRows {
+	idTable := lp.IdTable()
+	rows := &modelRows{nonNegative: make([]bool, len(lp.Variables))}
+	for i, v := range lp.Variables {
+		rows.nonNegative[i] = v.NonNegative
+	}
+	for _, constraint := range lp.Constraints {
+		row := constraint.Left.Dense(idTable)
+		rows.lhs = append(rows.lhs, row)
+		rows.rhs = append(rows.rhs, constraint.Right)
+		if idx, isNonNegative, _ := nonNegativeBound(row, constraint.Right, constraint.Operator); isNonNegative {
+			rows.nonNegative[idx] = true
+		}
+	}
+
+	return rows
+}
+
+// Largest absolute value in v (0 if v is empty)
+func maxAbs(v []float64) float64 {
+	largest := 0.0
+	for _, x := range v {
+		largest = math.Max(largest, math.Abs(x))
+	}
+
+	return largest
+}
+
+func dotProduct(a []float64, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+
+	return sum
+}
+
+// y^T A for a row vector y
+func rowTimesMatrix(y []float64, matrix [][]float64, numCols int) []float64 {
+	product := make([]float64, numCols)
+	for i, row := range matrix {
+		for j, val := range row {
+			product[j] += y[i] * val
+		}
+	}
+
+	return product
+}
+
+// How far a constraint with left side minus right side equal to value is from holding
+func constraintViolation(value float64, op lexer.TokenType) float64 {
+	switch op {
+	case lexer.TokenLessEqual:
+		return math.Max(0, value)
+	case lexer.TokenGreaterEqual:
+		return math.Max(0, -value)
+	default:
+		return math.Abs(value)
+	}
+}
+
+// How far the multiplier y of a constraint (dual or Farkas, for MAX) is from the sign its comparison needs
+func multiplierViolation(y float64, op lexer.TokenType) float64 {
+	switch op {
+	case lexer.TokenLessEqual:
+		return math.Max(0, -y)
+	case lexer.TokenGreaterEqual:
+		return math.Max(0, y)
+	default:
+		return 0
+	}
+}
+
+// How far an entry of c - y^T A (for MAX) is from what the variable needs: at most 0 if nonnegative, 0 if free
+func reducedCostViolation(reducedCost float64, nonNegative bool) float64 {
+	if nonNegative {
+		return math.Max(0, reducedCost)
+	}
+
+	return math.Abs(reducedCost)
+}
+
+// Largest violation of the constraints of lp and nonnegativity by x
+func primalResidual(lp *linear.LinearProgram, rows *modelRows, x []float64) float64 {
+	residual := 0.0
+	for i, constraint := range lp.Constraints {
+		residual = math.Max(residual, constraintViolation(dotProduct(rows.lhs[i], x)-rows.rhs[i], constraint.Operator))
+	}
+	for j, val := range x {
+		if rows.nonNegative[j] {
+			residual = math.Max(residual, -val)
+		}
+	}
+
+	return residual
+}
+
+// The dual of every constraint of lp, false if one is missing
+func dualValues(lp *linear.LinearProgram, duals map[string]float64) ([]float64, bool) {
+	y := make([]float64, len(lp.Constraints))
+	for i, key := range constraintKeys(lp) {
+		dual, ok := duals[key]
+		if !ok {
+			return nil, false
+		}
+		y[i] = dual
+	}
+
+	return y, true
+}
+
+// Checks res (already mapped back onto lp, see originalResult) against lp. The certificate of an infeasible result
+// is still over the rows of the standard equality form laid out by layout, the bounds left out of it get 0.
+// Results stopped at a limit have nothing to check, so they get nil.
+func verifyResult(lp *linear.LinearProgram, layout *sefLayout, res *SimplexResult) *Verification {
+	// c and y are checked as for MAX
+	sign := 1.0
+	if !lp.Objective.IsMax {
+		sign = -1.0
+	}
+
+	rows := rowsOf(lp)
+	numVariables := len(lp.Variables)
+	c := lp.Objective.Expr.Dense(lp.IdTable())
+	x, certificate := res.Solution, res.Certificate
+	v := &Verification{}
+	switch res.ResultType {
+	case "optimal":
+		y, ok := dualValues(lp, res.Duals)
+		if len(x) != numVariables || !ok {
+			return v
+		}
+
+		primal := primalResidual(lp, rows, x)
+		dual := 0.0
+		for i, constraint := range lp.Constraints {
+			dual = math.Max(dual, multiplierViolation(sign*y[i], constraint.Operator))
+		}
+		yA := rowTimesMatrix(y, rows.lhs, numVariables)
+		for j := range c {
+			dual = math.Max(dual, reducedCostViolation(sign*(c[j]-yA[j]), rows.nonNegative[j]))
+		}
+		value := dotProduct(c, x)
+		gap := math.Abs(value - dotProduct(y, rows.rhs))
+
+		tolerance := VERIFYTOLERANCE * (1 + maxAbs(x) + maxAbs(y) + maxAbs(rows.rhs))
+		v.PrimalResidual, v.DualResidual, v.DualityGap = &primal, &dual, &gap
+		v.Verified = primal <= tolerance && dual <= tolerance && gap <= VERIFYTOLERANCE*(1+math.Abs(value))
+	case "infeasible":
+		if len(certificate) != len(layout.rowConstraints) {
+			return v
+		}
+
+		y := make([]float64, len(lp.Constraints))
+		for r, i := range layout.rowConstraints {
+			y[i] = certificate[r]
+		}
+		// -y certifies infeasibility just as well (y^T A <= 0, y^T b > 0), which is what the C++ solver gives
+		if dotProduct(y, rows.rhs) > 0 {
+			for i := range y {
+				y[i] = -y[i]
+			}
+		}
+
+		farkas := 0.0
+		for i, constraint := range lp.Constraints {
+			farkas = math.Max(farkas, multiplierViolation(y[i], constraint.Operator))
+		}
+		for j, val := range rowTimesMatrix(y, rows.lhs, numVariables) {
+			farkas = math.Max(farkas, reducedCostViolation(-val, rows.nonNegative[j]))
+		}
+		value := dotProduct(y, rows.rhs)
+
+		tolerance := VERIFYTOLERANCE * (1 + maxAbs(y))
+		v.FarkasResidual, v.FarkasValue = &farkas, &value
+		v.Verified = farkas <= tolerance && value < -tolerance
+	case "unbounded":
+		if len(x) != numVariables || len(certificate) != numVariables {
+			return v
+		}
+
+		primal := primalResidual(lp, rows, x)
+		ray := verifyRay(lp, rows, certificate)
+		v.PrimalResidual, v.Ray = &primal, &ray
+		v.Verified = primal <= VERIFYTOLERANCE*(1+maxAbs(x)+maxAbs(rows.rhs)) && ray.Verified
+	default:
+		return nil
+	}
+
+	return v
+}
+
+// Checks d (by variable of lp, with rows from rowsOf) is a ray along which the objective improves without bound
+func verifyRay(lp *linear.LinearProgram, rows *modelRows, d []float64) RayVerification {
+	sign := 1.0
+	if !lp.Objective.IsMax {
+		sign = -1.0
+	}
+
+	check := RayVerification{Rate: dotProduct(lp.Objective.Expr.Dense(lp.IdTable()), d)}
+	for i, constraint := range lp.Constraints {
+		check.Residual = math.Max(check.Residual, constraintViolation(dotProduct(rows.lhs[i], d), constraint.Operator))
+	}
+	for j, val := range d {
+		if rows.nonNegative[j] {
+			check.MinEntry = math.Min(check.MinEntry, val)
+		}
+	}
+
+	tolerance := VERIFYTOLERANCE * (1 + maxAbs(d))
+	check.Verified = check.Residual <= tolerance && check.MinEntry >= -tolerance && sign*check.Rate > tolerance
+	check.MinEntry, check.Rate = cleanZero(check.MinEntry), cleanZero(check.Rate)
+	return check
+}
+
+// Checks an incumbent of branch-and-bound (by variable of lp, before rounding) satisfies lp and is integral
+// to within INTEGERTOLERANCE. Its certificate is only about a node's LP relaxation, so it isn't checked.
+func verifyIncumbent(lp *linear.LinearProgram, solution []float64) *Verification {
+	v := &Verification{}
+	if len(solution) != len(lp.Variables) {
+		return v
+	}
+
+	rows := rowsOf(lp)
+	primal := primalResidual(lp, rows, solution)
+	integrality := 0.0
+	for i, variable := range lp.Variables {
+		if variable.IsInteger() {
+			integrality = math.Max(integrality, math.Abs(solution[i]-math.Round(solution[i])))
+		}
+	}
+
+	v.PrimalResidual, v.IntegralityResidual = &primal, &integrality
+	v.Verified = primal <= VERIFYTOLERANCE*(1+maxAbs(solution)+maxAbs(rows.rhs)) && integrality <= INTEGERTOLERANCE
+	return v
+}
@@ -0,0 +1,112 @@
+package solve
+
+import (
+	"testing"
+
+	"github.com/animalat/Simplex-Algorithm/backend/service/simplex"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/parse_sef"
+)
+
+func assertVerified(t *testing.T, output SimplexResult) *Verification {
+	t.Helper()
+
+	if output.Verification == nil || !output.Verification.Verified {
+		t.Fatalf("expected a verified result, received %+v", output.Verification)
+	}
+
+	return output.Verification
+}
+
+func TestVerify_PostRequest(t *testing.T) {
+	output := assertPostRequest(t, []byte("let x1 >= 0; let x2 >= 0; min -3 * x1 - 2 * x2; s.t. x1 + x2 <= 4; x1 + 3 * x2 <= 6;"), []float64{4, 0}, "optimal", []float64{3, 0})
+	v := assertVerified(t, output)
+	if v.PrimalResidual == nil || v.DualResidual == nil || v.DualityGap == nil || v.FarkasValue != nil || v.Ray != nil {
+		t.Fatalf("expected primal, dual and duality gap checks, received %+v", v)
+	}
+
+	output = assertPostRequest(t, []byte("let x1 >= 0; max x1; s.t. x1 <= -1;"), nil, "infeasible", []float64{1})
+	v = assertVerified(t, output)
+	if v.FarkasResidual == nil || v.FarkasValue == nil || *v.FarkasValue >= 0 || v.PrimalResidual != nil {
+		t.Fatalf("expected Farkas checks, received %+v", v)
+	}
+
+	output = assertPostRequest(t, []byte("let x1 >= 0; max x1; s.t. x1 >= 1;"), []float64{1}, "unbounded", []float64{1})
+	v = assertVerified(t, output)
+	if v.PrimalResidual == nil || v.Ray == nil || v.DualityGap != nil {
+		t.Fatalf("expected primal and ray checks, received %+v", v)
+	}
+
+	output = assertPostRequest(t, []byte("let int x1 >= 0; let int x2 >= 0; max 5 * x1 + 4 * x2; s.t. 6 * x1 + 4 * x2 <= 24; x1 + 2 * x2 <= 6;"), []float64{4, 0}, "optimal", nil)
+	v = assertVerified(t, output)
+	if v.PrimalResidual == nil || v.IntegralityResidual == nil || v.DualResidual != nil {
+		t.Fatalf("expected primal and integrality checks of the incumbent, received %+v", v)
+	}
+}
+
+func TestVerify_WrongResults(t *testing.T) {
+	// optimal value 4, with the dual of c 1
+	lp, err := parse_sef.ParseSEF("let x1 >= 0; let x2 >= 0; max x1 + x2; s.t. c: x1 + x2 <= 4;")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, layout, err := standardForm(lp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		res  SimplexResult
+	}{
+		{"infeasible solution", SimplexResult{ResultType: "optimal", Solution: []float64{5, 0}, Duals: map[string]float64{"c": 1}}},
+		{"dual infeasible", SimplexResult{ResultType: "optimal", Solution: []float64{4, 0}, Duals: map[string]float64{"c": 0.5}}},
+		{"wrong dual sign", SimplexResult{ResultType: "optimal", Solution: []float64{0, 0}, Duals: map[string]float64{"c": -1}}},
+		{"suboptimal", SimplexResult{ResultType: "optimal", Solution: []float64{2, 0}, Duals: map[string]float64{"c": 1}}},
+		{"missing duals", SimplexResult{ResultType: "optimal", Solution: []float64{4, 0}}},
+		{"not a Farkas certificate", SimplexResult{ResultType: "infeasible", Certificate: []float64{-1}}},
+		{"zero Farkas certificate", SimplexResult{ResultType: "infeasible", Certificate: []float64{0}}},
+		{"not a ray", SimplexResult{ResultType: "unbounded", Solution: []float64{4, 0}, Certificate: []float64{1, 0}}},
+	}
+
+	for _, test := range tests {
+		if v := verifyResult(lp, layout, &test.res); v == nil || v.Verified {
+			t.Errorf("%s: expected the result not to be verified, received %+v", test.name, v)
+		}
+	}
+
+	good := SimplexResult{ResultType: "optimal", Solution: []float64{4, 0}, Duals: map[string]float64{"c": 1}}
+	if v := verifyResult(lp, layout, &good); v == nil || !v.Verified {
+		t.Errorf("expected the optimal result to be verified, received %+v", v)
+	}
+	// x1 + x2 <= -4 is infeasible, with either sign of y
+	infeasible, err := parse_sef.ParseSEF("let x1 >= 0; let x2 >= 0; max x1 + x2; s.t. c: x1 + x2 <= -4;")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, y := range []float64{1, -1} {
+		if v := verifyResult(infeasible, layout, &SimplexResult{ResultType: "infeasible", Certificate: []float64{y}}); v == nil || !v.Verified {
+			t.Errorf("expected the Farkas certificate [%v] to be verified, received %+v", y, v)
+		}
+	}
+
+	limited := SimplexResult{ResultType: string(simplex.TimeLimit), Solution: []float64{0, 0}}
+	if v := verifyResult(lp, layout, &limited); v != nil {
+		t.Errorf("expected no verification of a result stopped at a limit, received %+v", v)
+	}
+}
+
+func TestVerify_Incumbent(t *testing.T) {
+	lp, err := parse_sef.ParseSEF("let int x1 >= 0; let x2 >= 0; max x1 + x2; s.t. x1 + x2 <= 3.5;")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if v := verifyIncumbent(lp, []float64{3, 0.5}); !v.Verified || v.IntegralityResidual == nil || v.DualResidual != nil {
+		t.Errorf("expected the incumbent to be verified with an integrality check, received %+v", v)
+	}
+	for _, solution := range [][]float64{{2.5, 1}, {4, 0}, {3}} {
+		if v := verifyIncumbent(lp, solution); v.Verified {
+			t.Errorf("expected %v not to be verified, received %+v", solution, v)
+		}
+	}
+}
@@ -20,7 +20,7 @@ export default function SolutionDisplay({ solution, resultType, certificate, map
     return (
         <div>
             Result is {resultType}
-            {resultType != "infeasible" && (
+            {resultType != "infeasible" && solution && (
                 <div className="mt-4">
                     <p>Solution:</p>
                     <Latex>
@@ -0,0 +1,670 @@
+package cplex
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/linear"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/parser"
+)
+
+// Sections of an LP file
+type section int
+
+const (
+	sectionNone section = iota
+	sectionMaximize
+	sectionMinimize
+	sectionSubjectTo
+	sectionBounds
+	sectionGeneral
+	sectionBinary
+	sectionEnd
+	sectionUnsupported
+)
+
+// Section keywords (lower case), "subject to" and "such that" are two words
+var sectionKeywords = map[string]section{
+	"maximize": sectionMaximize, "maximise": sectionMaximize, "maximum": sectionMaximize, "max": sectionMaximize,
+	"minimize": sectionMinimize, "minimise": sectionMinimize, "minimum": sectionMinimize, "min": sectionMinimize,
+	"st": sectionSubjectTo, "s.t.": sectionSubjectTo, "st.": sectionSubjectTo,
+	"bounds": sectionBounds, "bound": sectionBounds,
+	"general": sectionGeneral, "generals": sectionGeneral, "gen": sectionGeneral,
+	"binary": sectionBinary, "binaries": sectionBinary, "bin": sectionBinary,
+	"end":             sectionEnd,
+	"semi-continuous": sectionUnsupported, "semis": sectionUnsupported, "semi": sectionUnsupported, "sos": sectionUnsupported,
+}
+
+var twoWordSections = map[string]string{"subject": "to", "such": "that"}
+
+const keywordFree = "free"
+
+var infinityKeywords = map[string]bool{"inf": true, "infinity": true}
+
+// A token, and whether it is the first on its line (section keywords must be)
+type token struct {
+	lexer.Token
+	lineStart bool
+}
+
+// Characters that can be in names besides letters and digits (brackets aren't in the CPLEX format, they are accepted
+// for names like x[1] from the LP language)
+const nameSymbols = "!\"#$%&()/,.;?@_`'{}|~[]"
+
+func isNameStart(r rune) bool {
+	return unicode.IsLetter(r) || (r != '.' && strings.ContainsRune(nameSymbols, r))
+}
+
+func isNameChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune(nameSymbols, r)
+}
+
+// Length of the number at the start of runes (0 if there isn't one)
+func numberLength(runes []rune) int {
+	i := 0
+	digits := 0
+	for i < len(runes) && unicode.IsDigit(runes[i]) {
+		i++
+		digits++
+	}
+	if i < len(runes) && runes[i] == '.' {
+		i++
+		for i < len(runes) && unicode.IsDigit(runes[i]) {
+			i++
+			digits++
+		}
+	}
+	if digits == 0 {
+		return 0
+	}
+
+	if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+		j := i + 1
+		if j < len(runes) && (runes[j] == '+' || runes[j] == '-') {
+			j++
+		}
+		if j < len(runes) && unicode.IsDigit(runes[j]) {
+			for j < len(runes) && unicode.IsDigit(runes[j]) {
+				j++
+			}
+			i = j
+		}
+	}
+
+	return i
+}
+
+// Length and type of the operator at the start of runes ("<" is read as "<=", and "=<" and "=>" are accepted)
+func operatorAt(runes []rune) (int, lexer.TokenType) {
+	hasNext := len(runes) > 1
+	switch runes[0] {
+	case '<':
+		if hasNext && runes[1] == '=' {
+			return 2, lexer.TokenLessEqual
+		}
+		return 1, lexer.TokenLessEqual
+	case '>':
+		if hasNext && runes[1] == '=' {
+			return 2, lexer.TokenGreaterEqual
+		}
+		return 1, lexer.TokenGreaterEqual
+	case '=':
+		if hasNext && runes[1] == '<' {
+			return 2, lexer.TokenLessEqual
+		}
+		if hasNext && runes[1] == '>' {
+			return 2, lexer.TokenGreaterEqual
+		}
+		return 1, lexer.TokenEqual
+	case '+':
+		return 1, lexer.TokenPlus
+	case '-':
+		return 1, lexer.TokenMinus
+	case ':':
+		return 1, lexer.TokenColon
+	default:
+		return 0, ""
+	}
+}
+
+// Splits an LP file into tokens ("\" starts a comment)
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	lineOffset := 0
+	for lineIdx, line := range strings.Split(input, "\n") {
+		lineNum := lineIdx + 1
+		nextLineOffset := lineOffset + len(line) + 1
+		if idx := strings.IndexRune(line, '\\'); idx != -1 {
+			line = line[:idx]
+		}
+
+		runes := []rune(line)
+		byteOffsets := make([]int, len(runes)+1)
+		for i := range runes {
+			byteOffsets[i+1] = byteOffsets[i] + len(string(runes[i]))
+		}
+		position := func(runeIdx int) lexer.Position {
+			return lexer.Position{Line: lineNum, Column: runeIdx + 1, Offset: lineOffset + byteOffsets[runeIdx]}
+		}
+
+		lineStart := true
+		for i := 0; i < len(runes); {
+			if unicode.IsSpace(runes[i]) {
+				i++
+				continue
+			}
+
+			var length int
+			var tokenType lexer.TokenType
+			switch {
+			case numberLength(runes[i:]) > 0:
+				length, tokenType = numberLength(runes[i:]), lexer.TokenNumber
+			case isNameStart(runes[i]):
+				length, tokenType = 1, lexer.TokenId
+				for i+length < len(runes) && isNameChar(runes[i+length]) {
+					length++
+				}
+			default:
+				length, tokenType = operatorAt(runes[i:])
+			}
+			if length == 0 {
+				span := lexer.Span{Start: position(i), End: position(i + 1)}
+				return nil, lexer.Errorf(span, "unexpected character %q", runes[i])
+			}
+
+			span := lexer.Span{Start: position(i), End: position(i + length)}
+			tokens = append(tokens, token{
+				Token:     lexer.Token{Type: tokenType, Value: string(runes[i : i+length]), Line: lineNum, Span: span},
+				lineStart: lineStart,
+			})
+			lineStart = false
+			i += length
+		}
+
+		lineOffset = nextLineOffset
+	}
+
+	end := lexer.Position{Line: strings.Count(input, "\n") + 1, Column: 1, Offset: len(input)}
+	tokens = append(tokens, token{Token: lexer.Token{Type: lexer.TokenEOF, Line: end.Line, Span: lexer.Span{Start: end, End: end}}, lineStart: true})
+	return tokens, nil
+}
+
+// Bounds of a variable, collected from the Bounds, General and Binary sections
+type bounds struct {
+	lower float64
+	upper float64
+	span  lexer.Span
+	// set is whether the Bounds section mentions the variable (so they are written out as constraints)
+	set bool
+}
+
+type lpParser struct {
+	tokens []token
+	pos    int
+	// variables in order of first appearance
+	decls     []*parser.Decl
+	declIndex map[string]int
+	bounds    map[string]*bounds
+}
+
+func (p *lpParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *lpParser) next() token {
+	t := p.tokens[p.pos]
+	if t.Type != lexer.TokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// The section the current token starts, and how many tokens its keyword takes (sectionNone if it doesn't start one)
+func (p *lpParser) sectionAt() (section, int) {
+	t := p.peek()
+	if !t.lineStart || t.Type != lexer.TokenId {
+		return sectionNone, 0
+	}
+
+	word := strings.ToLower(t.Value)
+	if second, ok := twoWordSections[word]; ok {
+		following := p.tokens[p.pos+1]
+		if !following.lineStart && strings.ToLower(following.Value) == second {
+			return sectionSubjectTo, 2
+		}
+	}
+
+	if s, ok := sectionKeywords[word]; ok {
+		return s, 1
+	}
+	return sectionNone, 0
+}
+
+func (p *lpParser) atSectionEnd() bool {
+	s, _ := p.sectionAt()
+	return s != sectionNone || p.peek().Type == lexer.TokenEOF
+}
+
+// Whether the current token starts "name:" (a constraint label)
+func (p *lpParser) atLabel() bool {
+	return p.peek().Type == lexer.TokenId && p.tokens[p.pos+1].Type == lexer.TokenColon
+}
+
+// Declares name on first appearance (variables are nonnegative unless the Bounds section says otherwise)
+func (p *lpParser) variable(t lexer.Token) *parser.Variable {
+	if _, ok := p.declIndex[t.Value]; !ok {
+		p.declIndex[t.Value] = len(p.decls)
+		p.decls = append(p.decls, &parser.Decl{ID: t, NonNegative: true, Type: parser.VarContinuous})
+		p.bounds[t.Value] = &bounds{upper: math.Inf(1)}
+	}
+
+	return &parser.Variable{ID: t}
+}
+
+func (p *lpParser) expectNumber() (float64, lexer.Token, error) {
+	t := p.next()
+	if t.Type != lexer.TokenNumber {
+		return 0, t.Token, lexer.Errorf(t.Span, "expected a number, received %q", t.Value)
+	}
+
+	value, err := strconv.ParseFloat(t.Value, 64)
+	if err != nil {
+		return 0, t.Token, lexer.Errorf(t.Span, "invalid number: %v", t.Value)
+	}
+	return value, t.Token, nil
+}
+
+// Parses an optionally signed number (or infinity, for bounds)
+func (p *lpParser) signedNumber(allowInfinity bool) (float64, lexer.Span, error) {
+	sign := 1.0
+	start := p.peek().Span
+	if t := p.peek(); t.Type == lexer.TokenPlus || t.Type == lexer.TokenMinus {
+		p.next()
+		if t.Type == lexer.TokenMinus {
+			sign = -1
+		}
+	}
+
+	if t := p.peek(); allowInfinity && t.Type == lexer.TokenId && infinityKeywords[strings.ToLower(t.Value)] {
+		p.next()
+		return sign * math.Inf(1), start.Join(t.Span), nil
+	}
+
+	value, t, err := p.expectNumber()
+	return sign * value, start.Join(t.Span), err
+}
+
+// Parses a sum of terms like "3 x1 - x2 + 4", stopping at a comparison, a label, a section or the end.
+// The sum is built as left-nested '+' and '-' (an empty sum is 0).
+func (p *lpParser) expr() (parser.Expr, error) {
+	var sum parser.Expr
+	for !p.atSectionEnd() && !p.atLabel() {
+		t := p.peek()
+		if t.Type == lexer.TokenLessEqual || t.Type == lexer.TokenGreaterEqual || t.Type == lexer.TokenEqual {
+			break
+		}
+
+		operator := lexer.Token{Type: lexer.TokenPlus, Value: "+", Line: t.Line, Span: t.Span}
+		if t.Type == lexer.TokenPlus || t.Type == lexer.TokenMinus {
+			operator = p.next().Token
+		} else if sum != nil {
+			return nil, lexer.Errorf(t.Span, "expected '+' or '-' between terms, received %q", t.Value)
+		}
+
+		term, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case sum != nil:
+			sum = &parser.BinaryExpr{Left: sum, Operator: operator, Right: term, Line: operator.Line, Span: parser.SpanOf(sum).Join(parser.SpanOf(term))}
+		case operator.Type == lexer.TokenMinus:
+			sum = &parser.UnaryExpr{Operator: operator, Expr: term, Line: operator.Line, Span: operator.Span.Join(parser.SpanOf(term))}
+		default:
+			sum = term
+		}
+	}
+
+	if sum == nil {
+		t := p.peek()
+		return &parser.NumberLiteral{Value: 0, Line: t.Line, Span: lexer.Span{Start: t.Span.Start, End: t.Span.Start}}, nil
+	}
+	return sum, nil
+}
+
+// Parses "coefficient name", "name" or a constant
+func (p *lpParser) term() (parser.Expr, error) {
+	t := p.next()
+	switch t.Type {
+	case lexer.TokenNumber:
+		value, err := strconv.ParseFloat(t.Value, 64)
+		if err != nil {
+			return nil, lexer.Errorf(t.Span, "invalid number: %v", t.Value)
+		}
+		number := &parser.NumberLiteral{Value: value, Line: t.Line, Span: t.Span}
+
+		// a constant is followed by a new term, a comparison, a label or a section
+		if following := p.peek(); following.Type != lexer.TokenId || following.lineStart || p.atLabel() {
+			return number, nil
+		}
+		variable := p.variable(p.next().Token)
+		return &parser.BinaryExpr{
+			Left:     number,
+			Operator: lexer.Token{Type: lexer.TokenAsterisk, Value: "*", Line: t.Line, Span: t.Span},
+			Right:    variable,
+			Line:     t.Line,
+			Span:     t.Span.Join(variable.ID.Span),
+		}, nil
+	case lexer.TokenId:
+		return p.variable(t.Token), nil
+	default:
+		return nil, lexer.Errorf(t.Span, "expected a term, received %q", t.Value)
+	}
+}
+
+func (p *lpParser) objective(isMax bool, span lexer.Span) (*parser.Objective, error) {
+	if p.atLabel() {
+		p.next()
+		p.next()
+	}
+
+	expr, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	return &parser.Objective{IsMax: isMax, Expr: expr, Span: span.Join(parser.SpanOf(expr))}, nil
+}
+
+func isComparison(t lexer.TokenType) bool {
+	return t == lexer.TokenLessEqual || t == lexer.TokenGreaterEqual || t == lexer.TokenEqual
+}
+
+func (p *lpParser) expectComparison() (lexer.Token, error) {
+	t := p.next()
+	if !isComparison(t.Type) {
+		return t.Token, lexer.Errorf(t.Span, "expected a comparison, received %q", t.Value)
+	}
+	return t.Token, nil
+}
+
+func numberLiteral(value float64, span lexer.Span) *parser.NumberLiteral {
+	return &parser.NumberLiteral{Value: value, Line: span.Start.Line, Span: span}
+}
+
+// Parses "[name:] expr op rhs" or a ranged constraint "[name:] lower <= expr <= upper",
+// which becomes two constraints (the second named like "name.range", see parser.Constraint.IsRange)
+func (p *lpParser) constraint() ([]*parser.Constraint, error) {
+	start := p.peek().Span
+	name := ""
+	if p.atLabel() {
+		name = p.next().Value
+		p.next()
+	}
+
+	// a ranged constraint starts with a number followed by a comparison
+	isRanged := false
+	for i := p.pos; i < len(p.tokens); i++ {
+		if p.tokens[i].Type == lexer.TokenPlus || p.tokens[i].Type == lexer.TokenMinus {
+			continue
+		}
+		isRanged = p.tokens[i].Type == lexer.TokenNumber && isComparison(p.tokens[i+1].Type)
+		break
+	}
+
+	if isRanged {
+		lower, lowerSpan, err := p.signedNumber(false)
+		if err != nil {
+			return nil, err
+		}
+		firstOp, err := p.expectComparison()
+		if err != nil {
+			return nil, err
+		}
+		left, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		secondOp, err := p.expectComparison()
+		if err != nil {
+			return nil, err
+		}
+		upper, upperSpan, err := p.signedNumber(false)
+		if err != nil {
+			return nil, err
+		}
+		if firstOp.Type != secondOp.Type || firstOp.Type == lexer.TokenEqual {
+			return nil, lexer.Errorf(firstOp.Span.Join(secondOp.Span), "ranged constraints need two '<=' or two '>='")
+		}
+
+		// lower <= left is left >= lower (flipping the first comparison)
+		flipped := firstOp
+		flipped.Type, flipped.Value = lexer.TokenGreaterEqual, ">="
+		if firstOp.Type == lexer.TokenGreaterEqual {
+			flipped.Type, flipped.Value = lexer.TokenLessEqual, "<="
+		}
+		span := start.Join(upperSpan)
+		rangedName := ""
+		if name != "" {
+			rangedName = name + ".range"
+		}
+		return []*parser.Constraint{
+			{Name: name, Left: left, Operator: flipped, Right: numberLiteral(lower, lowerSpan), Line: span.Start.Line, Span: span},
+			{Name: rangedName, Left: left, Operator: secondOp, Right: numberLiteral(upper, upperSpan), Line: span.Start.Line, Span: span, IsRange: true},
+		}, nil
+	}
+
+	left, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	op, err := p.expectComparison()
+	if err != nil {
+		return nil, err
+	}
+	right, rightSpan, err := p.signedNumber(false)
+	if err != nil {
+		return nil, err
+	}
+
+	span := start.Join(rightSpan)
+	return []*parser.Constraint{{Name: name, Left: left, Operator: op, Right: numberLiteral(right, rightSpan), Line: span.Start.Line, Span: span}}, nil
+}
+
+// Parses a bound: "x free", "x op value", "value op x" or "lower <= x <= upper"
+func (p *lpParser) bound() error {
+	start := p.peek()
+	setBound := func(name lexer.Token, op lexer.TokenType, value float64, flip bool) {
+		b := p.bounds[p.variable(name).ID.Value]
+		b.set = true
+		b.span = start.Span.Join(p.tokens[p.pos-1].Span)
+		if flip {
+			// value <= x is x >= value
+			switch op {
+			case lexer.TokenLessEqual:
+				op = lexer.TokenGreaterEqual
+			case lexer.TokenGreaterEqual:
+				op = lexer.TokenLessEqual
+			}
+		}
+
+		switch op {
+		case lexer.TokenLessEqual:
+			b.upper = value
+		case lexer.TokenGreaterEqual:
+			b.lower = value
+		case lexer.TokenEqual:
+			b.lower, b.upper = value, value
+		}
+	}
+
+	if start.Type == lexer.TokenId && !infinityKeywords[strings.ToLower(start.Value)] {
+		name := p.next().Token
+		if t := p.peek(); t.Type == lexer.TokenId && strings.ToLower(t.Value) == keywordFree {
+			p.next()
+			setBound(name, lexer.TokenGreaterEqual, math.Inf(-1), false)
+			setBound(name, lexer.TokenLessEqual, math.Inf(1), false)
+			return nil
+		}
+
+		op, err := p.expectComparison()
+		if err != nil {
+			return err
+		}
+		value, _, err := p.signedNumber(true)
+		if err != nil {
+			return err
+		}
+		setBound(name, op.Type, value, false)
+		return nil
+	}
+
+	value, _, err := p.signedNumber(true)
+	if err != nil {
+		return err
+	}
+	op, err := p.expectComparison()
+	if err != nil {
+		return err
+	}
+	name := p.next()
+	if name.Type != lexer.TokenId {
+		return lexer.Errorf(name.Span, "expected a variable, received %q", name.Value)
+	}
+	setBound(name.Token, op.Type, value, true)
+
+	if t := p.peek(); isComparison(t.Type) && !t.lineStart {
+		op, err := p.expectComparison()
+		if err != nil {
+			return err
+		}
+		upper, _, err := p.signedNumber(true)
+		if err != nil {
+			return err
+		}
+		setBound(name.Token, op.Type, upper, false)
+	}
+	return nil
+}
+
+// Parses the names of a General or Binary section
+func (p *lpParser) typeSection(varType parser.VarType) error {
+	for !p.atSectionEnd() {
+		t := p.next()
+		if t.Type != lexer.TokenId {
+			return lexer.Errorf(t.Span, "expected a variable, received %q", t.Value)
+		}
+
+		p.variable(t.Token)
+		p.decls[p.declIndex[t.Value]].Type = varType
+		if varType == parser.VarBinary {
+			b := p.bounds[t.Value]
+			b.lower, b.upper = math.Max(b.lower, 0), math.Min(b.upper, 1)
+		}
+	}
+
+	return nil
+}
+
+// The constraint for bound (see linear.BoundConstraints) on decl's variable, at the variable's bounds
+func boundConstraint(decl *parser.Decl, b *bounds, bound linear.Constraint) *parser.Constraint {
+	operators := map[lexer.TokenType]string{lexer.TokenLessEqual: "<=", lexer.TokenGreaterEqual: ">=", lexer.TokenEqual: "="}
+	return &parser.Constraint{
+		Name:     bound.Name,
+		Left:     &parser.Variable{ID: decl.ID},
+		Operator: lexer.Token{Type: bound.Operator, Value: operators[bound.Operator], Line: b.span.Start.Line, Span: b.span},
+		Right:    numberLiteral(bound.Right, b.span),
+		Line:     b.span.Start.Line,
+		Span:     b.span,
+	}
+}
+
+// Sets each variable's sign from its bounds, other bounds become constraints (see linear.BoundConstraints)
+func (p *lpParser) boundConstraints() []*parser.Constraint {
+	var constraints []*parser.Constraint
+	for _, decl := range p.decls {
+		b := p.bounds[decl.ID.Value]
+		decl.NonNegative = b.lower >= 0
+		if !b.set {
+			continue
+		}
+
+		variable := linear.Variable{Name: decl.ID.Value, NonNegative: decl.NonNegative, Type: decl.Type, Span: decl.ID.Span}
+		for _, bound := range linear.BoundConstraints(variable, b.lower, b.upper, b.span, b.span) {
+			constraints = append(constraints, boundConstraint(decl, b, bound))
+		}
+	}
+
+	return constraints
+}
+
+// ParseLP reads a model in CPLEX LP format (Maximize/Minimize, Subject To, Bounds, General, Binary and End sections)
+// into a program, to be solved like one in the LP language (see parse_sef.LinearizeProgram).
+// Variables are declared in order of first appearance, and are nonnegative unless the Bounds section says otherwise;
+// other bounds and ranged constraints are added as constraints. Errors are returned as a *lexer.SpanError.
+func ParseLP(input string) (*parser.Program, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &lpParser{tokens: tokens, declIndex: make(map[string]int), bounds: make(map[string]*bounds)}
+	prog := &parser.Program{}
+
+	if first, _ := p.sectionAt(); first != sectionMaximize && first != sectionMinimize {
+		return nil, lexer.Errorf(p.peek().Span, "expected Maximize or Minimize, received %q", p.peek().Value)
+	}
+
+	for {
+		keyword := p.peek()
+		current, length := p.sectionAt()
+		if current == sectionNone {
+			if keyword.Type == lexer.TokenEOF {
+				return nil, lexer.Errorf(keyword.Span, "missing End")
+			}
+			return nil, lexer.Errorf(keyword.Span, "expected a section, received %q", keyword.Value)
+		}
+		p.pos += length
+
+		switch current {
+		case sectionMaximize, sectionMinimize:
+			if prog.Objective != nil {
+				return nil, lexer.Errorf(keyword.Span, "duplicate objective")
+			}
+			if prog.Objective, err = p.objective(current == sectionMaximize, keyword.Span); err != nil {
+				return nil, err
+			}
+		case sectionSubjectTo:
+			for !p.atSectionEnd() {
+				constraints, err := p.constraint()
+				if err != nil {
+					return nil, err
+				}
+				prog.Constraints = append(prog.Constraints, constraints...)
+			}
+		case sectionBounds:
+			for !p.atSectionEnd() {
+				if err := p.bound(); err != nil {
+					return nil, err
+				}
+			}
+		case sectionGeneral, sectionBinary:
+			varType := parser.VarInteger
+			if current == sectionBinary {
+				varType = parser.VarBinary
+			}
+			if err := p.typeSection(varType); err != nil {
+				return nil, err
+			}
+		case sectionUnsupported:
+			return nil, lexer.Errorf(keyword.Span, "unsupported section: %v", keyword.Value)
+		case sectionEnd:
+			prog.Decls = p.decls
+			prog.Constraints = append(prog.Constraints, p.boundConstraints()...)
+			return prog, nil
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package cplex
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/linear"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/parse_sef"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/parser"
+)
+
+// Lists the program's variables, objective and constraints (dense, by the program's id table)
+func describe(lp *linear.LinearProgram) []string {
+	idTable := lp.IdTable()
+
+	var lines []string
+	for _, v := range lp.Variables {
+		lines = append(lines, fmt.Sprintf("var %s %v %s", v.Name, v.NonNegative, v.Type))
+	}
+	lines = append(lines, fmt.Sprintf("obj %v %v %v", lp.Objective.IsMax, lp.Objective.Expr.Dense(idTable), lp.Objective.Expr.Constant))
+	for _, constraint := range lp.Constraints {
+		lines = append(lines, fmt.Sprintf("%s: %v %s %v", constraint.Name, constraint.Left.Dense(idTable), constraint.Operator, constraint.Right))
+	}
+
+	return lines
+}
+
+func parseLinear(t *testing.T, input string) *linear.LinearProgram {
+	t.Helper()
+
+	prog, err := ParseLP(input)
+	if err != nil {
+		t.Fatalf("ParseLP failed: %v", err)
+	}
+	lp, err := parse_sef.LinearizeProgram(prog)
+	if err != nil {
+		t.Fatalf("LinearizeProgram failed: %v", err)
+	}
+
+	return lp
+}
+
+func assertDescription(t *testing.T, lp *linear.LinearProgram, want []string) {
+	t.Helper()
+
+	got := describe(lp)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("program mismatch:\nGot:\n%v\nWant:\n%v", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestParseLP(t *testing.T) {
+	input := `\ a comment
+Maximize
+ profit: 3 x1 + 2x2 - x3
+   + 0.5 y + 10
+Subject To
+ cap: x1 + x2 <= 8 \ trailing comment
+ demand: x3 >= 2
+ -2 x1 + x3 =< 4
+ span: -5 <= x1 - x3 <= 5
+Bounds
+ x1 <= 4
+ -inf <= x3 <= 6
+ 2 <= x2
+ z free
+ y = 1.5
+General
+ x2
+Binaries
+ b
+End
+`
+
+	lp := parseLinear(t, input)
+	assertDescription(t, lp, []string{
+		"var x1 true continuous",
+		"var x2 true integer",
+		"var x3 false continuous",
+		"var y true continuous",
+		"var z false continuous",
+		"var b true binary",
+		"obj true [3 2 -1 0.5 0 0] 10",
+		"cap: [1 1 0 0 0 0] LEQ 8",
+		"demand: [0 0 1 0 0 0] GEQ 2",
+		": [-2 0 1 0 0 0] LEQ 4",
+		"span: [1 0 -1 0 0 0] GEQ -5",
+		"span.range: [1 0 -1 0 0 0] LEQ 5",
+		"x1.up: [1 0 0 0 0 0] LEQ 4",
+		"x2.lo: [0 1 0 0 0 0] GEQ 2",
+		"x3.up: [0 0 1 0 0 0] LEQ 6",
+		"y.fx: [0 0 0 1 0 0] EQ 1.5",
+	})
+
+	if span := lp.Constraints[1].Span; span.Start.Line != 7 || span.Start.Column != 2 {
+		t.Errorf("demand span %+v; want line 7, column 2", span)
+	}
+	if lp.Constraints[3].IsRange || !lp.Constraints[4].IsRange {
+		t.Errorf("only span.range should be the second half of a ranged constraint")
+	}
+}
+
+func TestWriteLP_RoundTrip(t *testing.T) {
+	input := "param c[1..2] = {2, 3}; let x[1..2] >= 0; let y; let int n >= 0; let bin b; let unused >= 0; " +
+		"min sum{i in 1..2} c[i] * x[i] - y + n + b - 4; s.t. forall{i in 1..2}: cap: x[i] + y <= 10 * i; 2 * (x[1] - y) >= -2; obj: n + b = 1;"
+	tokens, err := lexer.Tokenize(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Tokenize failed: %v", err)
+	}
+	prog, err := parser.ConstructParser(tokens).ParseProgram()
+	if err != nil {
+		t.Fatalf("ParseProgram failed: %v", err)
+	}
+	before := fmt.Sprint(prog.Objective.Expr, len(prog.Constraints), len(prog.Decls))
+
+	var out bytes.Buffer
+	if err := WriteLP(&out, prog); err != nil {
+		t.Fatalf("WriteLP failed: %v", err)
+	}
+	if after := fmt.Sprint(prog.Objective.Expr, len(prog.Constraints), len(prog.Decls)); after != before {
+		t.Errorf("WriteLP modified the program:\nBefore: %v\nAfter:  %v", before, after)
+	}
+
+	want := strings.Join([]string{
+		"Minimize",
+		" obj_1: 2 x(1) + 3 x(2) - y + n + b - 4",
+		"Subject To",
+		" cap(1): x(1) + y <= 10",
+		" cap(2): x(2) + y <= 20",
+		" 2 x(1) - 2 y >= -2",
+		" obj: n + b = 1",
+		"Bounds",
+		" y free",
+		" unused >= 0",
+		"General",
+		" n",
+		"Binary",
+		" b",
+		"End",
+		"",
+	}, "\n")
+	if out.String() != want {
+		t.Errorf("output mismatch:\nGot:\n%v\nWant:\n%v", out.String(), want)
+	}
+
+	assertDescription(t, parseLinear(t, out.String()), []string{
+		"var x(1) true continuous",
+		"var x(2) true continuous",
+		"var y false continuous",
+		"var n true integer",
+		"var b true binary",
+		"var unused true continuous",
+		"obj false [2 3 -1 1 1 0] -4",
+		"cap(1): [1 0 1 0 0 0] LEQ 10",
+		"cap(2): [0 1 1 0 0 0] LEQ 20",
+		": [2 0 -2 0 0 0] GEQ -2",
+		"obj: [0 0 0 1 1 0] EQ 1",
+	})
+}
+
+func TestParseLP_Errors(t *testing.T) {
+	tests := []struct {
+		input string
+		line  int
+	}{
+		{"Subject To\n x <= 1\nEnd", 1},
+		{"Maximize\n x\nSubject To\n x <= y\nEnd", 4},
+		{"Maximize\n x\nSubject To\n x + 2 y ~ 1\nEnd", 4},
+		{"Maximize\n x y\nEnd", 2},
+		{"Maximize\n x\nSubject To\n x <= 1\n", 5},
+		{"Maximize\n x\nSOS\n s1: x:1\nEnd", 3},
+	}
+
+	for _, test := range tests {
+		_, err := ParseLP(test.input)
+
+		var spanErr *lexer.SpanError
+		if !errors.As(err, &spanErr) {
+			t.Errorf("expected SpanError for %q, got %v", test.input, err)
+			continue
+		}
+		if spanErr.Span.Start.Line != test.line {
+			t.Errorf("%q: error %v on line %d, want line %d", test.input, err, spanErr.Span.Start.Line, test.line)
+		}
+	}
+}
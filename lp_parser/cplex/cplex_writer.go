@@ -0,0 +1,255 @@
+package cplex
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/linear"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/parse_sef"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/parser"
+)
+
+// Lines are wrapped after this many characters (CPLEX reads lines of up to 510)
+const maxLineLength = 80
+
+var operatorStrings = map[lexer.TokenType]string{
+	lexer.TokenLessEqual:    "<=",
+	lexer.TokenGreaterEqual: ">=",
+	lexer.TokenEqual:        "=",
+}
+
+// Name of a variable or constraint in the LP format, brackets (which mark quadratic terms) become parentheses (x[1] is x(1))
+func lpName(name string) (string, error) {
+	name = strings.NewReplacer("[", "(", "]", ")").Replace(name)
+
+	runes := []rune(name)
+	if len(runes) == 0 || !isNameStart(runes[0]) {
+		return "", fmt.Errorf("invalid LP name: %q", name)
+	}
+	for _, r := range runes {
+		if !isNameChar(r) {
+			return "", fmt.Errorf("invalid LP name: %q", name)
+		}
+	}
+
+	return name, nil
+}
+
+// Whether name would be read as a keyword at the start of a line
+func isKeyword(name string) bool {
+	word := strings.ToLower(name)
+	_, isSection := sectionKeywords[word]
+	_, isTwoWordSection := twoWordSections[word]
+	return isSection || isTwoWordSection || word == keywordFree || infinityKeywords[word]
+}
+
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func leftExprs(lp *linear.LinearProgram) []linear.LinearExpr {
+	exprs := make([]linear.LinearExpr, len(lp.Constraints))
+	for i, constraint := range lp.Constraints {
+		exprs[i] = constraint.Left
+	}
+	return exprs
+}
+
+type lpWriter struct {
+	w     *bufio.Writer
+	names map[string]string
+	line  strings.Builder
+}
+
+// Adds part to the current line, wrapping to a new (indented) line if it gets too long
+func (lw *lpWriter) add(part string) {
+	if lw.line.Len() > 0 && lw.line.Len()+len(part)+1 > maxLineLength {
+		lw.flushLine()
+		lw.line.WriteString("   ")
+	}
+	lw.line.WriteString(" ")
+	lw.line.WriteString(part)
+}
+
+func (lw *lpWriter) flushLine() {
+	lw.w.WriteString(lw.line.String())
+	lw.w.WriteString("\n")
+	lw.line.Reset()
+}
+
+// Adds the terms of expr in variable order
+func (lw *lpWriter) addExpr(expr linear.LinearExpr, variables []linear.Variable) {
+	first := true
+	for _, v := range variables {
+		coefficient := expr.Coefficients[v.Name]
+		if coefficient == 0 {
+			continue
+		}
+
+		name := lw.names[v.Name]
+		sign := "+"
+		if coefficient < 0 {
+			sign = "-"
+		}
+		magnitude := math.Abs(coefficient)
+
+		var term string
+		switch {
+		case first && sign == "+" && magnitude == 1 && isKeyword(name):
+			// a coefficient keeps the name from being read as a keyword
+			term = "1 " + name
+		case first && sign == "+" && magnitude == 1:
+			term = name
+		case first && sign == "+":
+			term = formatNumber(magnitude) + " " + name
+		case magnitude == 1:
+			term = sign + " " + name
+		default:
+			term = sign + " " + formatNumber(magnitude) + " " + name
+		}
+		lw.add(term)
+		first = false
+	}
+
+	if first && len(variables) > 0 {
+		// an expression needs a term
+		lw.add("0 " + lw.names[variables[0].Name])
+	}
+}
+
+func (lw *lpWriter) write(lp *linear.LinearProgram) error {
+	lw.names = make(map[string]string, len(lp.Variables))
+	for _, v := range lp.Variables {
+		name, err := lpName(v.Name)
+		if err != nil {
+			return err
+		}
+		lw.names[v.Name] = name
+	}
+	constraintNames := make([]string, len(lp.Constraints))
+	for i, constraint := range lp.Constraints {
+		if constraint.Name == "" {
+			continue
+		}
+
+		name, err := lpName(constraint.Name)
+		if err != nil {
+			return err
+		}
+		constraintNames[i] = name
+	}
+
+	if lp.Objective.IsMax {
+		fmt.Fprintln(lw.w, "Maximize")
+	} else {
+		fmt.Fprintln(lw.w, "Minimize")
+	}
+	objectiveName := "obj"
+	for suffix := 1; containsString(constraintNames, objectiveName); suffix++ {
+		objectiveName = "obj_" + strconv.Itoa(suffix)
+	}
+	lw.line.WriteString(" " + objectiveName + ":")
+	lw.addExpr(lp.Objective.Expr, lp.Variables)
+	if constant := lp.Objective.Expr.Constant; constant < 0 {
+		lw.add("- " + formatNumber(-constant))
+	} else if constant > 0 {
+		lw.add("+ " + formatNumber(constant))
+	}
+	lw.flushLine()
+
+	fmt.Fprintln(lw.w, "Subject To")
+	for i, constraint := range lp.Constraints {
+		operator, ok := operatorStrings[constraint.Operator]
+		if !ok {
+			return fmt.Errorf("invalid operator %v in constraint %d", constraint.Operator, i)
+		}
+
+		if constraintNames[i] != "" {
+			lw.line.WriteString(" " + constraintNames[i] + ":")
+		}
+		lw.addExpr(constraint.Left, lp.Variables)
+		lw.add(operator + " " + formatNumber(constraint.Right-constraint.Left.Constant))
+		lw.flushLine()
+	}
+
+	// variables are nonnegative by default, variables that aren't in any expression are declared by their bound
+	used := make(map[string]bool)
+	for _, expr := range append([]linear.LinearExpr{lp.Objective.Expr}, leftExprs(lp)...) {
+		for variable, coefficient := range expr.Coefficients {
+			used[variable] = used[variable] || coefficient != 0
+		}
+	}
+
+	fmt.Fprintln(lw.w, "Bounds")
+	var integers, binaries []string
+	for _, v := range lp.Variables {
+		name := lw.names[v.Name]
+		switch {
+		case v.Type == parser.VarBinary:
+			// binary variables are declared in their section, and are between 0 and 1
+		case !v.NonNegative:
+			fmt.Fprintf(lw.w, " %s free\n", name)
+		case !used[v.Name]:
+			fmt.Fprintf(lw.w, " %s >= 0\n", name)
+		}
+
+		switch v.Type {
+		case parser.VarInteger:
+			integers = append(integers, name)
+		case parser.VarBinary:
+			binaries = append(binaries, name)
+		}
+	}
+
+	for _, section := range []struct {
+		keyword string
+		names   []string
+	}{{"General", integers}, {"Binary", binaries}} {
+		if len(section.names) == 0 {
+			continue
+		}
+
+		fmt.Fprintln(lw.w, section.keyword)
+		for _, name := range section.names {
+			lw.add(name)
+		}
+		lw.flushLine()
+	}
+
+	fmt.Fprintln(lw.w, "End")
+	return lw.w.Flush()
+}
+
+// WriteLP writes p in CPLEX LP format, with expressions written out as sums of terms.
+// p isn't modified (it is expanded and simplified like parse_sef.LinearizeProgram on a copy),
+// so errors are returned as a *parse_sef.StageError, except for names that can't be written
+// (brackets are written as parentheses, e.g. x[1] as x(1)).
+func WriteLP(w io.Writer, p *parser.Program) error {
+	copied := *p
+	if p.Objective != nil {
+		objective := *p.Objective
+		copied.Objective = &objective
+	}
+
+	lp, err := parse_sef.LinearizeProgram(&copied)
+	if err != nil {
+		return err
+	}
+
+	lw := &lpWriter{w: bufio.NewWriter(w)}
+	return lw.write(lp)
+}
@@ -0,0 +1,570 @@
+package expand
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/parser"
+)
+
+// An element of a set or range, either a number or a name (e.g. "north" in "set S = {north, south};")
+type element struct {
+	number float64
+	// name is empty for numbers
+	name string
+}
+
+func (e element) String() string {
+	if e.name != "" {
+		return e.name
+	}
+
+	return strconv.FormatFloat(e.number, 'f', -1, 64)
+}
+
+// The value of each iterator variable in scope
+type scope map[string]element
+
+// Copy of s with variable set to value
+func (s scope) with(variable string, value element) scope {
+	inner := make(scope, len(s)+1)
+	for k, v := range s {
+		inner[k] = v
+	}
+	inner[variable] = value
+
+	return inner
+}
+
+// MaxExpansion is the most elements a range may have, and the most combinations of iterators
+// (sum terms, constraints, variables and parameters) a program may expand into
+const MaxExpansion = 1000000
+
+type expander struct {
+	ctx  context.Context
+	sets map[string][]element
+	// params are the values of (expanded) parameters, used in indices and range bounds
+	params map[string]float64
+	// expanded counts the combinations of iterators gone over so far (see MaxExpansion)
+	expanded int
+}
+
+// Name of an indexed variable or constraint, e.g. x[1,north]
+func indexedName(name string, index []element) string {
+	parts := make([]string, len(index))
+	for i, e := range index {
+		parts[i] = e.String()
+	}
+
+	return name + "[" + strings.Join(parts, ",") + "]"
+}
+
+// Name of the variable (or parameter) an indexed reference like x[i + 1] refers to
+func (ex *expander) indexedName(v *parser.Variable, s scope) (string, error) {
+	index := make([]element, len(v.Index))
+	for i, indexExpr := range v.Index {
+		value, err := ex.evaluate(indexExpr, s)
+		if err != nil {
+			return "", err
+		}
+		index[i] = value
+	}
+
+	return indexedName(v.ID.Value, index), nil
+}
+
+// Evaluates a constant expression (e.g. an index or range bound) where iterator variables are in s.
+// A name that is not an iterator variable is taken as a set element.
+func (ex *expander) evaluate(e parser.Expr, s scope) (element, error) {
+	switch expr := e.(type) {
+	case *parser.NumberLiteral:
+		return element{number: expr.Value}, nil
+	case *parser.Variable:
+		if expr.Index != nil {
+			name, err := ex.indexedName(expr, s)
+			if err != nil {
+				return element{}, err
+			}
+			if value, ok := ex.params[name]; ok {
+				return element{number: value}, nil
+			}
+
+			return element{}, lexer.Errorf(expr.ID.Span, "expected a constant, received indexed variable %v", name)
+		}
+		if value, ok := s[expr.ID.Value]; ok {
+			return value, nil
+		}
+		if value, ok := ex.params[expr.ID.Value]; ok {
+			return element{number: value}, nil
+		}
+
+		return element{name: expr.ID.Value}, nil
+	case *parser.UnaryExpr:
+		value, err := ex.evaluateNumber(expr.Expr, s)
+		if err != nil {
+			return element{}, err
+		}
+
+		if expr.Operator.Type == lexer.TokenMinus {
+			value *= -1
+		}
+		return element{number: value}, nil
+	case *parser.BinaryExpr:
+		left, err := ex.evaluateNumber(expr.Left, s)
+		if err != nil {
+			return element{}, err
+		}
+		right, err := ex.evaluateNumber(expr.Right, s)
+		if err != nil {
+			return element{}, err
+		}
+
+		switch expr.Operator.Type {
+		case lexer.TokenPlus:
+			return element{number: left + right}, nil
+		case lexer.TokenMinus:
+			return element{number: left - right}, nil
+		case lexer.TokenAsterisk:
+			return element{number: left * right}, nil
+		case lexer.TokenDivide:
+			if right == 0 {
+				return element{}, lexer.Errorf(expr.Span, "division by zero: %v", expr)
+			}
+			return element{number: left / right}, nil
+		default:
+			return element{}, lexer.Errorf(expr.Operator.Span, "invalid operator in constant: %v", expr)
+		}
+	default:
+		return element{}, lexer.Errorf(parser.SpanOf(e), "expected a constant, received: %v", e)
+	}
+}
+
+// Evaluates a constant expression that must be a number
+func (ex *expander) evaluateNumber(e parser.Expr, s scope) (float64, error) {
+	value, err := ex.evaluate(e, s)
+	if err != nil {
+		return 0, err
+	}
+	if value.name != "" {
+		return 0, lexer.Errorf(parser.SpanOf(e), "expected a number, received set element %v", value.name)
+	}
+
+	return value.number, nil
+}
+
+// Evaluates a range bound, which must be an integer
+func (ex *expander) evaluateInteger(e parser.Expr, s scope) (int, error) {
+	value, err := ex.evaluateNumber(e, s)
+	if err != nil {
+		return 0, err
+	}
+	if value != float64(int(value)) {
+		return 0, lexer.Errorf(parser.SpanOf(e), "range bounds must be integers, received %v", value)
+	}
+
+	return int(value), nil
+}
+
+// The elements of from..to
+func (ex *expander) rangeElements(from parser.Expr, to parser.Expr, s scope) ([]element, error) {
+	first, err := ex.evaluateInteger(from, s)
+	if err != nil {
+		return nil, err
+	}
+	last, err := ex.evaluateInteger(to, s)
+	if err != nil {
+		return nil, err
+	}
+
+	if float64(last)-float64(first)+1 > MaxExpansion {
+		return nil, lexer.Errorf(parser.SpanOf(from).Join(parser.SpanOf(to)), "range %d..%d has %d elements, more than the limit of %d", first, last, last-first+1, MaxExpansion)
+	}
+
+	var elements []element
+	for i := first; i <= last; i++ {
+		elements = append(elements, element{number: float64(i)})
+	}
+
+	return elements, nil
+}
+
+// The elements an iterator goes over
+func (ex *expander) domain(it *parser.Iterator, s scope) ([]element, error) {
+	if it.Set == nil {
+		return ex.rangeElements(it.From, it.To, s)
+	}
+
+	elements, ok := ex.sets[it.Set.Value]
+	if !ok {
+		return nil, lexer.Errorf(it.Set.Span, "undeclared set: %v", it.Set.Value)
+	}
+
+	return elements, nil
+}
+
+// Calls fn for every combination of elements of iterators (later iterators can use earlier ones, e.g. "i in 1..3, j in i..3"),
+// with the scope including the iterator variables and the combination of elements.
+// It stops once ctx is done, or the program has expanded into more than MaxExpansion combinations.
+func (ex *expander) each(iterators []*parser.Iterator, s scope, index []element, fn func(scope, []element) error) error {
+	if len(iterators) == 0 {
+		return fn(s, index)
+	}
+
+	span := iterators[0].Span.Join(iterators[len(iterators)-1].Span)
+	return ex.product(iterators, s, index, span, fn)
+}
+
+func (ex *expander) product(iterators []*parser.Iterator, s scope, index []element, span lexer.Span, fn func(scope, []element) error) error {
+	if len(iterators) == 0 {
+		if err := ex.ctx.Err(); err != nil {
+			return err
+		}
+		ex.expanded++
+		if ex.expanded > MaxExpansion {
+			return lexer.Errorf(span, "program expands into more than %d terms", MaxExpansion)
+		}
+		return fn(s, index)
+	}
+
+	elements, err := ex.domain(iterators[0], s)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range elements {
+		inner := s
+		if iterators[0].Var != nil {
+			inner = s.with(iterators[0].Var.Value, e)
+		}
+
+		if err := ex.product(iterators[1:], inner, append(index[:len(index):len(index)], e), span, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns a copy of e with indices evaluated, sums written out, and iterator variables replaced by their values
+func (ex *expander) expandExpr(e parser.Expr, s scope) (parser.Expr, error) {
+	switch expr := e.(type) {
+	case *parser.NumberLiteral:
+		copied := *expr
+		return &copied, nil
+	case *parser.Variable:
+		if expr.Index == nil {
+			value, ok := s[expr.ID.Value]
+			if !ok {
+				return &parser.Variable{ID: expr.ID}, nil
+			}
+			if value.name != "" {
+				return nil, lexer.Errorf(expr.ID.Span, "set element %v used as a number", value.name)
+			}
+
+			return &parser.NumberLiteral{Value: value.number, Line: expr.ID.Line, Span: expr.ID.Span}, nil
+		}
+
+		name, err := ex.indexedName(expr, s)
+		if err != nil {
+			return nil, err
+		}
+
+		id := expr.ID
+		id.Value = name
+		return &parser.Variable{ID: id}, nil
+	case *parser.UnaryExpr:
+		inner, err := ex.expandExpr(expr.Expr, s)
+		if err != nil {
+			return nil, err
+		}
+
+		return &parser.UnaryExpr{Operator: expr.Operator, Expr: inner, Line: expr.Line, Span: expr.Span}, nil
+	case *parser.BinaryExpr:
+		left, err := ex.expandExpr(expr.Left, s)
+		if err != nil {
+			return nil, err
+		}
+		right, err := ex.expandExpr(expr.Right, s)
+		if err != nil {
+			return nil, err
+		}
+
+		return &parser.BinaryExpr{Left: left, Operator: expr.Operator, Right: right, Line: expr.Line, Span: expr.Span}, nil
+	case *parser.SumExpr:
+		var sum parser.Expr
+		err := ex.each(expr.Over, s, nil, func(inner scope, _ []element) error {
+			term, err := ex.expandExpr(expr.Body, inner)
+			if err != nil {
+				return err
+			}
+
+			if sum == nil {
+				sum = term
+				return nil
+			}
+			plus := lexer.Token{Type: lexer.TokenPlus, Value: "+", Line: expr.Span.Start.Line, Span: expr.Span}
+			sum = &parser.BinaryExpr{Left: sum, Operator: plus, Right: term, Line: expr.Span.Start.Line, Span: expr.Span}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if sum == nil {
+			// empty sum
+			return &parser.NumberLiteral{Value: 0, Line: expr.Span.Start.Line, Span: expr.Span}, nil
+		}
+		return sum, nil
+	default:
+		return nil, lexer.Errorf(parser.SpanOf(e), "unknown Expr type: %T", e)
+	}
+}
+
+func (ex *expander) expandSets(p *parser.Program) error {
+	for _, set := range p.Sets {
+		if _, ok := ex.sets[set.ID.Value]; ok {
+			return lexer.Errorf(set.ID.Span, "duplicate set: %v", set.ID.Value)
+		}
+
+		if set.Elements == nil {
+			elements, err := ex.rangeElements(set.From, set.To, scope{})
+			if err != nil {
+				return err
+			}
+			ex.sets[set.ID.Value] = elements
+			continue
+		}
+
+		elements := make([]element, 0, len(set.Elements))
+		seen := make(map[element]struct{})
+		for _, e := range set.Elements {
+			value, err := ex.evaluate(e, scope{})
+			if err != nil {
+				return err
+			}
+			if _, ok := seen[value]; ok {
+				return lexer.Errorf(parser.SpanOf(e), "duplicate element %v in set %v", value, set.ID.Value)
+			}
+
+			seen[value] = struct{}{}
+			elements = append(elements, value)
+		}
+		ex.sets[set.ID.Value] = elements
+	}
+
+	return nil
+}
+
+// Writes out indexed parameters (e.g. demand[1..3] into demand[1], demand[2] and demand[3]) and records their values.
+// Parameters keep their value expressions (with iterator variables replaced), simplify resolves them.
+func (ex *expander) expandParams(p *parser.Program) ([]*parser.ParamDecl, error) {
+	params := make([]*parser.ParamDecl, 0, len(p.Params))
+	add := func(param *parser.ParamDecl, id lexer.Token, valueExpr parser.Expr, s scope) error {
+		if _, ok := ex.params[id.Value]; ok {
+			return lexer.Errorf(id.Span, "duplicate parameter: %v", id.Value)
+		}
+
+		expanded, err := ex.expandExpr(valueExpr, s)
+		if err != nil {
+			return err
+		}
+		value, err := ex.evaluateNumber(expanded, s)
+		if err != nil {
+			return err
+		}
+
+		ex.params[id.Value] = value
+		params = append(params, &parser.ParamDecl{ID: id, Value: expanded, Span: param.Span})
+		return nil
+	}
+
+	for _, param := range p.Params {
+		if param.Index == nil {
+			if err := add(param, param.ID, param.Value, scope{}); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		count := 0
+		err := ex.each(param.Index, scope{}, nil, func(s scope, index []element) error {
+			valueExpr := param.Value
+			if param.Values != nil {
+				if count >= len(param.Values) {
+					return lexer.Errorf(param.Span, "parameter %v has more elements than values (%d)", param.ID.Value, len(param.Values))
+				}
+				valueExpr = param.Values[count]
+			}
+			count++
+
+			id := param.ID
+			id.Value = indexedName(param.ID.Value, index)
+			return add(param, id, valueExpr, s)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if param.Values != nil && count != len(param.Values) {
+			return nil, lexer.Errorf(param.Span, "parameter %v has %d elements but %d values", param.ID.Value, count, len(param.Values))
+		}
+	}
+
+	return params, nil
+}
+
+func (ex *expander) expandDecls(p *parser.Program) ([]*parser.Decl, error) {
+	decls := make([]*parser.Decl, 0, len(p.Decls))
+	for _, decl := range p.Decls {
+		if decl.Index == nil {
+			decls = append(decls, decl)
+			continue
+		}
+
+		err := ex.each(decl.Index, scope{}, nil, func(_ scope, index []element) error {
+			id := decl.ID
+			id.Value = indexedName(decl.ID.Value, index)
+			decls = append(decls, &parser.Decl{ID: id, NonNegative: decl.NonNegative, Type: decl.Type})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return decls, nil
+}
+
+// The comparison that flips the sides of op (e.g. "0 <= x" is "x >= 0")
+func flipComparison(op lexer.Token) lexer.Token {
+	switch op.Type {
+	case lexer.TokenLessEqual:
+		op.Type, op.Value = lexer.TokenGreaterEqual, ">="
+	case lexer.TokenGreaterEqual:
+		op.Type, op.Value = lexer.TokenLessEqual, "<="
+	}
+	return op
+}
+
+// Expands a single constraint with iterator variables in s. A chained constraint "lower <= expr <= upper"
+// becomes two constraints, "expr >= lower" (named name) and "expr <= upper" (named like "name.range", see IsRange).
+func (ex *expander) expandConstraint(constraint *parser.Constraint, s scope, name string) ([]*parser.Constraint, error) {
+	left, err := ex.expandExpr(constraint.Left, s)
+	if err != nil {
+		return nil, err
+	}
+	right, err := ex.expandExpr(constraint.Right, s)
+	if err != nil {
+		return nil, err
+	}
+
+	if constraint.RangeRight == nil {
+		return []*parser.Constraint{{
+			Name:     name,
+			Left:     left,
+			Operator: constraint.Operator,
+			Right:    right,
+			Line:     constraint.Line,
+			Span:     constraint.Span,
+			IsRange:  constraint.IsRange,
+		}}, nil
+	}
+
+	// right is in the middle, and is in both constraints (so is expanded again for the second)
+	middle, err := ex.expandExpr(constraint.Right, s)
+	if err != nil {
+		return nil, err
+	}
+	rangeRight, err := ex.expandExpr(constraint.RangeRight, s)
+	if err != nil {
+		return nil, err
+	}
+	rangeName := ""
+	if name != "" {
+		rangeName = name + ".range"
+	}
+
+	return []*parser.Constraint{
+		{Name: name, Left: right, Operator: flipComparison(constraint.Operator), Right: left, Line: constraint.Line, Span: constraint.Span},
+		{Name: rangeName, Left: middle, Operator: constraint.RangeOperator, Right: rangeRight, Line: constraint.Line, Span: constraint.Span, IsRange: true},
+	}, nil
+}
+
+func (ex *expander) expandConstraints(p *parser.Program) ([]*parser.Constraint, error) {
+	constraints := make([]*parser.Constraint, 0, len(p.Constraints))
+	for _, constraint := range p.Constraints {
+		if constraint.ForAll == nil {
+			expanded, err := ex.expandConstraint(constraint, scope{}, constraint.Name)
+			if err != nil {
+				return nil, err
+			}
+			constraints = append(constraints, expanded...)
+			continue
+		}
+
+		err := ex.each(constraint.ForAll, scope{}, nil, func(s scope, index []element) error {
+			name := constraint.Name
+			if name != "" {
+				name = indexedName(name, index)
+			}
+
+			expanded, err := ex.expandConstraint(constraint, s, name)
+			if err != nil {
+				return err
+			}
+			constraints = append(constraints, expanded...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return constraints, nil
+}
+
+// ExpandProgram writes out indexed declarations ("let x[1..3];"), indexed parameters, sums ("sum{i in S} x[i]"),
+// constraint families ("forall{i in S}: x[i] <= 1;") and chained constraints ("0 <= x <= 1;"), so that the program
+// only has plain variables and parameters (named like x[3]) and single comparisons. Parameters can be used in range bounds and indices (e.g. "1..n").
+// It must run before simplify.SimplifyProgram.
+func ExpandProgram(p *parser.Program) error {
+	return ExpandProgramContext(context.Background(), p)
+}
+
+// ExpandProgramContext is ExpandProgram, stopped with ctx's error once ctx is done (e.g. at the time limit of a solve).
+// Programs are limited to MaxExpansion terms, and ranges to MaxExpansion elements.
+func ExpandProgramContext(ctx context.Context, p *parser.Program) error {
+	ex := &expander{ctx: ctx, sets: make(map[string][]element), params: make(map[string]float64)}
+	if err := ex.expandSets(p); err != nil {
+		return err
+	}
+
+	params, err := ex.expandParams(p)
+	if err != nil {
+		return err
+	}
+
+	decls, err := ex.expandDecls(p)
+	if err != nil {
+		return err
+	}
+
+	objective, err := ex.expandExpr(p.Objective.Expr, scope{})
+	if err != nil {
+		return err
+	}
+
+	constraints, err := ex.expandConstraints(p)
+	if err != nil {
+		return err
+	}
+
+	p.Sets = nil
+	p.Params = params
+	p.Decls = decls
+	p.Objective.Expr = objective
+	p.Constraints = constraints
+	return nil
+}
@@ -0,0 +1,184 @@
+package expand
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/parser"
+)
+
+func expandString(t *testing.T, input string) (*parser.Program, error) {
+	t.Helper()
+
+	tokens, err := lexer.Tokenize(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Tokenizing failed: %v", err)
+	}
+
+	p := &parser.Parser{Tokens: tokens}
+	prog, err := p.ParseProgram()
+	if err != nil {
+		t.Fatalf("Parsing failed: %v", err)
+	}
+
+	return prog, ExpandProgram(prog)
+}
+
+func TestExpand_Program(t *testing.T) {
+	input := "set S = {north, south}; let x[1..3] >= 0; let int y[i in S]; " +
+		"max sum{i in 1..3} i * x[i] + sum{s in S} y[s]; " +
+		"s.t. forall{i in 1..3}: cap: x[i] <= 10; forall{s in S}: y[s] <= 2; sum{i in 2..3} x[i - 1] >= 1;"
+	prog, err := expandString(t, input)
+	if err != nil {
+		t.Fatalf("Expanding failed: %v", err)
+	}
+
+	var names []string
+	for _, decl := range prog.Decls {
+		names = append(names, decl.ID.Value)
+	}
+	if got, want := strings.Join(names, " "), "x[1] x[2] x[3] y[north] y[south]"; got != want {
+		t.Errorf("declarations mismatch:\nGot:  %v\nWant: %v", got, want)
+	}
+	if !prog.Decls[0].NonNegative || prog.Decls[3].Type != parser.VarInteger {
+		t.Errorf("declarations should keep their bound and type")
+	}
+
+	got := fmt.Sprint(prog.Objective.Expr)
+	want := "((((1 * x[1]) + (2 * x[2])) + (3 * x[3])) + (y[north] + y[south]))"
+	if got != want {
+		t.Errorf("objective mismatch:\nGot:  %v\nWant: %v", got, want)
+	}
+
+	var constraints []string
+	for _, constraint := range prog.Constraints {
+		constraints = append(constraints, fmt.Sprintf("%s: %v %s %v", constraint.Name, constraint.Left, constraint.Operator.Value, constraint.Right))
+	}
+	got = strings.Join(constraints, "; ")
+	want = "cap[1]: x[1] <= 10; cap[2]: x[2] <= 10; cap[3]: x[3] <= 10; : y[north] <= 2; : y[south] <= 2; : (x[1] + x[2]) >= 1"
+	if got != want {
+		t.Errorf("constraints mismatch:\nGot:  %v\nWant: %v", got, want)
+	}
+}
+
+func TestExpand_Errors(t *testing.T) {
+	tests := []string{
+		"let x[1..2]; max sum{i in T} x[i]; s.t. x[1] <= 1;",
+		"set S = {a, a}; let x; max x; s.t. x <= 1;",
+		"set S = {a}; let x[S]; max sum{i in S} i * x[i]; s.t. x[a] <= 1;",
+		"let x[1..2.5]; max x[1]; s.t. x[1] <= 1;",
+	}
+
+	for _, input := range tests {
+		_, err := expandString(t, input)
+
+		var spanErr *lexer.SpanError
+		if !errors.As(err, &spanErr) {
+			t.Errorf("expected SpanError for %q, got %v", input, err)
+		}
+	}
+}
+
+func TestExpand_Limits(t *testing.T) {
+	tests := []struct {
+		input string
+		// span of source the error should be at
+		span string
+	}{
+		{"let x; max sum{i in 1..3000000} x; s.t. x <= 1;", "1..3000000"},
+		{"let x; max sum{i in 1..2000, j in 1..1000} x; s.t. x <= 1;", "i in 1..2000, j in 1..1000"},
+		{"let x[1..1000]; max x[1]; s.t. forall{i in 1..1000}: sum{j in 1..1000} x[j] <= i;", "j in 1..1000"},
+	}
+
+	for _, tc := range tests {
+		_, err := expandString(t, tc.input)
+
+		var spanErr *lexer.SpanError
+		if !errors.As(err, &spanErr) {
+			t.Errorf("expected SpanError for %q, got %v", tc.input, err)
+			continue
+		}
+		if got := tc.input[spanErr.Span.Start.Offset:spanErr.Span.End.Offset]; got != tc.span {
+			t.Errorf("error span mismatch for %q: got %q, want %q", tc.input, got, tc.span)
+		}
+	}
+
+	tokens, err := lexer.Tokenize(strings.NewReader("let x[1..10]; max x[1]; s.t. x[1] <= 1;"))
+	if err != nil {
+		t.Fatalf("Tokenizing failed: %v", err)
+	}
+	prog, err := (&parser.Parser{Tokens: tokens}).ParseProgram()
+	if err != nil {
+		t.Fatalf("Parsing failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := ExpandProgramContext(ctx, prog); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled once the context is done, got %v", err)
+	}
+}
+
+func TestExpand_Params(t *testing.T) {
+	input := "param n = 3; param demand[1..n] = {4, 5, 6}; param double[i in 1..n] = 2 * demand[i]; let x[1..n] >= 0; " +
+		"min sum{i in 1..n} demand[i] * x[i]; s.t. forall{i in 2..n}: x[i] >= double[i - 1];"
+	prog, err := expandString(t, input)
+	if err != nil {
+		t.Fatalf("Expanding failed: %v", err)
+	}
+
+	var params []string
+	for _, param := range prog.Params {
+		params = append(params, fmt.Sprintf("%s = %v", param.ID.Value, param.Value))
+	}
+	got := strings.Join(params, "; ")
+	want := "n = 3; demand[1] = 4; demand[2] = 5; demand[3] = 6; double[1] = (2 * demand[1]); double[2] = (2 * demand[2]); double[3] = (2 * demand[3])"
+	if got != want {
+		t.Errorf("params mismatch:\nGot:  %v\nWant: %v", got, want)
+	}
+
+	if got, want := fmt.Sprint(prog.Constraints[1].Right), "double[2]"; got != want {
+		t.Errorf("constraint mismatch: got %v, want %v", got, want)
+	}
+
+	for _, input := range []string{
+		"param d[1..3] = {1, 2}; let x; min x; s.t. x >= 0;",
+		"param d[1..2] = {1, 2, 3}; let x; min x; s.t. x >= 0;",
+		"param a = 1; param a = 2; let x; min x; s.t. x >= 0;",
+	} {
+		_, err := expandString(t, input)
+
+		var spanErr *lexer.SpanError
+		if !errors.As(err, &spanErr) {
+			t.Errorf("expected SpanError for %q, got %v", input, err)
+		}
+	}
+}
+
+func TestExpand_Chained(t *testing.T) {
+	input := "let x[1..2] >= 0; max x[1]; s.t. forall{i in 1..2}: box: -i <= x[i] <= 2 * i; 4 >= x[1] + x[2] >= 1;"
+	prog, err := expandString(t, input)
+	if err != nil {
+		t.Fatalf("Expanding failed: %v", err)
+	}
+
+	var constraints []string
+	for i, constraint := range prog.Constraints {
+		if constraint.RangeRight != nil {
+			t.Errorf("constraint %q should not be chained after expanding", constraint.Name)
+		}
+		if constraint.IsRange != (i%2 == 1) {
+			t.Errorf("constraint %d (%q) should have IsRange %v", i, constraint.Name, i%2 == 1)
+		}
+		constraints = append(constraints, fmt.Sprintf("%s: %v %s %v", constraint.Name, constraint.Left, constraint.Operator.Value, constraint.Right))
+	}
+	got := strings.Join(constraints, "; ")
+	want := "box[1]: x[1] >= (-1); box[1].range: x[1] <= (2 * 1); box[2]: x[2] >= (-2); box[2].range: x[2] <= (2 * 2); " +
+		": (x[1] + x[2]) <= 4; : (x[1] + x[2]) >= 1"
+	if got != want {
+		t.Errorf("constraints mismatch:\nGot:  %v\nWant: %v", got, want)
+	}
+}
@@ -0,0 +1,177 @@
+package json_model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/linear"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/parse_sef"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/parser"
+)
+
+// Model is a linear program given as JSON, e.g.
+//
+//	{"variables": [{"name": "x1", "lower": 0}, {"name": "x2", "lower": 0, "upper": 5, "type": "integer"}],
+//	 "objective": {"sense": "max", "coefficients": {"x1": 3, "x2": 2}, "constant": 1},
+//	 "constraints": [{"name": "cap", "coefficients": {"x1": 1, "x2": 1}, "operator": "<=", "rhs": 4}]}
+type Model struct {
+	Variables   []Variable   `json:"variables"`
+	Objective   Objective    `json:"objective"`
+	Constraints []Constraint `json:"constraints"`
+}
+
+// Variable bounds are unbounded if left out (like "let x;"), except for binary variables, which are between 0 and 1.
+// Type is "continuous" (the default), "integer" or "binary".
+type Variable struct {
+	Name  string   `json:"name"`
+	Lower *float64 `json:"lower"`
+	Upper *float64 `json:"upper"`
+	Type  string   `json:"type"`
+}
+
+// Sense is "max" or "min"
+type Objective struct {
+	Sense        string             `json:"sense"`
+	Coefficients map[string]float64 `json:"coefficients"`
+	Constant     float64            `json:"constant"`
+}
+
+// Operator is "<=", ">=" or "=", and Name may be left out
+type Constraint struct {
+	Name         string             `json:"name"`
+	Coefficients map[string]float64 `json:"coefficients"`
+	Operator     string             `json:"operator"`
+	RHS          float64            `json:"rhs"`
+}
+
+var senses = map[string]bool{"max": true, "maximize": true, "min": false, "minimize": false}
+
+var operators = map[string]lexer.TokenType{
+	"<=": lexer.TokenLessEqual,
+	">=": lexer.TokenGreaterEqual,
+	"=":  lexer.TokenEqual,
+	"==": lexer.TokenEqual,
+}
+
+var varTypes = map[string]parser.VarType{
+	"":                           parser.VarContinuous,
+	string(parser.VarContinuous): parser.VarContinuous,
+	string(parser.VarInteger):    parser.VarInteger,
+	string(parser.VarBinary):     parser.VarBinary,
+}
+
+// Checks each variable of coefficients is declared, and builds the expression
+func linearExpr(coefficients map[string]float64, declared map[string]bool, path string) (linear.LinearExpr, error) {
+	expr := linear.NewLinearExpr()
+	for variable, coefficient := range coefficients {
+		if !declared[variable] {
+			return expr, fmt.Errorf("%s: undeclared variable: %v", path, variable)
+		}
+		if math.IsNaN(coefficient) || math.IsInf(coefficient, 0) {
+			return expr, fmt.Errorf("%s: invalid coefficient for %v", path, variable)
+		}
+		expr.Add(variable, coefficient, lexer.Span{})
+	}
+
+	return expr, nil
+}
+
+// LinearProgram builds the linear program m describes (the same as parse_sef.ParseSEF gives), variables are in the
+// order they are listed. Bounds other than x >= 0 are added as constraints (see linear.BoundConstraints).
+func (m *Model) LinearProgram() (*linear.LinearProgram, error) {
+	lp := &linear.LinearProgram{}
+	declared := make(map[string]bool, len(m.Variables))
+	var boundConstraints []linear.Constraint
+	for i, v := range m.Variables {
+		path := fmt.Sprintf("variables[%d]", i)
+		if v.Name == "" {
+			return nil, fmt.Errorf("%s: missing name", path)
+		}
+		if declared[v.Name] {
+			return nil, fmt.Errorf("%s: duplicate variable: %v", path, v.Name)
+		}
+		declared[v.Name] = true
+
+		varType, ok := varTypes[strings.ToLower(v.Type)]
+		if !ok {
+			return nil, fmt.Errorf("%s: invalid type: %v", path, v.Type)
+		}
+
+		lower, upper := math.Inf(-1), math.Inf(1)
+		if varType == parser.VarBinary {
+			lower, upper = 0, 1
+		}
+		if v.Lower != nil {
+			lower = *v.Lower
+		}
+		if v.Upper != nil {
+			upper = *v.Upper
+		}
+		if math.IsNaN(lower) || math.IsNaN(upper) || lower > upper {
+			return nil, fmt.Errorf("%s: invalid bounds for %v", path, v.Name)
+		}
+
+		variable := linear.Variable{Name: v.Name, NonNegative: lower >= 0, Type: varType}
+		lp.Variables = append(lp.Variables, variable)
+		boundConstraints = append(boundConstraints, linear.BoundConstraints(variable, lower, upper, variable.Span, variable.Span)...)
+	}
+
+	isMax, ok := senses[strings.ToLower(m.Objective.Sense)]
+	if !ok {
+		return nil, fmt.Errorf("objective: invalid sense: %q", m.Objective.Sense)
+	}
+	expr, err := linearExpr(m.Objective.Coefficients, declared, "objective")
+	if err != nil {
+		return nil, err
+	}
+	expr.Constant = m.Objective.Constant
+	lp.Objective = linear.Objective{IsMax: isMax, Expr: expr}
+
+	names := make(map[string]bool, len(m.Constraints))
+	for i, c := range m.Constraints {
+		path := fmt.Sprintf("constraints[%d]", i)
+		if c.Name != "" {
+			if names[c.Name] {
+				return nil, fmt.Errorf("%s: duplicate constraint name: %v", path, c.Name)
+			}
+			names[c.Name] = true
+		}
+
+		operator, ok := operators[c.Operator]
+		if !ok {
+			return nil, fmt.Errorf("%s: invalid operator: %q", path, c.Operator)
+		}
+		left, err := linearExpr(c.Coefficients, declared, path)
+		if err != nil {
+			return nil, err
+		}
+
+		lp.Constraints = append(lp.Constraints, linear.Constraint{Name: c.Name, Left: left, Operator: operator, Right: c.RHS})
+	}
+	lp.Constraints = append(lp.Constraints, boundConstraints...)
+
+	return lp, nil
+}
+
+// ParseJSON reads a Model from JSON and builds its linear program. Errors are returned as a *parse_sef.StageError,
+// StageParse for invalid JSON and StageSemantic for an invalid model (e.g. an undeclared variable).
+func ParseJSON(input []byte) (*linear.LinearProgram, error) {
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	decoder.DisallowUnknownFields()
+
+	var m Model
+	if err := decoder.Decode(&m); err != nil {
+		return nil, &parse_sef.StageError{Stage: parse_sef.StageParse, Err: err}
+	}
+
+	lp, err := m.LinearProgram()
+	if err != nil {
+		return nil, &parse_sef.StageError{Stage: parse_sef.StageSemantic, Err: err}
+	}
+
+	return lp, nil
+}
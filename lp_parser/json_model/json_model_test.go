@@ -0,0 +1,81 @@
+package json_model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/animalat/Simplex-Algorithm/lp_parser/parse_sef"
+)
+
+func TestParseJSON(t *testing.T) {
+	input := `{
+		"variables": [
+			{"name": "x1", "lower": 0},
+			{"name": "x2", "lower": -2, "upper": 5, "type": "integer"},
+			{"name": "y"},
+			{"name": "b", "type": "binary"}
+		],
+		"objective": {"sense": "max", "coefficients": {"x1": 3, "x2": 2, "b": -1}, "constant": 4},
+		"constraints": [
+			{"name": "cap", "coefficients": {"x1": 1, "x2": 1, "y": 1}, "operator": "<=", "rhs": 10},
+			{"coefficients": {"x1": 1, "y": -1}, "operator": "=", "rhs": 2}
+		]
+	}`
+
+	lp, err := ParseJSON([]byte(input))
+	if err != nil {
+		t.Fatalf("ParseJSON failed: %v", err)
+	}
+
+	idTable := lp.IdTable()
+	var got []string
+	for _, v := range lp.Variables {
+		got = append(got, fmt.Sprintf("var %s %v %s", v.Name, v.NonNegative, v.Type))
+	}
+	got = append(got, fmt.Sprintf("obj %v %v %v", lp.Objective.IsMax, lp.Objective.Expr.Dense(idTable), lp.Objective.Expr.Constant))
+	for _, constraint := range lp.Constraints {
+		got = append(got, fmt.Sprintf("%s: %v %s %v", constraint.Name, constraint.Left.Dense(idTable), constraint.Operator, constraint.Right))
+	}
+
+	want := []string{
+		"var x1 true continuous",
+		"var x2 false integer",
+		"var y false continuous",
+		"var b true binary",
+		"obj true [3 2 0 -1] 4",
+		"cap: [1 1 1 0] LEQ 10",
+		": [1 0 -1 0] EQ 2",
+		"x2.lo: [0 1 0 0] GEQ -2",
+		"x2.up: [0 1 0 0] LEQ 5",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("program mismatch:\nGot:\n%v\nWant:\n%v", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestParseJSON_Errors(t *testing.T) {
+	tests := []struct {
+		input string
+		stage parse_sef.Stage
+	}{
+		{`{"variables": [`, parse_sef.StageParse},
+		{`{"variables": [], "objective": {"sense": "max"}, "extra": 1}`, parse_sef.StageParse},
+		{`{"variables": [{"name": "x"}], "objective": {"sense": "up"}}`, parse_sef.StageSemantic},
+		{`{"variables": [{"name": "x"}, {"name": "x"}], "objective": {"sense": "max"}}`, parse_sef.StageSemantic},
+		{`{"variables": [{"name": "x", "type": "real"}], "objective": {"sense": "max"}}`, parse_sef.StageSemantic},
+		{`{"variables": [{"name": "x", "lower": 2, "upper": 1}], "objective": {"sense": "max"}}`, parse_sef.StageSemantic},
+		{`{"variables": [{"name": "x"}], "objective": {"sense": "max", "coefficients": {"y": 1}}}`, parse_sef.StageSemantic},
+		{`{"variables": [{"name": "x"}], "objective": {"sense": "min"}, "constraints": [{"coefficients": {"x": 1}, "operator": "<", "rhs": 1}]}`, parse_sef.StageSemantic},
+	}
+
+	for _, test := range tests {
+		_, err := ParseJSON([]byte(test.input))
+
+		var stageErr *parse_sef.StageError
+		if !errors.As(err, &stageErr) || stageErr.Stage != test.stage {
+			t.Errorf("expected %v error for %s, got %v", test.stage, test.input, err)
+		}
+	}
+}
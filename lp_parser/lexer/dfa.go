@@ -4,6 +4,13 @@ import "fmt"
 
 var allTokens = []TokenType{
 	TokenLet,
+	TokenInt,
+	TokenBin,
+	TokenSet,
+	TokenParam,
+	TokenSum,
+	TokenForall,
+	TokenIn,
 	TokenSubjectTo,
 	TokenMin,
 	TokenMax,
@@ -11,6 +18,7 @@ var allTokens = []TokenType{
 	TokenNumber,
 	TokenDecimal,
 	TokenSemiColon,
+	TokenColon,
 	TokenEqual,
 	TokenLessEqual,
 	TokenGreaterEqual,
@@ -20,6 +28,12 @@ var allTokens = []TokenType{
 	TokenDivide,
 	TokenLParen,
 	TokenRParen,
+	TokenLBracket,
+	TokenRBracket,
+	TokenLBrace,
+	TokenRBrace,
+	TokenComma,
+	TokenRange,
 }
 
 func (dfa *DFA) initAlphabet() {
@@ -42,6 +56,15 @@ func (dfa *DFA) initAlphabet() {
 
 	// we use '.' in "s.t."
 	dfa.AlphabetSymbols['.'] = true
+
+	// constraint names, e.g. "capacity: x1 <= 5;"
+	dfa.AlphabetSymbols[':'] = true
+
+	// indexing, e.g. "let x[1..3];" and "sum{i in S} x[i]"
+	const indexSymbols = "[]{},"
+	for _, symbol := range indexSymbols {
+		dfa.AlphabetSymbols[symbol] = true
+	}
 }
 
 func (dfa *DFA) initStates() {
@@ -51,16 +74,27 @@ func (dfa *DFA) initStates() {
 
 	dfa.States["<"] = true
 	dfa.States[">"] = true
+
+	dfa.States[decimalPointState] = true
+	dfa.States[exponentMarkState] = true
+	dfa.States[exponentSignState] = true
+	dfa.States[exponentState] = true
 }
 
 func (dfa *DFA) initFinalStates() {
 	for _, state := range allTokens {
 		dfa.FinalStates[string(state)] = state
 	}
+
+	// e.g. 1e-3 is read as a DECIMAL
+	dfa.FinalStates[exponentState] = TokenDecimal
 }
 
+// Letters an ID can be made of
+const idLetters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
 func addFallbackToId(dfa *DFA, fromState string, toState string, exclude rune) {
-	for letter := 'a'; letter <= 'z'; letter++ {
+	for _, letter := range idLetters {
 		if letter == exclude {
 			continue
 		}
@@ -74,6 +108,17 @@ func addFallbackToId(dfa *DFA, fromState string, toState string, exclude rune) {
 	}
 }
 
+// Whether runes are all letters (so they can be read as an ID)
+func isWord(runes []rune) bool {
+	for _, r := range runes {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+
+	return true
+}
+
 func addWordTransitions(dfa *DFA, keyword string, token TokenType) {
 	curr := StartingState
 	runes := []rune(keyword)
@@ -81,23 +126,38 @@ func addWordTransitions(dfa *DFA, keyword string, token TokenType) {
 	for i, ch := range runes {
 		isLast := i+1 == len(runes)
 
-		var next string
+		// keywords can share a prefix (e.g. min and max, or in and int), so follow the state that is already there
+		key := TransitionKey{curr, ch}
+		next, ok := dfa.Transitions[key]
+		if !ok || next == string(TokenId) {
+			if isLast {
+				next = string(token)
+			} else {
+				next = string(runes[:i+1])
+			}
+			dfa.States[next] = true
+			dfa.Transitions[key] = next
+		}
 
 		if isLast {
-			next = string(token)
-		} else {
-			next = string(runes[:i+1])
+			dfa.FinalStates[next] = token
+		} else if _, ok := dfa.FinalStates[next]; !ok && isWord(runes[:i+1]) {
+			// a prefix of a keyword on its own is an ID (e.g. "i" or "bi")
+			dfa.FinalStates[next] = TokenId
 		}
 
-		dfa.States[next] = true
-		dfa.Transitions[TransitionKey{curr, ch}] = next
-
 		// add fallbacks (e.g. less -> ID if another follows less)
 		exclude := rune(0)
 		if !isLast {
 			exclude = runes[i+1]
 		}
 		addFallbackToId(dfa, next, string(TokenId), exclude)
+		if isWord(runes[:i+1]) {
+			// e.g. b1 or let2
+			for number := '0'; number <= '9'; number++ {
+				dfa.Transitions[TransitionKey{next, number}] = string(TokenId)
+			}
+		}
 
 		curr = next
 	}
@@ -105,14 +165,11 @@ func addWordTransitions(dfa *DFA, keyword string, token TokenType) {
 
 func (dfa *DFA) initTransitions() {
 	// ID transitions
-	for letter := 'a'; letter <= 'z'; letter++ {
+	for _, letter := range idLetters {
 		// start -> ID and ID -> ID with rune
 		dfa.Transitions[TransitionKey{string(TokenId), letter}] = string(TokenId)
 
-		// skip 'l' and 's' as we need those for LET and S.T.
-		if letter == 'l' || letter == 's' {
-			continue
-		}
+		// keywords (e.g. LET and S.T.) replace these in addWordTransitions
 		dfa.Transitions[TransitionKey{StartingState, letter}] = string(TokenId)
 	}
 
@@ -127,15 +184,41 @@ func (dfa *DFA) initTransitions() {
 		dfa.Transitions[TransitionKey{StartingState, number}] = string(TokenNumber)
 		dfa.Transitions[TransitionKey{string(TokenNumber), number}] = string(TokenNumber)
 		dfa.Transitions[TransitionKey{string(TokenDecimal), number}] = string(TokenDecimal)
+
+		// .25 (leading dot needs at least one digit after it)
+		dfa.Transitions[TransitionKey{decimalPointState, number}] = string(TokenDecimal)
+
+		// exponent digits, e.g. 1e-3, 2.5E+10
+		dfa.Transitions[TransitionKey{exponentMarkState, number}] = exponentState
+		dfa.Transitions[TransitionKey{exponentSignState, number}] = exponentState
+		dfa.Transitions[TransitionKey{exponentState, number}] = exponentState
+	}
+
+	dfa.Transitions[TransitionKey{StartingState, '.'}] = decimalPointState
+	dfa.Transitions[TransitionKey{string(TokenNumber), '.'}] = string(TokenDecimal)
+
+	for _, mark := range "eE" {
+		dfa.Transitions[TransitionKey{string(TokenNumber), mark}] = exponentMarkState
+		dfa.Transitions[TransitionKey{string(TokenDecimal), mark}] = exponentMarkState
 	}
+	dfa.Transitions[TransitionKey{exponentMarkState, '+'}] = exponentSignState
+	dfa.Transitions[TransitionKey{exponentMarkState, '-'}] = exponentSignState
 
 	addWordTransitions(dfa, "let", TokenLet)
+	addWordTransitions(dfa, "int", TokenInt)
+	addWordTransitions(dfa, "bin", TokenBin)
+	addWordTransitions(dfa, "set", TokenSet)
+	addWordTransitions(dfa, "param", TokenParam)
+	addWordTransitions(dfa, "sum", TokenSum)
+	addWordTransitions(dfa, "forall", TokenForall)
+	addWordTransitions(dfa, "in", TokenIn)
 	addWordTransitions(dfa, "s.t.", TokenSubjectTo)
 	addWordTransitions(dfa, "min", TokenMin)
 	addWordTransitions(dfa, "max", TokenMax)
 
 	// OPERATOR and SYMBOL transitions
 	dfa.Transitions[TransitionKey{StartingState, ';'}] = string(TokenSemiColon)
+	dfa.Transitions[TransitionKey{StartingState, ':'}] = string(TokenColon)
 
 	dfa.Transitions[TransitionKey{StartingState, '<'}] = "<"
 	dfa.Transitions[TransitionKey{StartingState, '>'}] = ">"
@@ -149,6 +232,12 @@ func (dfa *DFA) initTransitions() {
 	dfa.Transitions[TransitionKey{StartingState, '/'}] = string(TokenDivide)
 	dfa.Transitions[TransitionKey{StartingState, '('}] = string(TokenLParen)
 	dfa.Transitions[TransitionKey{StartingState, ')'}] = string(TokenRParen)
+	dfa.Transitions[TransitionKey{StartingState, '['}] = string(TokenLBracket)
+	dfa.Transitions[TransitionKey{StartingState, ']'}] = string(TokenRBracket)
+	dfa.Transitions[TransitionKey{StartingState, '{'}] = string(TokenLBrace)
+	dfa.Transitions[TransitionKey{StartingState, '}'}] = string(TokenRBrace)
+	dfa.Transitions[TransitionKey{StartingState, ','}] = string(TokenComma)
+	dfa.Transitions[TransitionKey{decimalPointState, '.'}] = string(TokenRange)
 }
 
 func NewDFA() *DFA {
@@ -186,6 +275,12 @@ func (dfa *DFA) Run(input []rune, lineNumber int) (Token, int, error) {
 		}
 	}
 
+	// "1..3" is 1 then "..", not the decimal "1." then ".3"
+	if prevFinalType == TokenDecimal && input[prevFinalPos] == '.' && prevFinalPos+1 < len(input) && input[prevFinalPos+1] == '.' {
+		prevFinalPos--
+		prevFinalType = TokenNumber
+	}
+
 	if prevFinalPos != startPos {
 		return Token{Type: prevFinalType, Value: string(input[:prevFinalPos+1]), Line: lineNumber}, prevFinalPos + 1, nil
 	}
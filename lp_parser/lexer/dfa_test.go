@@ -1,6 +1,7 @@
 package lexer
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -84,3 +85,203 @@ func TestDFA_TokenizeLP(t *testing.T) {
 	}
 	assertTokens(t, input, expected)
 }
+
+func TestDFA_TokenizeDecimal(t *testing.T) {
+	input := "0.5*x1 + .25 - 1e-3*x2\n2.5E+10 3. 4e x1"
+	expected := []Token{
+		{Type: TokenDecimal, Value: "0.5", Line: 1},
+		{Type: TokenAsterisk, Value: "*", Line: 1},
+		{Type: TokenId, Value: "x1", Line: 1},
+		{Type: TokenPlus, Value: "+", Line: 1},
+		{Type: TokenDecimal, Value: ".25", Line: 1},
+		{Type: TokenMinus, Value: "-", Line: 1},
+		{Type: TokenDecimal, Value: "1e-3", Line: 1},
+		{Type: TokenAsterisk, Value: "*", Line: 1},
+		{Type: TokenId, Value: "x2", Line: 1},
+		{Type: TokenDecimal, Value: "2.5E+10", Line: 2},
+		{Type: TokenDecimal, Value: "3.", Line: 2},
+		{Type: TokenNumber, Value: "4", Line: 2},
+		{Type: TokenId, Value: "e", Line: 2},
+		{Type: TokenId, Value: "x1", Line: 2},
+	}
+	assertTokens(t, input, expected)
+}
+
+func TestDFA_TokenizeInvalidDecimal(t *testing.T) {
+	if _, err := Tokenize(strings.NewReader("let x1; max . * x1;")); err == nil {
+		t.Fatalf("expected error for lone decimal point")
+	}
+}
+
+func TestDFA_TokenizeComments(t *testing.T) {
+	input := "let x1; // first variable\n# a whole line\nmax x1 /* block\nstill a comment\n*/ + 2;\n/* inline */ s.t. x1<=5; # done"
+	expected := []Token{
+		{Type: TokenLet, Value: "let", Line: 1},
+		{Type: TokenId, Value: "x1", Line: 1},
+		{Type: TokenSemiColon, Value: ";", Line: 1},
+		{Type: TokenMax, Value: "max", Line: 3},
+		{Type: TokenId, Value: "x1", Line: 3},
+		{Type: TokenPlus, Value: "+", Line: 5},
+		{Type: TokenNumber, Value: "2", Line: 5},
+		{Type: TokenSemiColon, Value: ";", Line: 5},
+		{Type: TokenSubjectTo, Value: "s.t.", Line: 6},
+		{Type: TokenId, Value: "x1", Line: 6},
+		{Type: TokenLessEqual, Value: "<=", Line: 6},
+		{Type: TokenNumber, Value: "5", Line: 6},
+		{Type: TokenSemiColon, Value: ";", Line: 6},
+	}
+	assertTokens(t, input, expected)
+}
+
+func TestDFA_TokenizeUnterminatedComment(t *testing.T) {
+	if _, err := Tokenize(strings.NewReader("let x1;\nmax x1; /* never\nclosed")); err == nil {
+		t.Fatalf("expected error for unterminated block comment")
+	}
+}
+
+func TestDFA_TokenizeDivideNotComment(t *testing.T) {
+	input := "x1/2 / x2"
+	expected := []Token{
+		{Type: TokenId, Value: "x1", Line: 1},
+		{Type: TokenDivide, Value: "/", Line: 1},
+		{Type: TokenNumber, Value: "2", Line: 1},
+		{Type: TokenDivide, Value: "/", Line: 1},
+		{Type: TokenId, Value: "x2", Line: 1},
+	}
+	assertTokens(t, input, expected)
+}
+
+func TestDFA_TokenizeSpans(t *testing.T) {
+	input := "let x1;\r\n/* c\n */ x1<=10;"
+	tokens, err := Tokenize(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Tokenize() error: %v", err)
+	}
+
+	expected := []Span{
+		{Start: Position{Line: 1, Column: 1, Offset: 0}, End: Position{Line: 1, Column: 4, Offset: 3}},
+		{Start: Position{Line: 1, Column: 5, Offset: 4}, End: Position{Line: 1, Column: 7, Offset: 6}},
+		{Start: Position{Line: 1, Column: 7, Offset: 6}, End: Position{Line: 1, Column: 8, Offset: 7}},
+		{Start: Position{Line: 3, Column: 5, Offset: 18}, End: Position{Line: 3, Column: 7, Offset: 20}},
+		{Start: Position{Line: 3, Column: 7, Offset: 20}, End: Position{Line: 3, Column: 9, Offset: 22}},
+		{Start: Position{Line: 3, Column: 9, Offset: 22}, End: Position{Line: 3, Column: 11, Offset: 24}},
+		{Start: Position{Line: 3, Column: 11, Offset: 24}, End: Position{Line: 3, Column: 12, Offset: 25}},
+	}
+
+	if len(tokens) != len(expected) {
+		t.Fatalf("Tokenize() returned %d tokens, want %d", len(tokens), len(expected))
+	}
+
+	for i, token := range tokens {
+		if token.Span != expected[i] {
+			t.Errorf("Token %d (%q) span = %+v; want %+v", i, token.Value, token.Span, expected[i])
+		}
+		if input[token.Span.Start.Offset:token.Span.End.Offset] != token.Value {
+			t.Errorf("Token %d offsets give %q; want %q", i, input[token.Span.Start.Offset:token.Span.End.Offset], token.Value)
+		}
+	}
+}
+
+func TestDFA_TokenizeErrorSpan(t *testing.T) {
+	_, err := Tokenize(strings.NewReader("let x1;\nmax x1 $ 2;"))
+
+	var spanErr *SpanError
+	if !errors.As(err, &spanErr) {
+		t.Fatalf("expected SpanError, got %v", err)
+	}
+	if spanErr.Span.Start.Line != 2 || spanErr.Span.Start.Column != 8 {
+		t.Errorf("error at %s; want line 2, column 8", spanErr.Span.Start)
+	}
+}
+
+func TestDFA_TokenizeConstraintName(t *testing.T) {
+	input := "capacity: x1<=5;"
+	expected := []Token{
+		{Type: TokenId, Value: "capacity", Line: 1},
+		{Type: TokenColon, Value: ":", Line: 1},
+		{Type: TokenId, Value: "x1", Line: 1},
+		{Type: TokenLessEqual, Value: "<=", Line: 1},
+		{Type: TokenNumber, Value: "5", Line: 1},
+		{Type: TokenSemiColon, Value: ";", Line: 1},
+	}
+	assertTokens(t, input, expected)
+}
+
+func TestDFA_TokenizeVariableTypes(t *testing.T) {
+	input := "let int i; let bin b1; let bi; let m; let int2; let lets;"
+	expected := []Token{
+		{Type: TokenLet, Value: "let", Line: 1},
+		{Type: TokenInt, Value: "int", Line: 1},
+		{Type: TokenId, Value: "i", Line: 1},
+		{Type: TokenSemiColon, Value: ";", Line: 1},
+		{Type: TokenLet, Value: "let", Line: 1},
+		{Type: TokenBin, Value: "bin", Line: 1},
+		{Type: TokenId, Value: "b1", Line: 1},
+		{Type: TokenSemiColon, Value: ";", Line: 1},
+		{Type: TokenLet, Value: "let", Line: 1},
+		{Type: TokenId, Value: "bi", Line: 1},
+		{Type: TokenSemiColon, Value: ";", Line: 1},
+		{Type: TokenLet, Value: "let", Line: 1},
+		{Type: TokenId, Value: "m", Line: 1},
+		{Type: TokenSemiColon, Value: ";", Line: 1},
+		{Type: TokenLet, Value: "let", Line: 1},
+		{Type: TokenId, Value: "int2", Line: 1},
+		{Type: TokenSemiColon, Value: ";", Line: 1},
+		{Type: TokenLet, Value: "let", Line: 1},
+		{Type: TokenId, Value: "lets", Line: 1},
+		{Type: TokenSemiColon, Value: ";", Line: 1},
+	}
+	assertTokens(t, input, expected)
+}
+
+func TestDFA_TokenizeIndexing(t *testing.T) {
+	input := "set S = {1,2}; let x[1..3]; forall{i in S}: sum{j in 1..2} x[i] <= 2.5; let int2; let s1;"
+	expected := []Token{
+		{Type: TokenSet, Value: "set", Line: 1},
+		{Type: TokenId, Value: "S", Line: 1},
+		{Type: TokenEqual, Value: "=", Line: 1},
+		{Type: TokenLBrace, Value: "{", Line: 1},
+		{Type: TokenNumber, Value: "1", Line: 1},
+		{Type: TokenComma, Value: ",", Line: 1},
+		{Type: TokenNumber, Value: "2", Line: 1},
+		{Type: TokenRBrace, Value: "}", Line: 1},
+		{Type: TokenSemiColon, Value: ";", Line: 1},
+		{Type: TokenLet, Value: "let", Line: 1},
+		{Type: TokenId, Value: "x", Line: 1},
+		{Type: TokenLBracket, Value: "[", Line: 1},
+		{Type: TokenNumber, Value: "1", Line: 1},
+		{Type: TokenRange, Value: "..", Line: 1},
+		{Type: TokenNumber, Value: "3", Line: 1},
+		{Type: TokenRBracket, Value: "]", Line: 1},
+		{Type: TokenSemiColon, Value: ";", Line: 1},
+		{Type: TokenForall, Value: "forall", Line: 1},
+		{Type: TokenLBrace, Value: "{", Line: 1},
+		{Type: TokenId, Value: "i", Line: 1},
+		{Type: TokenIn, Value: "in", Line: 1},
+		{Type: TokenId, Value: "S", Line: 1},
+		{Type: TokenRBrace, Value: "}", Line: 1},
+		{Type: TokenColon, Value: ":", Line: 1},
+		{Type: TokenSum, Value: "sum", Line: 1},
+		{Type: TokenLBrace, Value: "{", Line: 1},
+		{Type: TokenId, Value: "j", Line: 1},
+		{Type: TokenIn, Value: "in", Line: 1},
+		{Type: TokenNumber, Value: "1", Line: 1},
+		{Type: TokenRange, Value: "..", Line: 1},
+		{Type: TokenNumber, Value: "2", Line: 1},
+		{Type: TokenRBrace, Value: "}", Line: 1},
+		{Type: TokenId, Value: "x", Line: 1},
+		{Type: TokenLBracket, Value: "[", Line: 1},
+		{Type: TokenId, Value: "i", Line: 1},
+		{Type: TokenRBracket, Value: "]", Line: 1},
+		{Type: TokenLessEqual, Value: "<=", Line: 1},
+		{Type: TokenDecimal, Value: "2.5", Line: 1},
+		{Type: TokenSemiColon, Value: ";", Line: 1},
+		{Type: TokenLet, Value: "let", Line: 1},
+		{Type: TokenId, Value: "int2", Line: 1},
+		{Type: TokenSemiColon, Value: ";", Line: 1},
+		{Type: TokenLet, Value: "let", Line: 1},
+		{Type: TokenId, Value: "s1", Line: 1},
+		{Type: TokenSemiColon, Value: ";", Line: 1},
+	}
+	assertTokens(t, input, expected)
+}
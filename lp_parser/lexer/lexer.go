@@ -1,40 +1,136 @@
 package lexer
 
 import (
-	"bufio"
 	"fmt"
 	"io"
 	"strings"
+	"unicode"
 )
 
+// Replaces comments ("// ...", "# ..." and "/* ... */") in line with spaces, so that columns are kept.
+// inBlockComment carries an unterminated "/*" over to the next line.
+// It also returns the rune index of the last "/*" opened on this line (-1 if none).
+func removeComments(line string, inBlockComment *bool) (string, int) {
+	runes := []rune(line)
+	blockCommentStart := -1
+	for i := 0; i < len(runes); i++ {
+		hasNext := i+1 < len(runes)
+
+		if *inBlockComment {
+			if runes[i] == '*' && hasNext && runes[i+1] == '/' {
+				runes[i+1] = ' '
+				*inBlockComment = false
+			}
+			runes[i] = ' '
+			continue
+		}
+
+		if runes[i] == '#' || (runes[i] == '/' && hasNext && runes[i+1] == '/') {
+			// line comment, blank out the rest of the line
+			for j := i; j < len(runes); j++ {
+				runes[j] = ' '
+			}
+			break
+		}
+
+		if runes[i] == '/' && hasNext && runes[i+1] == '*' {
+			runes[i] = ' '
+			runes[i+1] = ' '
+			blockCommentStart = i
+			i++
+			*inBlockComment = true
+		}
+	}
+
+	return string(runes), blockCommentStart
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("line %d, column %d", p.Line, p.Column)
+}
+
+// Join returns the smallest span covering both s and other
+func (s Span) Join(other Span) Span {
+	joined := s
+	if other.Start.Offset < joined.Start.Offset {
+		joined.Start = other.Start
+	}
+	if other.End.Offset > joined.End.Offset {
+		joined.End = other.End
+	}
+	return joined
+}
+
+func (e *SpanError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Span.Start, e.Msg)
+}
+
+// Errorf creates a SpanError at span
+func Errorf(span Span, format string, args ...any) error {
+	return &SpanError{Span: span, Msg: fmt.Sprintf(format, args...)}
+}
+
 func Tokenize(reader io.Reader) ([]Token, error) {
 	dfa := NewDFA()
 	var tokens []Token
 
-	scanner := bufio.NewScanner(reader)
-	lineNum := 0
-	for scanner.Scan() {
-		line := scanner.Text()
-		lineNum++
+	input, err := io.ReadAll(reader)
+	if err != nil {
+		return tokens, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	lineOffset := 0
+	inBlockComment := false
+	var blockCommentSpan Span
+	for lineIdx, rawLine := range strings.Split(string(input), "\n") {
+		lineNum := lineIdx + 1
+		nextLineOffset := lineOffset + len(rawLine) + 1
+		rawLine = strings.TrimSuffix(rawLine, "\r")
 
-		words := strings.Fields(line)
-		for _, word := range words {
-			wordRunes := []rune(word)
-			for len(wordRunes) > 0 {
-				currentToken, lettersRead, err := dfa.Run(wordRunes, lineNum)
+		// byteOffsets[i] is the byte offset of rune i in the line (plus one past the end)
+		byteOffsets := make([]int, 0, len(rawLine)+1)
+		for offset := range rawLine {
+			byteOffsets = append(byteOffsets, offset)
+		}
+		byteOffsets = append(byteOffsets, len(rawLine))
+		position := func(runeIdx int) Position {
+			return Position{Line: lineNum, Column: runeIdx + 1, Offset: lineOffset + byteOffsets[runeIdx]}
+		}
 
+		line, commentStart := removeComments(rawLine, &inBlockComment)
+		if commentStart != -1 {
+			blockCommentSpan = Span{Start: position(commentStart), End: position(commentStart + 2)}
+		}
+		lineRunes := []rune(line)
+
+		for i := 0; i < len(lineRunes); {
+			if unicode.IsSpace(lineRunes[i]) {
+				i++
+				continue
+			}
+
+			wordEnd := i
+			for wordEnd < len(lineRunes) && !unicode.IsSpace(lineRunes[wordEnd]) {
+				wordEnd++
+			}
+
+			for i < wordEnd {
+				currentToken, lettersRead, err := dfa.Run(lineRunes[i:wordEnd], lineNum)
 				if err != nil {
-					return tokens, err
+					return tokens, Errorf(Span{Start: position(i), End: position(wordEnd)}, "%v", err)
 				}
 
+				currentToken.Span = Span{Start: position(i), End: position(i + lettersRead)}
 				tokens = append(tokens, currentToken)
-				wordRunes = wordRunes[lettersRead:]
+				i += lettersRead
 			}
 		}
+
+		lineOffset = nextLineOffset
 	}
 
-	if err := scanner.Err(); err != nil {
-		return tokens, fmt.Errorf("failed to read file: %w", err)
+	if inBlockComment {
+		return tokens, Errorf(blockCommentSpan, "unterminated block comment")
 	}
 
 	return tokens, nil
@@ -2,14 +2,41 @@ package lexer
 
 type TokenType string
 
+// Position in the source. Line and Column (counted in runes) start at 1, Offset is the byte offset from the start.
+type Position struct {
+	Line   int
+	Column int
+	Offset int
+}
+
+// Span of the source from Start up to (not including) End
+type Span struct {
+	Start Position
+	End   Position
+}
+
+// SpanError is an error at a span of the source
+type SpanError struct {
+	Span Span
+	Msg  string
+}
+
 type Token struct {
 	Type  TokenType
 	Value string
 	Line  int
+	Span  Span
 }
 
 const (
 	TokenLet          TokenType = "LET"
+	TokenInt          TokenType = "INT"
+	TokenBin          TokenType = "BIN"
+	TokenSet          TokenType = "SET"
+	TokenParam        TokenType = "PARAM"
+	TokenSum          TokenType = "SUM"
+	TokenForall       TokenType = "FORALL"
+	TokenIn           TokenType = "IN"
 	TokenSubjectTo    TokenType = "S.T."
 	TokenMin          TokenType = "MIN"
 	TokenMax          TokenType = "MAX"
@@ -17,6 +44,7 @@ const (
 	TokenNumber       TokenType = "NUMBER"
 	TokenDecimal      TokenType = "DECIMAL"
 	TokenSemiColon    TokenType = "SEMICOLON"
+	TokenColon        TokenType = "COLON"
 	TokenEqual        TokenType = "EQ"
 	TokenLessEqual    TokenType = "LEQ"
 	TokenGreaterEqual TokenType = "GEQ"
@@ -26,11 +54,25 @@ const (
 	TokenDivide       TokenType = "SLASH"
 	TokenLParen       TokenType = "LPAREN"
 	TokenRParen       TokenType = "RPAREN"
+	TokenLBracket     TokenType = "LBRACKET"
+	TokenRBracket     TokenType = "RBRACKET"
+	TokenLBrace       TokenType = "LBRACE"
+	TokenRBrace       TokenType = "RBRACE"
+	TokenComma        TokenType = "COMMA"
+	TokenRange        TokenType = "RANGE"
 	TokenEOF          TokenType = "EOF"
 )
 
 const StartingState string = "start"
 
+// intermediate (non-final) states for number literals
+const (
+	decimalPointState string = "DECIMAL_POINT"
+	exponentMarkState string = "EXPONENT_MARK"
+	exponentSignState string = "EXPONENT_SIGN"
+	exponentState     string = "EXPONENT"
+)
+
 type TransitionKey struct {
 	State string
 	Input rune
@@ -0,0 +1,68 @@
+package linear
+
+import (
+	"math/big"
+	"strconv"
+)
+
+// ExactExpr is sum(Coefficients[x] * x) + Constant with rational values, keyed by variable name
+type ExactExpr struct {
+	Coefficients map[string]*big.Rat
+	Constant     *big.Rat
+}
+
+func NewExactExpr() *ExactExpr {
+	return &ExactExpr{Coefficients: make(map[string]*big.Rat), Constant: new(big.Rat)}
+}
+
+// Clone returns a copy of e that doesn't share its map or values
+func (e *ExactExpr) Clone() *ExactExpr {
+	clone := &ExactExpr{Coefficients: make(map[string]*big.Rat, len(e.Coefficients)), Constant: new(big.Rat).Set(e.Constant)}
+	for variable, coefficient := range e.Coefficients {
+		clone.Coefficients[variable] = new(big.Rat).Set(coefficient)
+	}
+
+	return clone
+}
+
+// RatFromFloat is the rational with num's shortest decimal representation (so 0.1 is 1/10, not the nearest binary fraction).
+// This is exact for numbers written in a model, which is how formats without exact values (e.g. MPS) are read in exact mode.
+func RatFromFloat(num float64) *big.Rat {
+	rat, ok := new(big.Rat).SetString(strconv.FormatFloat(num, 'g', -1, 64))
+	if !ok {
+		// infinities, which don't appear in linearized programs
+		return new(big.Rat)
+	}
+
+	return rat
+}
+
+// ExactCoefficient is the coefficient of variable without rounding, from e.Exact if it is set and from the float otherwise
+func (e LinearExpr) ExactCoefficient(variable string) *big.Rat {
+	if e.Exact != nil {
+		if coefficient, ok := e.Exact.Coefficients[variable]; ok {
+			return new(big.Rat).Set(coefficient)
+		}
+		return new(big.Rat)
+	}
+
+	return RatFromFloat(e.Coefficients[variable])
+}
+
+// ExactConstant is the constant of e without rounding, from e.Exact if it is set and from the float otherwise
+func (e LinearExpr) ExactConstant() *big.Rat {
+	if e.Exact != nil {
+		return new(big.Rat).Set(e.Exact.Constant)
+	}
+
+	return RatFromFloat(e.Constant)
+}
+
+// ExactRightValue is the right side of c without rounding, from c.ExactRight if it is set and from the float otherwise
+func (c Constraint) ExactRightValue() *big.Rat {
+	if c.ExactRight != nil {
+		return new(big.Rat).Set(c.ExactRight)
+	}
+
+	return RatFromFloat(c.Right)
+}
@@ -0,0 +1,174 @@
+package linear
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/parser"
+)
+
+// LinearExpr is sum(Coefficients[x] * x) + Constant, keyed by variable name
+type LinearExpr struct {
+	Coefficients map[string]float64
+	Constant     float64
+	// Spans records where each variable first appears in the source
+	Spans map[string]lexer.Span
+	// Exact holds the coefficients and constant without rounding, nil unless the program was linearized
+	// in exact mode (see parse_sef.ParseSEFExact)
+	Exact *ExactExpr
+}
+
+type Variable struct {
+	Name string
+	// NonNegative is set for variables declared as "let x >= 0;"
+	NonNegative bool
+	Type        parser.VarType
+	Span        lexer.Span
+}
+
+// IsInteger is whether v must take an integer value (integer and binary variables)
+func (v Variable) IsInteger() bool {
+	return v.Type == parser.VarInteger || v.Type == parser.VarBinary
+}
+
+type Objective struct {
+	IsMax bool
+	Expr  LinearExpr
+	Span  lexer.Span
+}
+
+// Constraint is Left Operator Right, Left.Constant is always 0 (constants are moved to Right)
+type Constraint struct {
+	// Name is empty if the constraint is unnamed
+	Name     string
+	Left     LinearExpr
+	Operator lexer.TokenType
+	Right    float64
+	// ExactRight is Right without rounding, nil unless the program was linearized in exact mode
+	ExactRight *big.Rat
+	Span       lexer.Span
+	// IsRange is whether this is the second half of a ranged constraint (e.g. "lower <= expr <= upper"),
+	// which directly follows the first half and shares its index in the source (see SourceIndices)
+	IsRange bool
+}
+
+// LinearProgram is a linear program with every expression in linear form.
+// Variables are in declaration order, so a variable's index is its position in Variables.
+type LinearProgram struct {
+	Variables   []Variable
+	Objective   Objective
+	Constraints []Constraint
+}
+
+func NewLinearExpr() LinearExpr {
+	return LinearExpr{Coefficients: make(map[string]float64), Spans: make(map[string]lexer.Span)}
+}
+
+// Add adds coefficient * variable to e
+func (e *LinearExpr) Add(variable string, coefficient float64, span lexer.Span) {
+	e.Coefficients[variable] += coefficient
+	if _, ok := e.Spans[variable]; !ok {
+		e.Spans[variable] = span
+	}
+}
+
+// Clone returns a copy of e that doesn't share its maps
+func (e LinearExpr) Clone() LinearExpr {
+	clone := LinearExpr{Coefficients: make(map[string]float64, len(e.Coefficients)), Constant: e.Constant, Spans: make(map[string]lexer.Span, len(e.Spans))}
+	for variable, coefficient := range e.Coefficients {
+		clone.Coefficients[variable] = coefficient
+	}
+	for variable, span := range e.Spans {
+		clone.Spans[variable] = span
+	}
+	if e.Exact != nil {
+		clone.Exact = e.Exact.Clone()
+	}
+
+	return clone
+}
+
+// Dense returns the coefficients as an array indexed by idTable
+func (e LinearExpr) Dense(idTable map[string]int) []float64 {
+	arr := make([]float64, len(idTable))
+	for variable, coefficient := range e.Coefficients {
+		if idx, ok := idTable[variable]; ok {
+			arr[idx] = coefficient
+		}
+	}
+
+	return arr
+}
+
+// HasIntegers is whether any variable of lp must take an integer value
+func (lp *LinearProgram) HasIntegers() bool {
+	for _, v := range lp.Variables {
+		if v.IsInteger() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SourceIndices gives the index of each constraint in the source, where the two halves of a ranged constraint
+// (see Constraint.IsRange) are one constraint
+func (lp *LinearProgram) SourceIndices() []int {
+	indices := make([]int, len(lp.Constraints))
+	index := -1
+	for i, constraint := range lp.Constraints {
+		if !constraint.IsRange || i == 0 {
+			index++
+		}
+		indices[i] = index
+	}
+
+	return indices
+}
+
+// IdTable maps each variable name to its index
+func (lp *LinearProgram) IdTable() map[string]int {
+	idTable := make(map[string]int, len(lp.Variables))
+	for i, v := range lp.Variables {
+		idTable[v.Name] = i
+	}
+
+	return idTable
+}
+
+// A constraint bounding a single variable, at span
+func boundConstraint(v Variable, operator lexer.TokenType, value float64, suffix string, span lexer.Span) Constraint {
+	constraint := Constraint{Name: v.Name + suffix, Left: NewLinearExpr(), Operator: operator, Right: value, Span: span}
+	constraint.Left.Add(v.Name, 1, v.Span)
+
+	return constraint
+}
+
+// BoundConstraints gives the constraints for lower <= v <= upper (either may be infinite), for formats with variable bounds.
+// A lower bound of 0 is left to v.NonNegative, and binary variables between 0 and 1 need no constraints.
+// The constraints are named like "x.lo", "x.up" and "x.fx" (for lower == upper), and are at the source of their bound,
+// lowerSpan or upperSpan ("x.fx" is at lowerSpan, or at upperSpan if the lower bound is the default with no source).
+func BoundConstraints(v Variable, lower float64, upper float64, lowerSpan lexer.Span, upperSpan lexer.Span) []Constraint {
+	switch {
+	case v.Type == parser.VarBinary && lower == 0 && upper == 1:
+		// branch-and-bound adds x <= 1 itself
+		return nil
+	case lower == upper:
+		span := lowerSpan
+		if span == (lexer.Span{}) {
+			span = upperSpan
+		}
+		return []Constraint{boundConstraint(v, lexer.TokenEqual, lower, ".fx", span)}
+	}
+
+	var constraints []Constraint
+	if lower != 0 && !math.IsInf(lower, -1) {
+		constraints = append(constraints, boundConstraint(v, lexer.TokenGreaterEqual, lower, ".lo", lowerSpan))
+	}
+	if !math.IsInf(upper, 1) {
+		constraints = append(constraints, boundConstraint(v, lexer.TokenLessEqual, upper, ".up", upperSpan))
+	}
+
+	return constraints
+}
@@ -0,0 +1,32 @@
+package linear
+
+import (
+	"testing"
+
+	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
+)
+
+func TestLinear_Dense(t *testing.T) {
+	lp := &LinearProgram{Variables: []Variable{{Name: "x1"}, {Name: "x2"}, {Name: "x3"}}}
+
+	e := NewLinearExpr()
+	first := lexer.Span{Start: lexer.Position{Line: 1, Column: 1}}
+	e.Add("x3", 2, first)
+	e.Add("x1", -1, lexer.Span{})
+	e.Add("x3", 0.5, lexer.Span{Start: lexer.Position{Line: 2, Column: 1}})
+
+	got := e.Dense(lp.IdTable())
+	want := []float64{-1, 0, 2.5}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+
+	if e.Spans["x3"] != first {
+		t.Errorf("expected span of first occurrence %+v, got %+v", first, e.Spans["x3"])
+	}
+}
@@ -0,0 +1,603 @@
+package mps
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/linear"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/parser"
+)
+
+// Format is the layout of the data lines of an MPS file
+type Format int
+
+const (
+	// FormatFree separates fields with whitespace (names can't contain spaces)
+	FormatFree Format = iota
+	// FormatFixed has fields at fixed columns (2-3, 5-12, 15-22, 25-36, 40-47 and 50-61)
+	FormatFixed
+)
+
+// Sections of an MPS file
+const (
+	sectionName     = "NAME"
+	sectionObjSense = "OBJSENSE"
+	sectionRows     = "ROWS"
+	sectionColumns  = "COLUMNS"
+	sectionRHS      = "RHS"
+	sectionRanges   = "RANGES"
+	sectionBounds   = "BOUNDS"
+	sectionEnd      = "ENDATA"
+)
+
+// Row types
+const (
+	rowObjective    = "N"
+	rowLessEqual    = "L"
+	rowGreaterEqual = "G"
+	rowEqual        = "E"
+)
+
+// Bound types
+const (
+	boundUpper        = "UP"
+	boundLower        = "LO"
+	boundFixed        = "FX"
+	boundFree         = "FR"
+	boundMinusInf     = "MI"
+	boundPlusInf      = "PL"
+	boundBinary       = "BV"
+	boundIntegerLower = "LI"
+	boundIntegerUpper = "UI"
+)
+
+const markerKeyword = "'MARKER'"
+const markerIntegerStart = "'INTORG'"
+const markerIntegerEnd = "'INTEND'"
+
+// fixedFields are the (1-based, inclusive) columns of each field in fixed MPS
+var fixedFields = [][2]int{{2, 3}, {5, 12}, {15, 22}, {25, 36}, {40, 47}, {50, 61}}
+
+// A field of a data line, with where it is in the source
+type field struct {
+	value string
+	span  lexer.Span
+}
+
+// A data line laid out as in fixed MPS: code, name1, name2, number1, name3, number2 (missing fields are empty)
+type record [6]field
+
+const (
+	fieldCode = iota
+	fieldName1
+	fieldName2
+	fieldNumber1
+	fieldName3
+	fieldNumber2
+)
+
+type row struct {
+	name       string
+	rowType    string
+	constraint *linear.Constraint
+	span       lexer.Span
+}
+
+type column struct {
+	variable *linear.Variable
+	lower    float64
+	upper    float64
+	// lowerSet is whether the lower bound was given (an upper bound below 0 makes a default lower bound -inf)
+	lowerSet bool
+	// lowerSpan and upperSpan are the BOUNDS records that gave the bounds
+	lowerSpan lexer.Span
+	upperSpan lexer.Span
+}
+
+type mpsReader struct {
+	isMax   bool
+	section string
+	// objective is the first N row, other N rows are ignored
+	objective   *row
+	objectiveLP linear.Objective
+	rows        map[string]*row
+	rowOrder    []*row
+	columns     map[string]*column
+	columnOrder []*column
+	inInteger   bool
+	// ranges are the range values of rows (from RANGES)
+	ranges map[*row]float64
+}
+
+// Span of line[from:to] (0-based byte offsets) on line lineNum, which starts at offset lineOffset
+func lineSpan(lineNum int, lineOffset int, from int, to int) lexer.Span {
+	return lexer.Span{
+		Start: lexer.Position{Line: lineNum, Column: from + 1, Offset: lineOffset + from},
+		End:   lexer.Position{Line: lineNum, Column: to + 1, Offset: lineOffset + to},
+	}
+}
+
+// Splits line on whitespace, keeping the span of each field
+func splitFields(line string, lineNum int, lineOffset int) []field {
+	var fields []field
+	start := -1
+	for i := 0; i <= len(line); i++ {
+		isSpace := i == len(line) || line[i] == ' ' || line[i] == '\t'
+		if !isSpace && start == -1 {
+			start = i
+		} else if isSpace && start != -1 {
+			fields = append(fields, field{value: line[start:i], span: lineSpan(lineNum, lineOffset, start, i)})
+			start = -1
+		}
+	}
+
+	return fields
+}
+
+// Lays out the fields of a free MPS data line as a record, which depends on the section
+// (e.g. the RHS set name is optional, so "rhs c1 4" and "c1 4" are both accepted).
+func (r *mpsReader) freeRecord(fields []field, lineSpan lexer.Span) (record, error) {
+	var rec record
+	// fields that aren't given are at the end of the line
+	for i := range rec {
+		rec[i].span = lexer.Span{Start: lineSpan.End, End: lineSpan.End}
+	}
+	switch r.section {
+	case sectionRows:
+		if len(fields) != 2 {
+			return rec, lexer.Errorf(lineSpan, "expected row type and name, received %d fields", len(fields))
+		}
+		rec[fieldCode], rec[fieldName1] = fields[0], fields[1]
+	case sectionColumns:
+		if len(fields) == 3 && fields[1].value == markerKeyword {
+			rec[fieldName1], rec[fieldName2], rec[fieldName3] = fields[0], fields[1], fields[2]
+			break
+		}
+		if len(fields) != 3 && len(fields) != 5 {
+			return rec, lexer.Errorf(lineSpan, "expected column, row and value (and optionally another row and value), received %d fields", len(fields))
+		}
+		rec[fieldName1], rec[fieldName2], rec[fieldNumber1] = fields[0], fields[1], fields[2]
+		if len(fields) == 5 {
+			rec[fieldName3], rec[fieldNumber2] = fields[3], fields[4]
+		}
+	case sectionRHS, sectionRanges:
+		if len(fields)%2 == 1 {
+			// the set name is given
+			rec[fieldName1] = fields[0]
+			fields = fields[1:]
+		}
+		if len(fields) != 2 && len(fields) != 4 {
+			return rec, lexer.Errorf(lineSpan, "expected row and value (and optionally another row and value), received %d fields", len(fields))
+		}
+		rec[fieldName2], rec[fieldNumber1] = fields[0], fields[1]
+		if len(fields) == 4 {
+			rec[fieldName3], rec[fieldNumber2] = fields[2], fields[3]
+		}
+	case sectionBounds:
+		if len(fields) < 2 {
+			return rec, lexer.Errorf(lineSpan, "expected bound type and column, received %d fields", len(fields))
+		}
+		rec[fieldCode] = fields[0]
+		fields = fields[1:]
+
+		hasValue := boundHasValue(rec[fieldCode].value)
+		if (hasValue && len(fields) == 3) || (!hasValue && len(fields) >= 2) {
+			// the set name is given
+			rec[fieldName1] = fields[0]
+			fields = fields[1:]
+		}
+		switch {
+		case len(fields) == 2:
+			rec[fieldNumber1] = fields[1]
+		case len(fields) != 1 || hasValue:
+			return rec, lexer.Errorf(lineSpan, "invalid %v bound", rec[fieldCode].value)
+		}
+		rec[fieldName2] = fields[0]
+	}
+
+	return rec, nil
+}
+
+// Lays out a fixed MPS data line as a record. Missing fields are empty, spanning their columns
+// (or the end of a line that stops before them) so errors about them point at where they should be.
+func fixedRecord(line string, lineNum int, lineOffset int) record {
+	var rec record
+	for i, columns := range fixedFields {
+		from, to := min(columns[0]-1, len(line)), min(columns[1], len(line))
+
+		value := strings.TrimSpace(line[from:to])
+		if value != "" {
+			from += strings.Index(line[from:to], value)
+			to = from + len(value)
+		}
+		rec[i] = field{value: value, span: lineSpan(lineNum, lineOffset, from, to)}
+	}
+
+	return rec
+}
+
+// Whether bounds of type boundType need a value (BV may have one, which is ignored)
+func boundHasValue(boundType string) bool {
+	switch boundType {
+	case boundFree, boundMinusInf, boundPlusInf, boundBinary:
+		return false
+	default:
+		return true
+	}
+}
+
+func parseNumber(f field) (float64, error) {
+	if f.value == "" {
+		return 0, lexer.Errorf(f.span, "missing number")
+	}
+	value, err := strconv.ParseFloat(f.value, 64)
+	if err != nil {
+		return 0, lexer.Errorf(f.span, "invalid number: %v", f.value)
+	}
+
+	return value, nil
+}
+
+// The row called f.value
+func (r *mpsReader) findRow(f field) (*row, error) {
+	if f.value == "" {
+		return nil, lexer.Errorf(f.span, "missing row name")
+	}
+
+	found, ok := r.rows[f.value]
+	if !ok {
+		return nil, lexer.Errorf(f.span, "undeclared row: %v", f.value)
+	}
+
+	return found, nil
+}
+
+func (r *mpsReader) readRow(rec record) error {
+	name := rec[fieldName1]
+	if _, ok := r.rows[name.value]; ok {
+		return lexer.Errorf(name.span, "duplicate row: %v", name.value)
+	}
+
+	newRow := &row{name: name.value, rowType: rec[fieldCode].value, span: rec[fieldCode].span.Join(name.span)}
+	constraint := &linear.Constraint{Name: name.value, Left: linear.NewLinearExpr(), Span: newRow.span}
+	switch newRow.rowType {
+	case rowObjective:
+		if r.objective == nil {
+			r.objective = newRow
+			r.objectiveLP = linear.Objective{IsMax: r.isMax, Expr: linear.NewLinearExpr(), Span: newRow.span}
+		}
+		r.rows[name.value] = newRow
+		return nil
+	case rowLessEqual:
+		constraint.Operator = lexer.TokenLessEqual
+	case rowGreaterEqual:
+		constraint.Operator = lexer.TokenGreaterEqual
+	case rowEqual:
+		constraint.Operator = lexer.TokenEqual
+	default:
+		return lexer.Errorf(rec[fieldCode].span, "invalid row type: %v", newRow.rowType)
+	}
+
+	newRow.constraint = constraint
+	r.rows[name.value] = newRow
+	r.rowOrder = append(r.rowOrder, newRow)
+	return nil
+}
+
+// Adds coefficient * column to the row called rowField.value
+func (r *mpsReader) addEntry(col *column, colSpan lexer.Span, rowField field, valueField field) error {
+	found, err := r.findRow(rowField)
+	if err != nil {
+		return err
+	}
+	value, err := parseNumber(valueField)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case found == r.objective:
+		r.objectiveLP.Expr.Add(col.variable.Name, value, colSpan)
+	case found.constraint != nil:
+		found.constraint.Left.Add(col.variable.Name, value, colSpan)
+	}
+
+	return nil
+}
+
+func (r *mpsReader) readColumn(rec record) error {
+	name := rec[fieldName1]
+	if rec[fieldName2].value == markerKeyword {
+		marker := rec[fieldName3].value
+		if marker == "" {
+			marker = rec[fieldNumber1].value
+		}
+
+		switch marker {
+		case markerIntegerStart:
+			r.inInteger = true
+		case markerIntegerEnd:
+			r.inInteger = false
+		default:
+			return lexer.Errorf(rec[fieldName2].span, "invalid marker: %v", marker)
+		}
+		return nil
+	}
+
+	if name.value == "" {
+		return lexer.Errorf(rec[fieldName2].span, "missing column name")
+	}
+
+	col, ok := r.columns[name.value]
+	if !ok {
+		variable := &linear.Variable{Name: name.value, Type: parser.VarContinuous, Span: name.span}
+		if r.inInteger {
+			variable.Type = parser.VarInteger
+		}
+		col = &column{variable: variable, upper: math.Inf(1)}
+		r.columns[name.value] = col
+		r.columnOrder = append(r.columnOrder, col)
+	}
+
+	if err := r.addEntry(col, name.span, rec[fieldName2], rec[fieldNumber1]); err != nil {
+		return err
+	}
+	if rec[fieldName3].value != "" {
+		return r.addEntry(col, name.span, rec[fieldName3], rec[fieldNumber2])
+	}
+
+	return nil
+}
+
+// Sets the right hand side (or range, if isRange) of the row called rowField.value
+func (r *mpsReader) setRHS(rowField field, valueField field, isRange bool) error {
+	found, err := r.findRow(rowField)
+	if err != nil {
+		return err
+	}
+	value, err := parseNumber(valueField)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case isRange && found.constraint == nil:
+		return lexer.Errorf(rowField.span, "range on objective row: %v", rowField.value)
+	case isRange:
+		r.ranges[found] = value
+	case found == r.objective:
+		// the right hand side of the objective is minus its constant
+		r.objectiveLP.Expr.Constant = -value
+	case found.constraint != nil:
+		found.constraint.Right = value
+	}
+
+	return nil
+}
+
+func (r *mpsReader) readRHS(rec record, isRange bool) error {
+	if err := r.setRHS(rec[fieldName2], rec[fieldNumber1], isRange); err != nil {
+		return err
+	}
+	if rec[fieldName3].value != "" {
+		return r.setRHS(rec[fieldName3], rec[fieldNumber2], isRange)
+	}
+
+	return nil
+}
+
+func (r *mpsReader) readBound(rec record) error {
+	colField := rec[fieldName2]
+	col, ok := r.columns[colField.value]
+	if !ok {
+		return lexer.Errorf(colField.span, "undeclared column: %v", colField.value)
+	}
+
+	boundType := rec[fieldCode].value
+	span := rec[fieldCode].span.Join(colField.span)
+	var value float64
+	if boundHasValue(boundType) {
+		var err error
+		if value, err = parseNumber(rec[fieldNumber1]); err != nil {
+			return err
+		}
+		span = span.Join(rec[fieldNumber1].span)
+	}
+
+	switch boundType {
+	case boundUpper, boundIntegerUpper:
+		col.upper, col.upperSpan = value, span
+		if value < 0 && !col.lowerSet {
+			col.lower = math.Inf(-1)
+		}
+	case boundLower, boundIntegerLower:
+		col.lower, col.lowerSpan = value, span
+		col.lowerSet = true
+	case boundFixed:
+		col.lower, col.upper = value, value
+		col.lowerSpan, col.upperSpan = span, span
+		col.lowerSet = true
+	case boundFree:
+		col.lower, col.upper = math.Inf(-1), math.Inf(1)
+		col.lowerSet = true
+	case boundMinusInf:
+		col.lower = math.Inf(-1)
+		col.lowerSet = true
+	case boundPlusInf:
+		col.upper = math.Inf(1)
+	case boundBinary:
+		col.variable.Type = parser.VarBinary
+		col.lower, col.upper = 0, 1
+		col.lowerSpan, col.upperSpan = span, span
+		col.lowerSet = true
+	default:
+		return lexer.Errorf(rec[fieldCode].span, "unsupported bound type: %v", boundType)
+	}
+
+	if boundType == boundIntegerLower || boundType == boundIntegerUpper {
+		col.variable.Type = parser.VarInteger
+	}
+
+	return nil
+}
+
+// Reads a section header line (fields start at column 1), returning whether the file has ended
+func (r *mpsReader) readSection(fields []field) (bool, error) {
+	header := fields[0]
+	switch header.value {
+	case sectionName:
+		r.section = sectionName
+	case sectionObjSense:
+		r.section = sectionObjSense
+		if len(fields) > 1 {
+			// free MPS allows "OBJSENSE MAX"
+			return false, r.readObjSense(fields[1])
+		}
+	case sectionRows, sectionColumns, sectionRHS, sectionRanges, sectionBounds:
+		r.section = header.value
+	case sectionEnd:
+		return true, nil
+	default:
+		return false, lexer.Errorf(header.span, "unknown section: %v", header.value)
+	}
+
+	return false, nil
+}
+
+func (r *mpsReader) readObjSense(sense field) error {
+	switch sense.value {
+	case "MAX", "MAXIMIZE":
+		r.isMax = true
+	case "MIN", "MINIMIZE":
+		r.isMax = false
+	default:
+		return lexer.Errorf(sense.span, "invalid objective sense: %v", sense.value)
+	}
+
+	r.objectiveLP.IsMax = r.isMax
+	return nil
+}
+
+func (r *mpsReader) readRecord(rec record) error {
+	switch r.section {
+	case sectionRows:
+		return r.readRow(rec)
+	case sectionColumns:
+		return r.readColumn(rec)
+	case sectionRHS:
+		return r.readRHS(rec, false)
+	case sectionRanges:
+		return r.readRHS(rec, true)
+	case sectionBounds:
+		return r.readBound(rec)
+	default:
+		return lexer.Errorf(rec[fieldName1].span, "data in %v section", r.section)
+	}
+}
+
+// Builds the linear program: RANGES turn a row into two constraints (the second named like "c1.range"),
+// and bounds other than x >= 0 and x free become constraints (named like "x.lo", "x.up" and "x.fx").
+func (r *mpsReader) linearProgram() *linear.LinearProgram {
+	lp := &linear.LinearProgram{Objective: r.objectiveLP}
+	if r.objective == nil {
+		lp.Objective = linear.Objective{IsMax: r.isMax, Expr: linear.NewLinearExpr()}
+	}
+
+	for _, rw := range r.rowOrder {
+		constraint := *rw.constraint
+		rangeValue, ok := r.ranges[rw]
+		if !ok {
+			lp.Constraints = append(lp.Constraints, constraint)
+			continue
+		}
+
+		// the row becomes lower <= row <= upper
+		lower, upper := constraint.Right, constraint.Right
+		switch {
+		case constraint.Operator == lexer.TokenLessEqual:
+			lower -= math.Abs(rangeValue)
+		case constraint.Operator == lexer.TokenGreaterEqual:
+			upper += math.Abs(rangeValue)
+		case rangeValue > 0:
+			upper += rangeValue
+		default:
+			lower += rangeValue
+		}
+
+		ranged := linear.Constraint{Name: constraint.Name + ".range", Left: constraint.Left.Clone(), Span: constraint.Span, IsRange: true}
+		if constraint.Operator == lexer.TokenLessEqual {
+			ranged.Operator, ranged.Right = lexer.TokenGreaterEqual, lower
+		} else {
+			constraint.Operator, constraint.Right = lexer.TokenGreaterEqual, lower
+			ranged.Operator, ranged.Right = lexer.TokenLessEqual, upper
+		}
+		lp.Constraints = append(lp.Constraints, constraint, ranged)
+	}
+
+	for _, col := range r.columnOrder {
+		variable := col.variable
+		variable.NonNegative = col.lower >= 0
+		lp.Constraints = append(lp.Constraints, linear.BoundConstraints(*variable, col.lower, col.upper, col.lowerSpan, col.upperSpan)...)
+		lp.Variables = append(lp.Variables, *variable)
+	}
+
+	return lp
+}
+
+// ParseMPS reads a free or fixed MPS file into a linear program (the same as parse_sef.ParseSEF gives),
+// with variables in the order their columns first appear. Errors are returned as a *lexer.SpanError.
+// The first N row is the objective (other N rows are dropped), and the objective is minimized unless OBJSENSE says MAX.
+// Variables without bounds are nonnegative, the other bounds (and RANGES) are added as constraints (see linearProgram).
+func ParseMPS(input string, format Format) (*linear.LinearProgram, error) {
+	r := &mpsReader{
+		rows:    make(map[string]*row),
+		columns: make(map[string]*column),
+		ranges:  make(map[*row]float64),
+	}
+
+	lineOffset := 0
+	ended := false
+	for lineIdx, line := range strings.Split(input, "\n") {
+		lineNum := lineIdx + 1
+		nextLineOffset := lineOffset + len(line) + 1
+		line = strings.TrimRight(line, "\r")
+
+		fields := splitFields(line, lineNum, lineOffset)
+		if len(fields) == 0 || strings.HasPrefix(line, "*") || ended {
+			lineOffset = nextLineOffset
+			continue
+		}
+
+		var err error
+		switch {
+		case line[0] != ' ' && line[0] != '\t':
+			ended, err = r.readSection(fields)
+		case r.section == sectionObjSense:
+			err = r.readObjSense(fields[0])
+		case r.section == "" || r.section == sectionName:
+			err = lexer.Errorf(fields[0].span, "data outside of a section")
+		case format == FormatFixed:
+			err = r.readRecord(fixedRecord(line, lineNum, lineOffset))
+		default:
+			var rec record
+			rec, err = r.freeRecord(fields, lineSpan(lineNum, lineOffset, 0, len(line)))
+			if err == nil {
+				err = r.readRecord(rec)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		lineOffset = nextLineOffset
+	}
+
+	if !ended {
+		end := lineSpan(strings.Count(input, "\n")+1, len(input), 0, 0)
+		return nil, lexer.Errorf(end, "missing %v", sectionEnd)
+	}
+
+	return r.linearProgram(), nil
+}
@@ -0,0 +1,238 @@
+package mps
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/linear"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/parse_sef"
+)
+
+// Lists the program's variables, objective and constraints (dense, by the program's id table)
+func describe(lp *linear.LinearProgram) []string {
+	idTable := lp.IdTable()
+
+	var lines []string
+	for _, v := range lp.Variables {
+		lines = append(lines, fmt.Sprintf("var %s %v %s", v.Name, v.NonNegative, v.Type))
+	}
+	lines = append(lines, fmt.Sprintf("obj %v %v %v", lp.Objective.IsMax, lp.Objective.Expr.Dense(idTable), lp.Objective.Expr.Constant))
+	for _, constraint := range lp.Constraints {
+		lines = append(lines, fmt.Sprintf("%s: %v %s %v", constraint.Name, constraint.Left.Dense(idTable), constraint.Operator, constraint.Right))
+	}
+
+	return lines
+}
+
+func assertDescription(t *testing.T, lp *linear.LinearProgram, want []string) {
+	t.Helper()
+
+	got := describe(lp)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("program mismatch:\nGot:\n%v\nWant:\n%v", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestParseMPS_Fixed(t *testing.T) {
+	input := strings.Join([]string{
+		"NAME          TESTPROB",
+		"ROWS",
+		" N  COST",
+		" L  LIM 1",
+		" G  LIM2",
+		" E  MYEQN",
+		"COLUMNS",
+		"    X ONE     COST      1.0            LIM 1     1.0",
+		"    X ONE     LIM2      1.0",
+		"    YTWO      COST      2.0            LIM 1     1.0",
+		"    YTWO      MYEQN     -1.0",
+		"    ZTHREE    COST      3.0            LIM2      1.0",
+		"    ZTHREE    MYEQN     1.0",
+		"RHS",
+		"              LIM 1     4.0            LIM2      1.0",
+		"              MYEQN     7.0",
+		"BOUNDS",
+		" UP BND1      X ONE     4.0",
+		" LO BND1      YTWO      -1.0",
+		" UP BND1      YTWO      1.0",
+		"ENDATA",
+	}, "\n")
+
+	lp, err := ParseMPS(input, FormatFixed)
+	if err != nil {
+		t.Fatalf("ParseMPS failed: %v", err)
+	}
+
+	assertDescription(t, lp, []string{
+		"var X ONE true continuous",
+		"var YTWO false continuous",
+		"var ZTHREE true continuous",
+		"obj false [1 2 3] 0",
+		"LIM 1: [1 1 0] LEQ 4",
+		"LIM2: [1 0 1] GEQ 1",
+		"MYEQN: [0 -1 1] EQ 7",
+		"X ONE.up: [1 0 0] LEQ 4",
+		"YTWO.lo: [0 1 0] GEQ -1",
+		"YTWO.up: [0 1 0] LEQ 1",
+	})
+}
+
+func TestParseMPS_Free(t *testing.T) {
+	input := `* a comment
+NAME example
+OBJSENSE MAX
+ROWS
+ N profit
+ L cap
+ G demand
+ E balance
+ N other
+COLUMNS
+ x profit 3 cap 1
+ x other 5
+ MARKER 'MARKER' 'INTORG'
+ y profit 2 cap 1
+ y balance 1
+ MARKER 'MARKER' 'INTEND'
+ z profit -1 demand 1
+ z balance -1
+ b profit 1
+RHS
+ rhs profit -10 cap 8
+ demand 2
+RANGES
+ rng cap 3 balance -2
+BOUNDS
+ FR bnd z
+ BV bnd b
+ UI bnd y 5
+ FX x 1.5
+ENDATA
+`
+
+	lp, err := ParseMPS(input, FormatFree)
+	if err != nil {
+		t.Fatalf("ParseMPS failed: %v", err)
+	}
+
+	assertDescription(t, lp, []string{
+		"var x true continuous",
+		"var y true integer",
+		"var z false continuous",
+		"var b true binary",
+		"obj true [3 2 -1 1] 10",
+		"cap: [1 1 0 0] LEQ 8",
+		"cap.range: [1 1 0 0] GEQ 5",
+		"demand: [0 0 1 0] GEQ 2",
+		"balance: [0 1 -1 0] GEQ -2",
+		"balance.range: [0 1 -1 0] LEQ 0",
+		"x.fx: [1 0 0 0] EQ 1.5",
+		"y.up: [0 1 0 0] LEQ 5",
+	})
+
+	if span := lp.Variables[2].Span; span.Start.Line != 17 || span.Start.Column != 2 {
+		t.Errorf("z span %+v; want line 17, column 2", span)
+	}
+	// bound rows are at their BOUNDS records, not at the columns
+	for i, line := range map[int]int{5: 29, 6: 28} {
+		if span := lp.Constraints[i].Span; span.Start.Line != line || span.Start.Column != 2 {
+			t.Errorf("%s span %+v; want line %d, column 2", lp.Constraints[i].Name, span, line)
+		}
+	}
+	if got := lp.SourceIndices(); fmt.Sprint(got) != "[0 0 1 2 2 3 4]" {
+		t.Errorf("source indices %v; want each row with its range at one index", got)
+	}
+}
+
+func TestWriteMPS_RoundTrip(t *testing.T) {
+	lp, err := parse_sef.ParseSEF("let x1 >= 0; let x2; let int n >= 0; let bin b; max 2 * x1 - x2 + n + b + 4; " +
+		"s.t. cap: x1 + x2 + n <= 10; x1 - x2 >= -2; obj: x1 + b = 1;")
+	if err != nil {
+		t.Fatalf("ParseSEF failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := WriteMPS(&out, lp, "ROUND"); err != nil {
+		t.Fatalf("WriteMPS failed: %v", err)
+	}
+
+	want := []string{
+		"var x1 true continuous",
+		"var x2 false continuous",
+		"var n true integer",
+		"var b true binary",
+		"obj true [2 -1 1 1] 4",
+		"cap: [1 1 1 0] LEQ 10",
+		"R2: [1 -1 0 0] GEQ -2",
+		"obj: [1 0 0 1] EQ 1",
+	}
+	// names fit in 8 characters, so the output is both free and fixed MPS
+	for _, format := range []Format{FormatFree, FormatFixed} {
+		read, err := ParseMPS(out.String(), format)
+		if err != nil {
+			t.Fatalf("ParseMPS failed: %v\n%v", err, out.String())
+		}
+		assertDescription(t, read, want)
+	}
+
+	lp.Variables[0].Name = "x 1"
+	if err := WriteMPS(&bytes.Buffer{}, lp, "ROUND"); err == nil {
+		t.Errorf("expected an error for a name with a space")
+	}
+}
+
+func TestParseMPS_Errors(t *testing.T) {
+	tests := []struct {
+		input string
+		line  int
+	}{
+		{"NAME t\nROWS\n N obj\nCOLUMNS\n x missing 1\nENDATA", 5},
+		{"NAME t\nROWS\n N obj\n X c1\nENDATA", 4},
+		{"NAME t\nROWS\n N obj\nCOLUMNS\n x obj one\nENDATA", 5},
+		{"NAME t\nROWS\n N obj\nCOLUMNS\n x obj 1\nBOUNDS\n SC bnd x 1\nENDATA", 7},
+		{"NAME t\nROWS\n N obj\nCOLUMNS\n x obj 1\n", 6},
+	}
+
+	for _, test := range tests {
+		_, err := ParseMPS(test.input, FormatFree)
+
+		var spanErr *lexer.SpanError
+		if !errors.As(err, &spanErr) {
+			t.Errorf("expected SpanError for %q, got %v", test.input, err)
+			continue
+		}
+		if spanErr.Span.Start.Line != test.line {
+			t.Errorf("%q: error %v on line %d, want line %d", test.input, err, spanErr.Span.Start.Line, test.line)
+		}
+	}
+
+	// missing fixed fields are reported where they should be, not at line 0
+	fixed := []struct {
+		input  string
+		line   int
+		column int
+	}{
+		// the row name belongs in columns 15 to 22
+		{"NAME t\nROWS\n N  obj\nCOLUMNS\n    x                      1\nENDATA", 5, 15},
+		// the line stops before the row name
+		{"NAME t\nROWS\n N  obj\nCOLUMNS\n    x\nENDATA", 5, 6},
+		{"NAME t\nROWS\n N  obj\n L  c1\nCOLUMNS\n    x         c1        1\nRHS\n    rhs       c1\nENDATA", 8, 17},
+	}
+	for _, test := range fixed {
+		_, err := ParseMPS(test.input, FormatFixed)
+
+		var spanErr *lexer.SpanError
+		if !errors.As(err, &spanErr) {
+			t.Errorf("expected SpanError for %q, got %v", test.input, err)
+			continue
+		}
+		if spanErr.Span.Start.Line != test.line || spanErr.Span.Start.Column != test.column {
+			t.Errorf("%q: error %v at line %d, column %d, want line %d, column %d",
+				test.input, err, spanErr.Span.Start.Line, spanErr.Span.Start.Column, test.line, test.column)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package mps
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/linear"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/parser"
+)
+
+const objectiveRow = "obj"
+const rhsSet = "RHS"
+const boundSet = "BND"
+
+var rowTypes = map[lexer.TokenType]string{
+	lexer.TokenLessEqual:    rowLessEqual,
+	lexer.TokenGreaterEqual: rowGreaterEqual,
+	lexer.TokenEqual:        rowEqual,
+}
+
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// Writes a data line with fields at the fixed MPS columns (longer fields push the rest along, which is still valid free MPS)
+func writeRecord(w *bufio.Writer, fields ...string) {
+	var line strings.Builder
+	for i, f := range fields {
+		if f == "" {
+			continue
+		}
+		for line.Len() < fixedFields[i][0]-1 {
+			line.WriteByte(' ')
+		}
+		if line.Len() > fixedFields[i][0]-1 {
+			line.WriteByte(' ')
+		}
+		line.WriteString(f)
+	}
+	line.WriteByte('\n')
+	w.WriteString(line.String())
+}
+
+// Names of the rows of lp: constraint names, or R1, R2, ... for unnamed constraints (renamed if they clash)
+func rowNames(lp *linear.LinearProgram) (string, []string) {
+	used := make(map[string]bool)
+	for _, constraint := range lp.Constraints {
+		used[constraint.Name] = true
+	}
+
+	unique := func(name string) string {
+		for suffix := 1; used[name]; suffix++ {
+			name = fmt.Sprintf("%s_%d", strings.TrimRight(name, "_0123456789"), suffix)
+		}
+		used[name] = true
+		return name
+	}
+
+	objective := unique(objectiveRow)
+	names := make([]string, len(lp.Constraints))
+	for i, constraint := range lp.Constraints {
+		if constraint.Name != "" {
+			names[i] = constraint.Name
+		} else {
+			names[i] = unique("R" + strconv.Itoa(i+1))
+		}
+	}
+
+	return objective, names
+}
+
+// WriteMPS writes lp as an MPS file called name. Fields are aligned to the fixed MPS columns,
+// so the output is fixed MPS when every name fits in 8 characters, and free MPS otherwise.
+// Free variables are written as FR bounds, binary variables as BV, and integer variables between markers.
+func WriteMPS(w io.Writer, lp *linear.LinearProgram, name string) error {
+	objective, names := rowNames(lp)
+	allNames := append([]string{name}, names...)
+	for _, v := range lp.Variables {
+		allNames = append(allNames, v.Name)
+	}
+	for _, n := range allNames {
+		if strings.ContainsAny(n, " \t") {
+			return fmt.Errorf("MPS name %q contains whitespace", n)
+		}
+	}
+
+	// the nonzero entries of each column, in row order
+	type entry struct {
+		row         string
+		coefficient float64
+	}
+	columns := make(map[string][]entry, len(lp.Variables))
+	for variable, coefficient := range lp.Objective.Expr.Coefficients {
+		if coefficient != 0 {
+			columns[variable] = append(columns[variable], entry{row: objective, coefficient: coefficient})
+		}
+	}
+	for i, constraint := range lp.Constraints {
+		if _, ok := rowTypes[constraint.Operator]; !ok {
+			return fmt.Errorf("invalid operator %v in constraint %v", constraint.Operator, names[i])
+		}
+		for variable, coefficient := range constraint.Left.Coefficients {
+			if coefficient != 0 {
+				columns[variable] = append(columns[variable], entry{row: names[i], coefficient: coefficient})
+			}
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%-14s%s\n", sectionName, name)
+	if lp.Objective.IsMax {
+		fmt.Fprintf(bw, "%s\n    MAX\n", sectionObjSense)
+	}
+
+	fmt.Fprintln(bw, sectionRows)
+	writeRecord(bw, rowObjective, objective)
+	for i, constraint := range lp.Constraints {
+		writeRecord(bw, rowTypes[constraint.Operator], names[i])
+	}
+
+	fmt.Fprintln(bw, sectionColumns)
+	inInteger := false
+	for _, v := range lp.Variables {
+		if v.IsInteger() != inInteger {
+			marker := markerIntegerStart
+			if inInteger {
+				marker = markerIntegerEnd
+			}
+			writeRecord(bw, "", "MARKER", markerKeyword, "", marker)
+			inInteger = !inInteger
+		}
+
+		for _, e := range columns[v.Name] {
+			writeRecord(bw, "", v.Name, e.row, formatNumber(e.coefficient))
+		}
+		if len(columns[v.Name]) == 0 {
+			// every column has to appear to be declared
+			writeRecord(bw, "", v.Name, objective, "0")
+		}
+	}
+	if inInteger {
+		writeRecord(bw, "", "MARKER", markerKeyword, "", markerIntegerEnd)
+	}
+
+	fmt.Fprintln(bw, sectionRHS)
+	if constant := lp.Objective.Expr.Constant; constant != 0 {
+		writeRecord(bw, "", rhsSet, objective, formatNumber(-constant))
+	}
+	for i, constraint := range lp.Constraints {
+		if rhs := constraint.Right - constraint.Left.Constant; rhs != 0 {
+			writeRecord(bw, "", rhsSet, names[i], formatNumber(rhs))
+		}
+	}
+
+	fmt.Fprintln(bw, sectionBounds)
+	for _, v := range lp.Variables {
+		switch {
+		case v.Type == parser.VarBinary:
+			writeRecord(bw, boundBinary, boundSet, v.Name)
+		case !v.NonNegative:
+			writeRecord(bw, boundFree, boundSet, v.Name)
+		case v.IsInteger():
+			// some readers give integer variables an upper bound of 1 by default
+			writeRecord(bw, boundPlusInf, boundSet, v.Name)
+		}
+	}
+
+	fmt.Fprintln(bw, sectionEnd)
+	return bw.Flush()
+}
@@ -1,38 +1,130 @@
 package parse_sef
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
+	"github.com/animalat/Simplex-Algorithm/lp_parser/expand"
 	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/linear"
 	"github.com/animalat/Simplex-Algorithm/lp_parser/parser"
 	"github.com/animalat/Simplex-Algorithm/lp_parser/semantics"
 	"github.com/animalat/Simplex-Algorithm/lp_parser/simplify"
 )
 
-// Combines everything else and returns a parsed, simplified program.
+// Stage of ParseSEF that an error came from
+type Stage string
+
+const (
+	StageLex      Stage = "lex"
+	StageParse    Stage = "parse"
+	StageExpand   Stage = "expand"
+	StageSimplify Stage = "simplify"
+	StageSemantic Stage = "semantic"
+)
+
+var stageDescriptions = map[Stage]string{
+	StageLex:      "error tokenizing",
+	StageParse:    "error parsing",
+	StageExpand:   "error expanding indexed expressions",
+	StageSimplify: "error simplifying expression",
+	StageSemantic: "semantic check failed",
+}
+
+// StageError records which stage of ParseSEF failed
+type StageError struct {
+	Stage Stage
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return fmt.Sprintf("%s: %v", stageDescriptions[e.Stage], e.Err)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// Combines everything else and returns the program in linear form (see linear.LinearProgram),
+// variable indices are given by LinearProgram.IdTable.
 // Note that converting the objective function from MIN to MAX is not a concern of this function.
-func ParseSEF(progStr string) (*parser.Program, map[string]int, error) {
+// Errors are returned as a *StageError.
+func ParseSEF(progStr string) (*linear.LinearProgram, error) {
+	return ParseSEFContext(context.Background(), progStr, false)
+}
+
+// ParseSEFExact is ParseSEF in exact mode: the program also gets its values without rounding (see linear.LinearExpr.Exact),
+// folded with rationals instead of floats.
+func ParseSEFExact(progStr string) (*linear.LinearProgram, error) {
+	return ParseSEFContext(context.Background(), progStr, true)
+}
+
+// ParseSEFContext is ParseSEF (or ParseSEFExact if exact is set), stopped with ctx's error (as a StageExpand error)
+// if ctx is done while expanding, see expand.ExpandProgramContext
+func ParseSEFContext(ctx context.Context, progStr string, exact bool) (*linear.LinearProgram, error) {
 	tokens, err := lexer.Tokenize(strings.NewReader(progStr))
 	if err != nil {
-		return nil, nil, fmt.Errorf("error tokenizing: %v", err)
+		return nil, &StageError{Stage: StageLex, Err: err}
 	}
 
 	parseProg := parser.ConstructParser(tokens)
 	prog, err := parseProg.ParseProgram()
 	if err != nil {
-		return nil, nil, fmt.Errorf("error parsing: %v", err)
+		return nil, &StageError{Stage: StageParse, Err: err}
+	}
+
+	return LinearizeProgramContext(ctx, prog, exact)
+}
+
+// LinearizeProgram runs the stages after parsing (expanding, simplifying and the semantic check) on a parsed program,
+// for programs that come from other formats (e.g. cplex.ParseLP). Note that prog is modified.
+// Errors are returned as a *StageError.
+func LinearizeProgram(prog *parser.Program) (*linear.LinearProgram, error) {
+	return LinearizeProgramContext(context.Background(), prog, false)
+}
+
+// LinearizeProgramExact is LinearizeProgram in exact mode (see ParseSEFExact)
+func LinearizeProgramExact(prog *parser.Program) (*linear.LinearProgram, error) {
+	return LinearizeProgramContext(context.Background(), prog, true)
+}
+
+// LinearizeProgramContext is LinearizeProgram (or LinearizeProgramExact if exact is set) stopped once ctx is done, see ParseSEFContext
+func LinearizeProgramContext(ctx context.Context, prog *parser.Program, exact bool) (*linear.LinearProgram, error) {
+	if err := expand.ExpandProgramContext(ctx, prog); err != nil {
+		return nil, &StageError{Stage: StageExpand, Err: err}
+	}
+
+	// folded before simplifying, which rounds the program in place
+	var exactObjective *linear.ExactExpr
+	var exactConstraints []*linear.ExactExpr
+	if exact {
+		if _, err := simplify.ParamValues(prog); err != nil {
+			return nil, &StageError{Stage: StageSimplify, Err: err}
+		}
+		var err error
+		exactObjective, exactConstraints, err = simplify.ExactLinearizeProgram(prog)
+		if err != nil {
+			return nil, &StageError{Stage: StageSimplify, Err: err}
+		}
 	}
 
-	err = simplify.SimplifyProgram(prog)
+	if err := simplify.SimplifyProgram(prog); err != nil {
+		return nil, &StageError{Stage: StageSimplify, Err: err}
+	}
+
+	_, varTypes, err := semantics.SemanticCheck(prog)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error simplifying expression: %v", err)
+		return nil, &StageError{Stage: StageSemantic, Err: err}
 	}
 
-	idTable, err := semantics.SemanticCheck(prog)
+	lp, err := simplify.LinearizeProgram(prog, varTypes)
 	if err != nil {
-		return nil, nil, fmt.Errorf("semantic check failed: %v", err)
+		return nil, &StageError{Stage: StageSimplify, Err: err}
+	}
+	if exact {
+		simplify.AttachExact(lp, exactObjective, exactConstraints)
 	}
 
-	return prog, idTable, nil
+	return lp, nil
 }
@@ -3,6 +3,7 @@ package parser
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
 )
@@ -11,18 +12,43 @@ func (u *UnaryExpr) exprNode()     {}
 func (b *BinaryExpr) exprNode()    {}
 func (n *NumberLiteral) exprNode() {}
 func (v *Variable) exprNode()      {}
+func (s *SumExpr) exprNode()       {}
+
+func (u *UnaryExpr) Pos() lexer.Span     { return u.Span }
+func (b *BinaryExpr) Pos() lexer.Span    { return b.Span }
+func (n *NumberLiteral) Pos() lexer.Span { return n.Span }
+func (v *Variable) Pos() lexer.Span      { return v.ID.Span }
+func (s *SumExpr) Pos() lexer.Span       { return s.Span }
+
+// SpanOf is e.Pos(), or an empty span if there is no Expr
+func SpanOf(e Expr) lexer.Span {
+	if e == nil {
+		return lexer.Span{}
+	}
+	return e.Pos()
+}
 
 func ConstructParser(tokens []lexer.Token) *Parser {
 	return &Parser{Tokens: tokens}
 }
 
 func (p *Parser) Peek() (lexer.Token, error) {
-	if p.Pos >= len(p.Tokens) {
-		// Return EOF token
+	return p.PeekAhead(0)
+}
+
+// PeekAhead looks at the token n tokens after the current one
+func (p *Parser) PeekAhead(n int) (lexer.Token, error) {
+	if p.Pos+n >= len(p.Tokens) {
+		// Return EOF token (placed at the end of the last token)
 		const endLine = -1
-		return lexer.Token{Type: lexer.TokenEOF, Value: "", Line: endLine}, nil
+		if len(p.Tokens) == 0 {
+			return lexer.Token{Type: lexer.TokenEOF, Value: "", Line: endLine}, nil
+		}
+
+		end := p.Tokens[len(p.Tokens)-1].Span.End
+		return lexer.Token{Type: lexer.TokenEOF, Value: "", Line: end.Line, Span: lexer.Span{Start: end, End: end}}, nil
 	}
-	return p.Tokens[p.Pos], nil
+	return p.Tokens[p.Pos+n], nil
 }
 
 func (p *Parser) Advance() (lexer.Token, error) {
@@ -42,18 +68,81 @@ func (p *Parser) Expect(tt lexer.TokenType) (lexer.Token, error) {
 	}
 
 	if tt != token.Type {
-		return token, fmt.Errorf("token type does not match at line %d: Expected %s but got %s", token.Line, tt, token.Type)
+		return token, lexer.Errorf(token.Span, "token type does not match: Expected %s but got %s", tt, token.Type)
 	}
 
 	return token, nil
 }
 
+// Keywords added for integer and indexed models, which are only keywords where the grammar expects them and are names
+// elsewhere (so models written before them, e.g. "let sum;", "let in;" or "let bin;", still parse)
+var contextualKeywords = map[lexer.TokenType]bool{
+	lexer.TokenInt:    true,
+	lexer.TokenBin:    true,
+	lexer.TokenSet:    true,
+	lexer.TokenParam:  true,
+	lexer.TokenSum:    true,
+	lexer.TokenForall: true,
+	lexer.TokenIn:     true,
+}
+
+// Whether token can be a name (an ID or a contextual keyword)
+func isName(token lexer.Token) bool {
+	return token.Type == lexer.TokenId || contextualKeywords[token.Type]
+}
+
+// token as an ID, if it is a contextual keyword used as a name
+func asName(token lexer.Token) lexer.Token {
+	if contextualKeywords[token.Type] {
+		token.Type = lexer.TokenId
+	}
+	return token
+}
+
+// ExpectName is Expect(lexer.TokenId), also accepting contextual keywords as names
+func (p *Parser) ExpectName() (lexer.Token, error) {
+	token, err := p.Peek()
+	if err != nil {
+		return token, err
+	}
+	if contextualKeywords[token.Type] {
+		p.Pos++
+		return asName(token), nil
+	}
+
+	return p.Expect(lexer.TokenId)
+}
+
 func (p *Parser) ParseDecl() (*Decl, error) {
 	if _, err := p.Expect(lexer.TokenLet); err != nil {
 		return nil, err
 	}
 
-	token, err := p.Expect(lexer.TokenId)
+	varType, err := p.ParseDeclType()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := p.ExpectName()
+	if err != nil {
+		return nil, err
+	}
+
+	var index []*Iterator
+	next, err := p.Peek()
+	if err != nil {
+		return nil, err
+	}
+	if next.Type == lexer.TokenLBracket {
+		if _, err = p.Advance(); err != nil {
+			return nil, err
+		}
+		if index, err = p.ParseIterators(lexer.TokenRBracket); err != nil {
+			return nil, err
+		}
+	}
+
+	nonNegative, err := p.ParseDeclBound()
 	if err != nil {
 		return nil, err
 	}
@@ -62,7 +151,282 @@ func (p *Parser) ParseDecl() (*Decl, error) {
 		return nil, err
 	}
 
-	return &Decl{ID: token}, nil
+	return &Decl{ID: token, NonNegative: nonNegative, Type: varType, Index: index}, nil
+}
+
+// Parses "set S = {a, b, c};" or "set S = 1..n;"
+func (p *Parser) ParseSetDecl() (*SetDecl, error) {
+	start, err := p.Expect(lexer.TokenSet)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := p.ExpectName()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = p.Expect(lexer.TokenEqual); err != nil {
+		return nil, err
+	}
+
+	set := &SetDecl{ID: token}
+	next, err := p.Peek()
+	if err != nil {
+		return nil, err
+	}
+	if next.Type == lexer.TokenLBrace {
+		if _, err = p.Advance(); err != nil {
+			return nil, err
+		}
+		if set.Elements, err = p.ParseExprList(lexer.TokenRBrace); err != nil {
+			return nil, err
+		}
+	} else if set.From, set.To, err = p.ParseRange(); err != nil {
+		return nil, err
+	}
+
+	semiColon, err := p.Expect(lexer.TokenSemiColon)
+	if err != nil {
+		return nil, err
+	}
+	set.Span = start.Span.Join(semiColon.Span)
+
+	return set, nil
+}
+
+// Parses "param cost = 12.5;", "param demand[1..3] = {4, 5, 6};" or "param double[i in 1..3] = 2 * i;"
+func (p *Parser) ParseParamDecl() (*ParamDecl, error) {
+	start, err := p.Expect(lexer.TokenParam)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := p.ExpectName()
+	if err != nil {
+		return nil, err
+	}
+
+	param := &ParamDecl{ID: token}
+	next, err := p.Peek()
+	if err != nil {
+		return nil, err
+	}
+	if next.Type == lexer.TokenLBracket {
+		if _, err = p.Advance(); err != nil {
+			return nil, err
+		}
+		if param.Index, err = p.ParseIterators(lexer.TokenRBracket); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err = p.Expect(lexer.TokenEqual); err != nil {
+		return nil, err
+	}
+
+	if next, err = p.Peek(); err != nil {
+		return nil, err
+	}
+	if next.Type == lexer.TokenLBrace {
+		if param.Index == nil {
+			return nil, lexer.Errorf(next.Span, "a list of values needs an indexed parameter: %v", token.Value)
+		}
+		if _, err = p.Advance(); err != nil {
+			return nil, err
+		}
+		if param.Values, err = p.ParseExprList(lexer.TokenRBrace); err != nil {
+			return nil, err
+		}
+	} else if param.Value, err = p.ParseExpr(); err != nil {
+		return nil, err
+	}
+
+	semiColon, err := p.Expect(lexer.TokenSemiColon)
+	if err != nil {
+		return nil, err
+	}
+	param.Span = start.Span.Join(semiColon.Span)
+
+	return param, nil
+}
+
+// Parses "from..to"
+func (p *Parser) ParseRange() (Expr, Expr, error) {
+	from, err := p.ParseExpr()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err = p.Expect(lexer.TokenRange); err != nil {
+		return nil, nil, err
+	}
+
+	to, err := p.ParseExpr()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return from, to, nil
+}
+
+// Parses a comma separated list of expressions, up to and including closing (e.g. "1, 2, 3}")
+func (p *Parser) ParseExprList(closing lexer.TokenType) ([]Expr, error) {
+	var list []Expr
+	for {
+		expr, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, expr)
+
+		token, err := p.Advance()
+		if err != nil {
+			return nil, err
+		}
+		switch token.Type {
+		case lexer.TokenComma:
+		case closing:
+			return list, nil
+		default:
+			return nil, lexer.Errorf(token.Span, "expected %s or %s but got %s", lexer.TokenComma, closing, token.Type)
+		}
+	}
+}
+
+// Parses a single iterator, e.g. "i in S", "i in 1..n", "S" or "1..n"
+func (p *Parser) ParseIterator() (*Iterator, error) {
+	token, err := p.Peek()
+	if err != nil {
+		return nil, err
+	}
+	next, err := p.PeekAhead(1)
+	if err != nil {
+		return nil, err
+	}
+
+	it := &Iterator{Span: token.Span}
+	if isName(token) && next.Type == lexer.TokenIn {
+		p.Pos += 2
+		variable := asName(token)
+		it.Var = &variable
+
+		if token, err = p.Peek(); err != nil {
+			return nil, err
+		}
+		if next, err = p.PeekAhead(1); err != nil {
+			return nil, err
+		}
+	}
+
+	// a set name is on its own, anything else is a range
+	if isName(token) && (next.Type == lexer.TokenComma || next.Type == lexer.TokenRBracket || next.Type == lexer.TokenRBrace) {
+		p.Pos++
+		token = asName(token)
+		it.Set = &token
+		it.Span = it.Span.Join(token.Span)
+		return it, nil
+	}
+
+	if it.From, it.To, err = p.ParseRange(); err != nil {
+		return nil, err
+	}
+	it.Span = it.Span.Join(it.To.Pos())
+
+	return it, nil
+}
+
+// Parses a comma separated list of iterators, up to and including closing (e.g. "i in S, j in 1..3}")
+func (p *Parser) ParseIterators(closing lexer.TokenType) ([]*Iterator, error) {
+	var iterators []*Iterator
+	for {
+		it, err := p.ParseIterator()
+		if err != nil {
+			return nil, err
+		}
+		iterators = append(iterators, it)
+
+		token, err := p.Advance()
+		if err != nil {
+			return nil, err
+		}
+		switch token.Type {
+		case lexer.TokenComma:
+		case closing:
+			return iterators, nil
+		default:
+			return nil, lexer.Errorf(token.Span, "expected %s or %s but got %s", lexer.TokenComma, closing, token.Type)
+		}
+	}
+}
+
+// Parses "{i in S}" after sum or forall
+func (p *Parser) ParseIteratorBlock() ([]*Iterator, error) {
+	if _, err := p.Expect(lexer.TokenLBrace); err != nil {
+		return nil, err
+	}
+
+	return p.ParseIterators(lexer.TokenRBrace)
+}
+
+// Parses the optional "int" or "bin" before a declared variable (variables are continuous otherwise)
+func (p *Parser) ParseDeclType() (VarType, error) {
+	token, err := p.Peek()
+	if err != nil {
+		return "", err
+	}
+
+	var varType VarType
+	switch token.Type {
+	case lexer.TokenInt:
+		varType = VarInteger
+	case lexer.TokenBin:
+		varType = VarBinary
+	default:
+		return VarContinuous, nil
+	}
+
+	// a type needs a name after it, "let int;" declares a variable called int
+	next, err := p.PeekAhead(1)
+	if err != nil {
+		return "", err
+	}
+	if !isName(next) {
+		return VarContinuous, nil
+	}
+
+	if _, err = p.Advance(); err != nil {
+		return "", err
+	}
+
+	return varType, nil
+}
+
+// Parses the optional ">= 0" after a declared variable, returning whether it was there
+func (p *Parser) ParseDeclBound() (bool, error) {
+	token, err := p.Peek()
+	if err != nil {
+		return false, err
+	}
+	if token.Type != lexer.TokenGreaterEqual {
+		return false, nil
+	}
+
+	if _, err = p.Advance(); err != nil {
+		return false, err
+	}
+
+	bound, err := p.ParseFactor()
+	if err != nil {
+		return false, err
+	}
+
+	nl, ok := bound.(*NumberLiteral)
+	if !ok || nl.Value != 0 {
+		return false, lexer.Errorf(bound.Pos(), "only nonnegativity bounds (>= 0) are supported in declarations, got %v", bound)
+	}
+
+	return true, nil
 }
 
 func (p *Parser) ParseObjective() (*Objective, error) {
@@ -72,8 +436,9 @@ func (p *Parser) ParseObjective() (*Objective, error) {
 	}
 
 	if token.Type != lexer.TokenMax && token.Type != lexer.TokenMin {
-		return nil, fmt.Errorf("token min or max not found at line %d", token.Line)
+		return nil, lexer.Errorf(token.Span, "token min or max not found")
 	}
+	start := token.Span
 	isMax := token.Type == lexer.TokenMax
 
 	expr, err := p.ParseExpr()
@@ -86,10 +451,67 @@ func (p *Parser) ParseObjective() (*Objective, error) {
 		return nil, err
 	}
 
-	return &Objective{IsMax: isMax, Expr: expr}, nil
+	return &Objective{IsMax: isMax, Expr: expr, Span: start.Join(token.Span)}, nil
+}
+
+func isComparison(tt lexer.TokenType) bool {
+	return tt == lexer.TokenLessEqual || tt == lexer.TokenEqual || tt == lexer.TokenGreaterEqual
+}
+
+// Parses the optional "name:" in front of a constraint, returning the name token if there is one
+func (p *Parser) ParseConstraintName() (*lexer.Token, error) {
+	token, err := p.Peek()
+	if err != nil {
+		return nil, err
+	}
+	next, err := p.PeekAhead(1)
+	if err != nil {
+		return nil, err
+	}
+	if !isName(token) || next.Type != lexer.TokenColon {
+		return nil, nil
+	}
+
+	name, err := p.ExpectName()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = p.Expect(lexer.TokenColon); err != nil {
+		return nil, err
+	}
+
+	return &name, nil
 }
 
 func (p *Parser) ParseConstraint() (*Constraint, error) {
+	token, err := p.Peek()
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := p.PeekAhead(1)
+	if err != nil {
+		return nil, err
+	}
+
+	var forAll []*Iterator
+	if token.Type == lexer.TokenForall && next.Type == lexer.TokenLBrace {
+		if _, err = p.Advance(); err != nil {
+			return nil, err
+		}
+		if forAll, err = p.ParseIteratorBlock(); err != nil {
+			return nil, err
+		}
+		if _, err = p.Expect(lexer.TokenColon); err != nil {
+			return nil, err
+		}
+	}
+
+	name, err := p.ParseConstraintName()
+	if err != nil {
+		return nil, err
+	}
+
 	left, err := p.ParseExpr()
 	if err != nil {
 		return nil, err
@@ -99,8 +521,8 @@ func (p *Parser) ParseConstraint() (*Constraint, error) {
 	if err != nil {
 		return nil, err
 	}
-	if op.Type != lexer.TokenLessEqual && op.Type != lexer.TokenEqual && op.Type != lexer.TokenGreaterEqual {
-		return nil, fmt.Errorf("operator not found at line %d", op.Line)
+	if !isComparison(op.Type) {
+		return nil, lexer.Errorf(op.Span, "operator not found")
 	}
 
 	right, err := p.ParseExpr()
@@ -108,11 +530,52 @@ func (p *Parser) ParseConstraint() (*Constraint, error) {
 		return nil, err
 	}
 
-	if _, err = p.Expect(lexer.TokenSemiColon); err != nil {
+	// a chained comparison, e.g. "0 <= x1 <= 10;"
+	var rangeOp lexer.Token
+	var rangeRight Expr
+	next, err = p.Peek()
+	if err != nil {
+		return nil, err
+	}
+	if isComparison(next.Type) {
+		if rangeOp, err = p.Advance(); err != nil {
+			return nil, err
+		}
+		if op.Type != rangeOp.Type || op.Type == lexer.TokenEqual {
+			return nil, lexer.Errorf(op.Span.Join(rangeOp.Span), "chained comparisons need two '<=' or two '>='")
+		}
+		if rangeRight, err = p.ParseExpr(); err != nil {
+			return nil, err
+		}
+	}
+
+	semiColon, err := p.Expect(lexer.TokenSemiColon)
+	if err != nil {
 		return nil, err
 	}
 
-	return &Constraint{Left: left, Operator: op, Right: right}, nil
+	span := left.Pos().Join(semiColon.Span)
+	constraint := &Constraint{
+		Left:          left,
+		Operator:      op,
+		Right:         right,
+		Line:          span.Start.Line,
+		Span:          span,
+		ForAll:        forAll,
+		RangeOperator: rangeOp,
+		RangeRight:    rangeRight,
+	}
+	if name != nil {
+		constraint.Name = name.Value
+		constraint.Span = name.Span.Join(span)
+		constraint.Line = constraint.Span.Start.Line
+	}
+	if forAll != nil {
+		constraint.Span = token.Span.Join(constraint.Span)
+		constraint.Line = constraint.Span.Start.Line
+	}
+
+	return constraint, nil
 }
 
 func (p *Parser) ParseExpr() (Expr, error) {
@@ -140,7 +603,8 @@ func (p *Parser) ParseExpr() (Expr, error) {
 			return nil, err
 		}
 
-		left = &BinaryExpr{Left: left, Operator: op, Right: right}
+		span := left.Pos().Join(right.Pos())
+		left = &BinaryExpr{Left: left, Operator: op, Right: right, Line: span.Start.Line, Span: span}
 	}
 
 	return left, nil
@@ -171,7 +635,8 @@ func (p *Parser) ParseTerm() (Expr, error) {
 			return nil, err
 		}
 
-		left = &BinaryExpr{Left: left, Operator: op, Right: right}
+		span := left.Pos().Join(right.Pos())
+		left = &BinaryExpr{Left: left, Operator: op, Right: right, Line: span.Start.Line, Span: span}
 	}
 	return left, nil
 }
@@ -194,7 +659,8 @@ func (p *Parser) ParseFactor() (Expr, error) {
 			return nil, err
 		}
 
-		return &UnaryExpr{Operator: op, Expr: expr}, nil
+		span := op.Span.Join(expr.Pos())
+		return &UnaryExpr{Operator: op, Expr: expr, Line: span.Start.Line, Span: span}, nil
 	}
 
 	// remaining cases
@@ -202,17 +668,50 @@ func (p *Parser) ParseFactor() (Expr, error) {
 	if err != nil {
 		return nil, err
 	}
+	next, err := p.Peek()
+	if err != nil {
+		return nil, err
+	}
+	if token.Type != lexer.TokenSum || next.Type != lexer.TokenLBrace {
+		// "sum" is only a sum before its iterators, other contextual keywords are always names here
+		token = asName(token)
+	}
 
 	switch token.Type {
-	case lexer.TokenNumber:
+	case lexer.TokenNumber, lexer.TokenDecimal:
 		const doubleSize = 64
 		value, err := strconv.ParseFloat(token.Value, doubleSize)
 		if err != nil {
-			return nil, fmt.Errorf("invalid number token at line %d", token.Line)
+			return nil, lexer.Errorf(token.Span, "invalid number token %s", token.Value)
 		}
-		return &NumberLiteral{Value: value, Line: token.Line}, nil
+		return &NumberLiteral{Value: value, Line: token.Line, Span: token.Span}, nil
 	case lexer.TokenId:
-		return &Variable{ID: token}, nil
+		if next.Type != lexer.TokenLBracket {
+			return &Variable{ID: token}, nil
+		}
+
+		if _, err = p.Advance(); err != nil {
+			return nil, err
+		}
+		index, err := p.ParseExprList(lexer.TokenRBracket)
+		if err != nil {
+			return nil, err
+		}
+		token.Span = token.Span.Join(p.Tokens[p.Pos-1].Span)
+		return &Variable{ID: token, Index: index}, nil
+	case lexer.TokenSum:
+		over, err := p.ParseIteratorBlock()
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := p.ParseTerm()
+		if err != nil {
+			return nil, err
+		}
+
+		span := token.Span.Join(body.Pos())
+		return &SumExpr{Over: over, Body: body, Span: span}, nil
 	case lexer.TokenLParen:
 		expr, err := p.ParseExpr()
 		if err != nil {
@@ -224,17 +723,40 @@ func (p *Parser) ParseFactor() (Expr, error) {
 		}
 		return expr, nil
 	default:
-		return nil, fmt.Errorf("unexpected token with value %s at line %d", token.Value, token.Line)
+		return nil, lexer.Errorf(token.Span, "unexpected token with value %s", token.Value)
 	}
 }
 
 func (p *Parser) ParseProgram() (*Program, error) {
+	var sets []*SetDecl
+	var params []*ParamDecl
 	var decls []*Decl
 	for {
 		token, err := p.Peek()
 		if err != nil {
 			return nil, err
 		}
+
+		if token.Type == lexer.TokenSet {
+			set, err := p.ParseSetDecl()
+			if err != nil {
+				return nil, err
+			}
+
+			sets = append(sets, set)
+			continue
+		}
+
+		if token.Type == lexer.TokenParam {
+			param, err := p.ParseParamDecl()
+			if err != nil {
+				return nil, err
+			}
+
+			params = append(params, param)
+			continue
+		}
+
 		if token.Type != lexer.TokenLet {
 			break
 		}
@@ -273,12 +795,57 @@ func (p *Parser) ParseProgram() (*Program, error) {
 		constraints = append(constraints, constraint)
 	}
 
-	return &Program{Decls: decls, Objective: objective, Constraints: constraints}, nil
+	return &Program{Sets: sets, Params: params, Decls: decls, Objective: objective, Constraints: constraints}, nil
 }
 
 func PrintParse(p *Program) error {
+	for _, set := range p.Sets {
+		if set.Elements != nil {
+			elements := make([]string, len(set.Elements))
+			for i, e := range set.Elements {
+				elements[i] = fmt.Sprint(e)
+			}
+			fmt.Printf("set %s = {%s};\n", set.ID.Value, strings.Join(elements, ", "))
+		} else {
+			fmt.Printf("set %s = %s..%s;\n", set.ID.Value, set.From, set.To)
+		}
+	}
+
+	for _, param := range p.Params {
+		fmt.Printf("param %s", param.ID.Value)
+		if param.Index != nil {
+			fmt.Printf("[%s]", iteratorList(param.Index))
+		}
+
+		if param.Values != nil {
+			values := make([]string, len(param.Values))
+			for i, e := range param.Values {
+				values[i] = fmt.Sprint(e)
+			}
+			fmt.Printf(" = {%s};\n", strings.Join(values, ", "))
+		} else {
+			fmt.Printf(" = %s;\n", param.Value)
+		}
+	}
+
 	for _, decl := range p.Decls {
-		fmt.Printf("let %s;\n", decl.ID.Value)
+		fmt.Print("let ")
+		switch decl.Type {
+		case VarInteger:
+			fmt.Print("int ")
+		case VarBinary:
+			fmt.Print("bin ")
+		}
+
+		fmt.Print(decl.ID.Value)
+		if decl.Index != nil {
+			fmt.Printf("[%s]", iteratorList(decl.Index))
+		}
+
+		if decl.NonNegative {
+			fmt.Print(" >= 0")
+		}
+		fmt.Println(";")
 	}
 
 	if p.Objective.IsMax {
@@ -290,7 +857,17 @@ func PrintParse(p *Program) error {
 	fmt.Printf("%s;\n", p.Objective.Expr)
 
 	for _, constraint := range p.Constraints {
-		fmt.Printf("%s %s %s;\n", constraint.Left, constraint.Operator.Value, constraint.Right)
+		if constraint.ForAll != nil {
+			fmt.Printf("forall{%s}: ", iteratorList(constraint.ForAll))
+		}
+		if constraint.Name != "" {
+			fmt.Printf("%s: ", constraint.Name)
+		}
+		fmt.Printf("%s %s %s", constraint.Left, constraint.Operator.Value, constraint.Right)
+		if constraint.RangeRight != nil {
+			fmt.Printf(" %s %s", constraint.RangeOperator.Value, constraint.RangeRight)
+		}
+		fmt.Println(";")
 	}
 
 	return nil
@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 
@@ -215,3 +217,384 @@ func TestParseProgram_DefaultTest(t *testing.T) {
 		PrintParse(prog)
 	}
 }
+
+func TestParseFactor_Decimal(t *testing.T) {
+	toks := tokens(
+		lexer.Token{Type: lexer.TokenDecimal, Value: "0.5", Line: 1},
+		lexer.Token{Type: lexer.TokenDecimal, Value: ".25", Line: 1},
+		lexer.Token{Type: lexer.TokenDecimal, Value: "1e-3", Line: 1},
+		lexer.Token{Type: lexer.TokenDecimal, Value: "2.5E+2", Line: 1},
+	)
+
+	parser := &Parser{Tokens: toks}
+	for _, want := range []float64{0.5, 0.25, 0.001, 250} {
+		expr, err := parser.ParseFactor()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		nl, ok := expr.(*NumberLiteral)
+		if !ok {
+			t.Fatalf("expected NumberLiteral, got %T", expr)
+		}
+		if nl.Value != want {
+			t.Errorf("expected %v, got %v", want, nl.Value)
+		}
+	}
+}
+
+func TestParseProgram_DecimalTest(t *testing.T) {
+	tokens, err := lexer.Tokenize(strings.NewReader("let x1; let x2; max 0.5 * x1 + 1e-3 * x2; s.t. .25 * x1 + x2 <= 1.5E1;"))
+	if err != nil {
+		t.Fatalf("Tokenize() error: %v", err)
+	}
+
+	parser := &Parser{Tokens: tokens}
+	prog, err := parser.ParseProgram()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := fmt.Sprint(prog.Objective.Expr)
+	want := "((0.5 * x1) + (0.001 * x2))"
+	if got != want {
+		t.Errorf("objective mismatch:\nGot:  %v\nWant: %v", got, want)
+	}
+
+	got = fmt.Sprint(prog.Constraints[0].Right)
+	want = "15"
+	if got != want {
+		t.Errorf("constraint right side mismatch:\nGot:  %v\nWant: %v", got, want)
+	}
+}
+
+func TestParseProgram_Spans(t *testing.T) {
+	tokens, err := lexer.Tokenize(strings.NewReader("let x1;\nmax -x1;\ns.t.\n  2 * x1 +\n  3 <= 4;"))
+	if err != nil {
+		t.Fatalf("Tokenize() error: %v", err)
+	}
+
+	parser := &Parser{Tokens: tokens}
+	prog, err := parser.ParseProgram()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	objective := prog.Objective.Expr.Pos()
+	if objective.Start.Column != 5 || objective.End.Column != 8 {
+		t.Errorf("objective span %+v; want columns 5 to 8", objective)
+	}
+
+	constraint := prog.Constraints[0]
+	if constraint.Line != 4 {
+		t.Errorf("constraint line %d; want 4", constraint.Line)
+	}
+	if constraint.Span.Start != (lexer.Position{Line: 4, Column: 3, Offset: 24}) || constraint.Span.End.Line != 5 {
+		t.Errorf("constraint span %+v; want line 4, column 3 to line 5", constraint.Span)
+	}
+
+	left, ok := constraint.Left.(*BinaryExpr)
+	if !ok {
+		t.Fatalf("expected BinaryExpr, got %T", constraint.Left)
+	}
+	if left.Line != 4 || left.Span.End != (lexer.Position{Line: 5, Column: 4, Offset: 36}) {
+		t.Errorf("constraint left side line %d, span %+v", left.Line, left.Span)
+	}
+}
+
+func TestParseProgram_ErrorSpan(t *testing.T) {
+	tokens, err := lexer.Tokenize(strings.NewReader("let x1;\nmax x1;\ns.t. x1 <= ;"))
+	if err != nil {
+		t.Fatalf("Tokenize() error: %v", err)
+	}
+
+	parser := &Parser{Tokens: tokens}
+	_, err = parser.ParseProgram()
+
+	var spanErr *lexer.SpanError
+	if !errors.As(err, &spanErr) {
+		t.Fatalf("expected SpanError, got %v", err)
+	}
+	if spanErr.Span.Start.Line != 3 || spanErr.Span.Start.Column != 12 {
+		t.Errorf("error at %s; want line 3, column 12", spanErr.Span.Start)
+	}
+}
+
+func TestParseDecl_NonNegative(t *testing.T) {
+	tokens, err := lexer.Tokenize(strings.NewReader("let x1 >= 0; let x2; let x3 >= 0.0; max x1; s.t. x1 <= 1;"))
+	if err != nil {
+		t.Fatalf("Tokenize() error: %v", err)
+	}
+
+	parser := &Parser{Tokens: tokens}
+	prog, err := parser.ParseProgram()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i, want := range []bool{true, false, true} {
+		if prog.Decls[i].NonNegative != want {
+			t.Errorf("decl %s NonNegative = %v; want %v", prog.Decls[i].ID.Value, prog.Decls[i].NonNegative, want)
+		}
+	}
+
+	tokens, err = lexer.Tokenize(strings.NewReader("let x1 >= 2; max x1; s.t. x1 <= 1;"))
+	if err != nil {
+		t.Fatalf("Tokenize() error: %v", err)
+	}
+
+	parser = &Parser{Tokens: tokens}
+	if _, err = parser.ParseProgram(); err == nil {
+		t.Fatalf("expected error for nonzero declaration bound")
+	}
+}
+
+func TestParseDecl_Type(t *testing.T) {
+	tokens, err := lexer.Tokenize(strings.NewReader("let int x1 >= 0; let bin x2; let x3; max x1; s.t. x1 <= 1;"))
+	if err != nil {
+		t.Fatalf("Tokenize() error: %v", err)
+	}
+
+	parser := &Parser{Tokens: tokens}
+	prog, err := parser.ParseProgram()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i, want := range []VarType{VarInteger, VarBinary, VarContinuous} {
+		if prog.Decls[i].Type != want {
+			t.Errorf("decl %s Type = %v; want %v", prog.Decls[i].ID.Value, prog.Decls[i].Type, want)
+		}
+	}
+	if !prog.Decls[0].NonNegative {
+		t.Errorf("decl x1 should be NonNegative")
+	}
+
+	tokens, err = lexer.Tokenize(strings.NewReader("let int bin x1; max x1; s.t. x1 <= 1;"))
+	if err != nil {
+		t.Fatalf("Tokenize() error: %v", err)
+	}
+
+	parser = &Parser{Tokens: tokens}
+	if _, err = parser.ParseProgram(); err == nil {
+		t.Fatalf("expected error for two variable types")
+	}
+}
+
+func TestParseConstraint_Named(t *testing.T) {
+	tokens, err := lexer.Tokenize(strings.NewReader("let x1; let x2; max x1;\ns.t. capacity: x1 + x2 <= 5;\nx1 >= 0;"))
+	if err != nil {
+		t.Fatalf("Tokenize() error: %v", err)
+	}
+
+	parser := &Parser{Tokens: tokens}
+	prog, err := parser.ParseProgram()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(prog.Constraints) != 2 {
+		t.Fatalf("expected 2 constraints, got %d", len(prog.Constraints))
+	}
+	if prog.Constraints[0].Name != "capacity" || prog.Constraints[1].Name != "" {
+		t.Errorf("constraint names %q and %q; want \"capacity\" and \"\"", prog.Constraints[0].Name, prog.Constraints[1].Name)
+	}
+	if prog.Constraints[0].Span.Start.Column != 6 {
+		t.Errorf("named constraint starts at column %d; want 6", prog.Constraints[0].Span.Start.Column)
+	}
+
+	if testing.Verbose() {
+		PrintParse(prog)
+	}
+}
+
+func TestParseProgram_Indexing(t *testing.T) {
+	input := "set S = {a, b}; set T = 1..4; let x[i in 1..3, S] >= 0; max sum{i in 1..3, j in S} 2 * x[i, j] + 1; s.t. forall{i in T}: cap: x[i, a] <= 10;"
+	tokens, err := lexer.Tokenize(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Tokenize() error: %v", err)
+	}
+
+	parser := &Parser{Tokens: tokens}
+	prog, err := parser.ParseProgram()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(prog.Sets) != 2 || len(prog.Sets[0].Elements) != 2 || prog.Sets[1].Elements != nil {
+		t.Fatalf("sets mismatch: %+v", prog.Sets)
+	}
+
+	decl := prog.Decls[0]
+	if got := iteratorList(decl.Index); got != "i in 1..3, S" || !decl.NonNegative {
+		t.Errorf("declaration index mismatch: got %v", got)
+	}
+
+	got := fmt.Sprint(prog.Objective.Expr)
+	want := "((sum{i in 1..3, j in S} (2 * x[i,j])) + 1)"
+	if got != want {
+		t.Errorf("objective mismatch:\nGot:  %v\nWant: %v", got, want)
+	}
+
+	constraint := prog.Constraints[0]
+	if got := iteratorList(constraint.ForAll); got != "i in T" || constraint.Name != "cap" {
+		t.Errorf("constraint mismatch: forall %v, name %v", got, constraint.Name)
+	}
+	if constraint.Span.Start.Column != 106 {
+		t.Errorf("constraint span %+v; want it to start at forall (column 106)", constraint.Span)
+	}
+}
+
+func TestParseProgram_ContextualKeywords(t *testing.T) {
+	// set, param, sum, forall and in are only keywords where the grammar expects them
+	input := "set in = {a}; let set; let param; let sum[in]; let forall; let in >= 0; max sum + in + sum{i in in} sum[i]; " +
+		"s.t. forall: param + set <= 1; forall{forall in 1..2}: in <= forall;"
+	tokens, err := lexer.Tokenize(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Tokenize() error: %v", err)
+	}
+
+	parser := &Parser{Tokens: tokens}
+	prog, err := parser.ParseProgram()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	for _, decl := range prog.Decls {
+		names = append(names, decl.ID.Value)
+		if decl.ID.Type != lexer.TokenId {
+			t.Errorf("declaration %s has token type %s; want %s", decl.ID.Value, decl.ID.Type, lexer.TokenId)
+		}
+	}
+	if got, want := strings.Join(names, " "), "set param sum forall in"; got != want {
+		t.Errorf("declarations mismatch:\nGot:  %v\nWant: %v", got, want)
+	}
+	if got := iteratorList(prog.Decls[2].Index); got != "in" {
+		t.Errorf("declaration index mismatch: got %v", got)
+	}
+
+	got := fmt.Sprint(prog.Objective.Expr)
+	want := "((sum + in) + (sum{i in in} sum[i]))"
+	if got != want {
+		t.Errorf("objective mismatch:\nGot:  %v\nWant: %v", got, want)
+	}
+
+	if constraint := prog.Constraints[0]; constraint.Name != "forall" || constraint.ForAll != nil || fmt.Sprint(constraint.Left) != "(param + set)" {
+		t.Errorf("constraint 0 mismatch: name %v, left %v", constraint.Name, constraint.Left)
+	}
+	if constraint := prog.Constraints[1]; iteratorList(constraint.ForAll) != "forall in 1..2" || fmt.Sprint(constraint.Right) != "forall" {
+		t.Errorf("constraint 1 mismatch: forall %v, right %v", iteratorList(constraint.ForAll), constraint.Right)
+	}
+}
+
+func TestParseProgram_ContextualTypes(t *testing.T) {
+	// int and bin are only types after "let" when a name follows them
+	input := "let bin; let int int >= 0; let bin x[1..2]; let int[1..2]; max bin + int + x[1] + int[2]; s.t. bin <= 2; int: int + bin >= 1;"
+	tokens, err := lexer.Tokenize(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Tokenize() error: %v", err)
+	}
+
+	parser := &Parser{Tokens: tokens}
+	prog, err := parser.ParseProgram()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var decls []string
+	for _, decl := range prog.Decls {
+		decls = append(decls, fmt.Sprintf("%s %s", decl.Type, decl.ID.Value))
+		if decl.ID.Type != lexer.TokenId {
+			t.Errorf("declaration %s has token type %s; want %s", decl.ID.Value, decl.ID.Type, lexer.TokenId)
+		}
+	}
+	want := fmt.Sprintf("%s bin; %s int; %s x; %s int", VarContinuous, VarInteger, VarBinary, VarContinuous)
+	if got := strings.Join(decls, "; "); got != want {
+		t.Errorf("declarations mismatch:\nGot:  %v\nWant: %v", got, want)
+	}
+
+	if got, want := fmt.Sprint(prog.Objective.Expr), "(((bin + int) + x[1]) + int[2])"; got != want {
+		t.Errorf("objective mismatch:\nGot:  %v\nWant: %v", got, want)
+	}
+	if constraint := prog.Constraints[1]; constraint.Name != "int" || fmt.Sprint(constraint.Left) != "(int + bin)" {
+		t.Errorf("constraint 1 mismatch: name %v, left %v", constraint.Name, constraint.Left)
+	}
+}
+
+func TestParseProgram_Params(t *testing.T) {
+	input := "param cost = 12.5; param demand[1..3] = {4, 5, 6}; let x; min cost * x; s.t. x >= demand[2];"
+	tokens, err := lexer.Tokenize(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Tokenize() error: %v", err)
+	}
+
+	parser := &Parser{Tokens: tokens}
+	prog, err := parser.ParseProgram()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(prog.Params) != 2 {
+		t.Fatalf("Expected 2 params, got %d", len(prog.Params))
+	}
+	if param := prog.Params[0]; param.ID.Value != "cost" || param.Index != nil || fmt.Sprint(param.Value) != "12.5" {
+		t.Errorf("param 0 mismatch: %+v", param)
+	}
+	if param := prog.Params[1]; param.ID.Value != "demand" || iteratorList(param.Index) != "1..3" || len(param.Values) != 3 {
+		t.Errorf("param 1 mismatch: %+v", param)
+	}
+
+	tokens, err = lexer.Tokenize(strings.NewReader("param c = {1, 2}; let x; min x; s.t. x >= 0;"))
+	if err != nil {
+		t.Fatalf("Tokenize() error: %v", err)
+	}
+	if _, err := (&Parser{Tokens: tokens}).ParseProgram(); err == nil {
+		t.Errorf("expected an error for a value list without an index")
+	}
+}
+
+func TestParseConstraint_Chained(t *testing.T) {
+	tokens, err := lexer.Tokenize(strings.NewReader("let x1; let x2; max x1;\ns.t. box: 0 <= x1 <= 10;\n5 >= x1 + x2 >= -5;"))
+	if err != nil {
+		t.Fatalf("Tokenize() error: %v", err)
+	}
+
+	parser := &Parser{Tokens: tokens}
+	prog, err := parser.ParseProgram()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(prog.Constraints) != 2 {
+		t.Fatalf("expected 2 constraints, got %d", len(prog.Constraints))
+	}
+	for i, want := range []string{"0 <= x1 <= 10", "5 >= (x1 + x2) >= (-5)"} {
+		c := prog.Constraints[i]
+		if c.RangeRight == nil {
+			t.Fatalf("constraint %d should be chained", i)
+		}
+		if got := fmt.Sprintf("%v %s %v %s %v", c.Left, c.Operator.Value, c.Right, c.RangeOperator.Value, c.RangeRight); got != want {
+			t.Errorf("constraint %d: got %q, want %q", i, got, want)
+		}
+	}
+	if prog.Constraints[0].Name != "box" {
+		t.Errorf("constraint name %q; want \"box\"", prog.Constraints[0].Name)
+	}
+
+	for _, input := range []string{"let x; max x; s.t. 0 <= x >= 10;", "let x; max x; s.t. 0 = x = 10;", "let x; max x; s.t. 0 <= x <= 10 <= 20;"} {
+		tokens, err := lexer.Tokenize(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("Tokenize() error: %v", err)
+		}
+
+		parser := &Parser{Tokens: tokens}
+		if _, err := parser.ParseProgram(); err == nil {
+			t.Errorf("expected an error for %q", input)
+		}
+	}
+
+	if testing.Verbose() {
+		PrintParse(prog)
+	}
+}
@@ -2,40 +2,108 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
 )
 
 type Program struct {
+	Sets        []*SetDecl
+	Params      []*ParamDecl
 	Decls       []*Decl
 	Objective   *Objective
 	Constraints []*Constraint
 }
 
+// SetDecl is "set S = {a, b, c};" or "set S = 1..n;"
+type SetDecl struct {
+	ID lexer.Token
+	// Elements are the listed elements, nil if the set is a range
+	Elements []Expr
+	From     Expr
+	To       Expr
+	Span     lexer.Span
+}
+
+// ParamDecl is "param cost = 12.5;" or "param demand[1..3] = {4, 5, 6};"
+type ParamDecl struct {
+	ID lexer.Token
+	// Index is set for indexed parameters, one parameter is declared for each element (nil for a single parameter)
+	Index []*Iterator
+	// Value is the value of a single parameter, or of every element of an indexed one (nil if Values is used)
+	Value Expr
+	// Values are the listed values of an indexed parameter, one for each element
+	Values []Expr
+	Span   lexer.Span
+}
+
+// Iterator is "i in S" or "i in 1..n", in declarations it can also be just "S" or "1..n"
+type Iterator struct {
+	// Var is nil if the iterator is unnamed
+	Var *lexer.Token
+	// Set is the name of the set iterated over, nil if iterating over the range From..To
+	Set  *lexer.Token
+	From Expr
+	To   Expr
+	Span lexer.Span
+}
+
+// VarType is the kind of values a declared variable may take
+type VarType string
+
+const (
+	VarContinuous VarType = "continuous"
+	// VarInteger is set by "let int x;"
+	VarInteger VarType = "integer"
+	// VarBinary is set by "let bin x;" (an integer that is 0 or 1)
+	VarBinary VarType = "binary"
+)
+
 type Decl struct {
 	ID lexer.Token
+	// NonNegative is set by "let x >= 0;"
+	NonNegative bool
+	Type        VarType
+	// Index is set by "let x[1..n];", one variable is declared for each element (nil for a single variable)
+	Index []*Iterator
 }
 
 type Objective struct {
 	IsMax bool
 	Expr  Expr
+	Span  lexer.Span
 }
 
 type Constraint struct {
+	// Name is set by "name: ..." (empty if the constraint is unnamed)
+	Name     string
 	Left     Expr
 	Operator lexer.Token
 	Right    Expr
 	Line     int
+	Span     lexer.Span
+	// ForAll is set by "forall{i in S}: ...", one constraint is made for each element (nil for a single constraint)
+	ForAll []*Iterator
+	// RangeOperator and RangeRight are the second comparison of a chained constraint "Left <= Right <= RangeRight"
+	// (RangeRight is nil for a single comparison). Both operators are "<=" or both are ">=".
+	RangeOperator lexer.Token
+	RangeRight    Expr
+	// IsRange is set on the second of the two constraints a chained constraint expands into,
+	// which is reported under the index of the first (see linear.Constraint.IsRange)
+	IsRange bool
 }
 
 type Expr interface {
 	exprNode()
+	// Pos is the span of source the Expr was parsed from
+	Pos() lexer.Span
 }
 
 type UnaryExpr struct {
 	Operator lexer.Token
 	Expr     Expr
 	Line     int
+	Span     lexer.Span
 }
 
 type BinaryExpr struct {
@@ -43,15 +111,27 @@ type BinaryExpr struct {
 	Operator lexer.Token
 	Right    Expr
 	Line     int
+	Span     lexer.Span
 }
 
 type NumberLiteral struct {
 	Value float64
 	Line  int
+	Span  lexer.Span
 }
 
 type Variable struct {
+	// ID's span covers the index too (e.g. all of "x[i]")
 	ID lexer.Token
+	// Index is set by "x[i]" (nil otherwise)
+	Index []Expr
+}
+
+// SumExpr is "sum{i in S} body", the sum of body over each element of S
+type SumExpr struct {
+	Over []*Iterator
+	Body Expr
+	Span lexer.Span
 }
 
 func (n *NumberLiteral) String() string {
@@ -59,7 +139,42 @@ func (n *NumberLiteral) String() string {
 }
 
 func (v *Variable) String() string {
-	return v.ID.Value
+	if v.Index == nil {
+		return v.ID.Value
+	}
+
+	index := make([]string, len(v.Index))
+	for i, e := range v.Index {
+		index[i] = fmt.Sprint(e)
+	}
+	return fmt.Sprintf("%s[%s]", v.ID.Value, strings.Join(index, ","))
+}
+
+func (it *Iterator) String() string {
+	var domain string
+	if it.Set != nil {
+		domain = it.Set.Value
+	} else {
+		domain = fmt.Sprintf("%s..%s", it.From, it.To)
+	}
+
+	if it.Var == nil {
+		return domain
+	}
+	return fmt.Sprintf("%s in %s", it.Var.Value, domain)
+}
+
+// Iterators as a comma separated list
+func iteratorList(iterators []*Iterator) string {
+	list := make([]string, len(iterators))
+	for i, it := range iterators {
+		list[i] = it.String()
+	}
+	return strings.Join(list, ", ")
+}
+
+func (s *SumExpr) String() string {
+	return fmt.Sprintf("(sum{%s} %s)", iteratorList(s.Over), s.Body)
 }
 
 func (u *UnaryExpr) String() string {
@@ -1,112 +1,109 @@
 package semantics
 
 import (
-	"fmt"
-
 	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
 	"github.com/animalat/Simplex-Algorithm/lp_parser/parser"
 )
 
-const enableObjective = true
-const disableObjective = false
-
-func checkTerm(isObjectiveAndFirst bool, e parser.Expr, idTable map[string]int) error {
+// degree returns the degree of e as a polynomial in the declared variables (0 for constants, 1 for linear
+// expressions), failing on undeclared variables and on anything that is not a polynomial of degree at most 1
+func degree(e parser.Expr, idTable map[string]int) (int, error) {
 	switch expr := e.(type) {
+	case *parser.NumberLiteral:
+		return 0, nil
 	case *parser.Variable:
 		if _, ok := idTable[expr.ID.Value]; !ok {
-			return fmt.Errorf("undeclared Variable: %s", expr)
+			return 0, lexer.Errorf(expr.ID.Span, "undeclared Variable: %s", expr)
 		}
 
-		return nil
-	case *parser.NumberLiteral:
-		if isObjectiveAndFirst {
-			return nil
-		} else {
-			return fmt.Errorf("expected no NumberLiteral, received NumberLiteral %s", expr)
-		}
+		return 1, nil
 	case *parser.UnaryExpr:
-		return fmt.Errorf("invalid UnaryExpr (should not have UnaryExpr at this stage): %s", expr)
-	case *parser.BinaryExpr:
-		left := expr.Left
-		right := expr.Right
-
-		if _, ok := left.(*parser.NumberLiteral); !ok {
-			return fmt.Errorf("expected NumberLiteral, received: %s", left)
+		switch expr.Operator.Type {
+		case lexer.TokenPlus, lexer.TokenMinus:
+			return degree(expr.Expr, idTable)
+		default:
+			return 0, lexer.Errorf(expr.Operator.Span, "invalid unary operator: %s", expr)
 		}
-
-		v, ok := right.(*parser.Variable)
-		if !ok {
-			return fmt.Errorf("expected Variable, received: %s", right)
+	case *parser.BinaryExpr:
+		left, err := degree(expr.Left, idTable)
+		if err != nil {
+			return 0, err
 		}
-
-		if _, ok = idTable[v.ID.Value]; !ok {
-			return fmt.Errorf("undeclared Variable: %s", v)
+		right, err := degree(expr.Right, idTable)
+		if err != nil {
+			return 0, err
 		}
 
-		return nil
-	default:
-		return fmt.Errorf("unknown Expr type: %T", e)
-	}
-}
-
-func checkExpr(isObjectiveAndFirst bool, e parser.Expr, idTable map[string]int) error {
-	switch expr := e.(type) {
-	case *parser.Variable, *parser.NumberLiteral, *parser.UnaryExpr:
-		return checkTerm(isObjectiveAndFirst, e, idTable)
-	case *parser.BinaryExpr:
-		left := expr.Left
-		right := expr.Right
-		op := expr.Operator
+		switch expr.Operator.Type {
+		case lexer.TokenPlus, lexer.TokenMinus:
+			return max(left, right), nil
+		case lexer.TokenAsterisk:
+			if left+right > 1 {
+				return 0, lexer.Errorf(parser.SpanOf(expr), "nonlinear product of variables: %s", expr)
+			}
 
-		switch op.Type {
-		case lexer.TokenPlus:
-			// check right, recurse on left
-			if err := checkTerm(isObjectiveAndFirst, right, idTable); err != nil {
-				return err
+			return left + right, nil
+		case lexer.TokenDivide:
+			if right > 0 {
+				return 0, lexer.Errorf(parser.SpanOf(expr.Right), "division by an expression with variables: %s", expr)
 			}
 
-			return checkExpr(disableObjective, left, idTable)
-		case lexer.TokenAsterisk:
-			return checkTerm(isObjectiveAndFirst, expr, idTable)
+			return left, nil
 		default:
-			return fmt.Errorf("invalid Expr operator: %s", expr)
+			return 0, lexer.Errorf(expr.Operator.Span, "invalid Expr operator: %s", expr)
 		}
 	default:
-		return fmt.Errorf("unknown Expr type: %T", e)
+		return 0, lexer.Errorf(parser.SpanOf(e), "unknown Expr type: %T", e)
 	}
 }
 
-func checkNumber(e parser.Expr) error {
-	if _, ok := e.(*parser.NumberLiteral); !ok {
-		return fmt.Errorf("constant not found at RHS: %v", e)
-	}
-
-	return nil
+// checkLinear checks that e is linear in the declared variables
+func checkLinear(e parser.Expr, idTable map[string]int) error {
+	_, err := degree(e, idTable)
+	return err
 }
 
-func SemanticCheck(p *parser.Program) (map[string]int, error) {
+// SemanticCheck checks that a program is linear in its declared variables, returning the index of each variable and its type
+func SemanticCheck(p *parser.Program) (map[string]int, map[string]parser.VarType, error) {
 	idTable := make(map[string]int)
+	varTypes := make(map[string]parser.VarType)
 	for i, decl := range p.Decls {
 		if _, ok := idTable[decl.ID.Value]; ok {
-			return nil, fmt.Errorf("duplicate variable: %v", decl.ID.Value)
+			return nil, nil, lexer.Errorf(decl.ID.Span, "duplicate variable: %v", decl.ID.Value)
 		}
 
 		idTable[decl.ID.Value] = i
+		switch decl.Type {
+		case parser.VarContinuous, parser.VarInteger, parser.VarBinary:
+			varTypes[decl.ID.Value] = decl.Type
+		case "":
+			varTypes[decl.ID.Value] = parser.VarContinuous
+		default:
+			return nil, nil, lexer.Errorf(decl.ID.Span, "unknown variable type %q: %v", decl.Type, decl.ID.Value)
+		}
 	}
 
-	if err := checkExpr(enableObjective, p.Objective.Expr, idTable); err != nil {
-		return nil, err
+	if err := checkLinear(p.Objective.Expr, idTable); err != nil {
+		return nil, nil, err
 	}
 
+	constraintNames := make(map[string]struct{})
 	for _, constraint := range p.Constraints {
-		if err := checkExpr(disableObjective, constraint.Left, idTable); err != nil {
-			return nil, err
+		if constraint.Name != "" {
+			if _, ok := constraintNames[constraint.Name]; ok {
+				return nil, nil, lexer.Errorf(constraint.Span, "duplicate constraint name: %v", constraint.Name)
+			}
+			constraintNames[constraint.Name] = struct{}{}
+		}
+
+		if err := checkLinear(constraint.Left, idTable); err != nil {
+			return nil, nil, err
 		}
 
-		if err := checkNumber(constraint.Right); err != nil {
-			return nil, err
+		if err := checkLinear(constraint.Right, idTable); err != nil {
+			return nil, nil, err
 		}
 	}
 
-	return idTable, nil
+	return idTable, varTypes, nil
 }
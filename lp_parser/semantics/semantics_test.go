@@ -1,6 +1,7 @@
 package semantics
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
@@ -8,67 +9,50 @@ import (
 	"github.com/animalat/Simplex-Algorithm/lp_parser/parser"
 )
 
-func TestSemantics_Term(t *testing.T) {
-	numberTest := &parser.NumberLiteral{Value: 5, Line: 0}
-	m := make(map[string]int)
-	err := checkTerm(enableObjective, numberTest, m)
-	if err != nil {
-		t.Errorf("valid parser.NumberLiteral failed: %v", numberTest)
-	}
-
-	err = checkTerm(disableObjective, numberTest, m)
-	if err == nil {
-		t.Errorf("invalid parser.NumberLiteral passed: %v", numberTest)
-	}
-
-	variableTest := &parser.Variable{ID: lexer.Token{Type: lexer.TokenId, Value: "x1", Line: 0}}
-	err = checkTerm(enableObjective, variableTest, m)
-	if err == nil {
-		t.Errorf("invalid parser.Variable passed: %v", variableTest)
-	}
-
-	m[variableTest.ID.Value] = 0
-	err = checkTerm(disableObjective, variableTest, m)
-	if err != nil {
-		t.Errorf("valid parser.Variable failed: %v", variableTest)
-	}
-
-	unaryExprTest := &parser.UnaryExpr{Operator: lexer.Token{Type: lexer.TokenMinus, Value: "-", Line: 0}, Expr: variableTest, Line: 0}
-	err = checkTerm(disableObjective, unaryExprTest, m)
-	if err == nil {
-		t.Errorf("invalid parser.UnaryExpr passed: %v", unaryExprTest)
-	}
-
-	variableTest2 := &parser.Variable{ID: lexer.Token{Type: lexer.TokenId, Value: "x2", Line: 0}}
-	unaryExprTest2 := &parser.UnaryExpr{Operator: lexer.Token{Type: lexer.TokenMinus, Value: "-", Line: 0}, Expr: variableTest2, Line: 0}
-	err = checkTerm(disableObjective, unaryExprTest2, m)
-	if err == nil {
-		t.Errorf("invalid parser.UnaryExpr passed: %v", unaryExprTest2)
-	}
-
-	binaryTest := &parser.BinaryExpr{Left: numberTest, Operator: lexer.Token{Type: lexer.TokenAsterisk, Value: "*", Line: 0}, Right: variableTest, Line: 0}
-	err = checkTerm(disableObjective, binaryTest, m)
-	if err != nil {
-		t.Errorf("valid parser.BinaryExpr failed: %v", binaryTest)
-	}
-
-	binaryTest2 := &parser.BinaryExpr{Left: numberTest, Operator: lexer.Token{Type: lexer.TokenAsterisk, Value: "*", Line: 0}, Right: variableTest2, Line: 0}
-	err = checkTerm(disableObjective, binaryTest2, m)
-	if err == nil {
-		t.Errorf("invalid parser.BinaryExpr passed: %v", binaryTest2)
-	}
-
-	binaryTest3 := &parser.BinaryExpr{Left: variableTest, Operator: lexer.Token{Type: lexer.TokenAsterisk, Value: "*", Line: 0}, Right: variableTest2, Line: 0}
-	err = checkTerm(disableObjective, binaryTest3, m)
-	if err == nil {
-		t.Errorf("invalid parser.BinaryExpr passed: %v", binaryTest3)
-	}
-
-	m[variableTest2.ID.Value] = 1
-	binaryTest4 := &parser.BinaryExpr{Left: variableTest, Operator: lexer.Token{Type: lexer.TokenAsterisk, Value: "*", Line: 0}, Right: variableTest2, Line: 0}
-	err = checkTerm(disableObjective, binaryTest4, m)
-	if err == nil {
-		t.Errorf("invalid parser.BinaryExpr passed: %v", binaryTest4)
+func TestSemantics_Degree(t *testing.T) {
+	x1 := &parser.Variable{ID: lexer.Token{Type: lexer.TokenId, Value: "x1", Line: 0}}
+	x2 := &parser.Variable{ID: lexer.Token{Type: lexer.TokenId, Value: "x2", Line: 0}}
+	five := &parser.NumberLiteral{Value: 5, Line: 0}
+	binary := func(left parser.Expr, op lexer.TokenType, value string, right parser.Expr) parser.Expr {
+		return &parser.BinaryExpr{Left: left, Operator: lexer.Token{Type: op, Value: value, Line: 0}, Right: right, Line: 0}
+	}
+	negate := func(e parser.Expr) parser.Expr {
+		return &parser.UnaryExpr{Operator: lexer.Token{Type: lexer.TokenMinus, Value: "-", Line: 0}, Expr: e, Line: 0}
+	}
+	m := map[string]int{"x1": 0, "x2": 1}
+
+	tests := []struct {
+		expr parser.Expr
+		// -1 if the expression should be rejected
+		degree int
+	}{
+		{five, 0},
+		{x1, 1},
+		{negate(x1), 1},
+		{binary(five, lexer.TokenAsterisk, "*", x1), 1},
+		{binary(x1, lexer.TokenAsterisk, "*", five), 1},
+		{binary(binary(five, lexer.TokenPlus, "+", x1), lexer.TokenMinus, "-", binary(x2, lexer.TokenDivide, "/", five)), 1},
+		{binary(negate(binary(x1, lexer.TokenPlus, "+", x2)), lexer.TokenAsterisk, "*", binary(five, lexer.TokenMinus, "-", five)), 1},
+		{binary(five, lexer.TokenDivide, "/", five), 0},
+		{binary(x1, lexer.TokenAsterisk, "*", x2), -1},
+		{binary(binary(x1, lexer.TokenPlus, "+", five), lexer.TokenAsterisk, "*", negate(x2)), -1},
+		{binary(five, lexer.TokenDivide, "/", x1), -1},
+		{binary(x1, lexer.TokenPlus, "+", &parser.Variable{ID: lexer.Token{Type: lexer.TokenId, Value: "x3", Line: 0}}), -1},
+	}
+
+	for _, tc := range tests {
+		got, err := degree(tc.expr, m)
+		if tc.degree < 0 {
+			if err == nil {
+				t.Errorf("invalid expression %v passed with degree %d", tc.expr, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("valid expression %v failed: %v", tc.expr, err)
+		} else if got != tc.degree {
+			t.Errorf("degree of %v = %d; want %d", tc.expr, got, tc.degree)
+		}
 	}
 }
 
@@ -86,7 +70,7 @@ func assertProg(t *testing.T, s string) error {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	_, err = SemanticCheck(prog)
+	_, _, err = SemanticCheck(prog)
 	if err != nil {
 		return err
 	}
@@ -109,13 +93,71 @@ func TestSemantics_Expr(t *testing.T) {
 		t.Errorf("valid program failed (%v)", err)
 	}
 
-	err = assertProg(t, "let x1; let x2; let x3; max x1 + x2 + 3; s.t. 5 * x1 + 3 * x2 * 5 - 5 <= 3; x1 + x2 + 3 * x3 + 1 >= 5;")
+	// linear whatever the shape of the expression
+	err = assertProg(t, "let x1; let x2; let x3; max 3 + -x1 + x2 / 2; s.t. 5 * x1 + 3 * x2 * 5 - 5 <= x3; (x1 + x2) * 3 + 1 >= 5 - x3;")
+	if err != nil {
+		t.Errorf("valid program failed (%v)", err)
+	}
+
+	err = assertProg(t, "let x1; let x2; let x3; max x1 + x2 + 3; s.t. 5 * x1 + 3 * x2 * x3 - 5 <= 3; x1 + x2 + 3 * x3 + 1 >= 5;")
 	if err == nil {
-		t.Errorf("invalid program passed")
+		t.Errorf("invalid program passed (product of variables)")
 	}
 
-	err = assertProg(t, "let x1; let x2; max x1 + 5 + x2; s.t. x1 + x2 = 5;")
+	err = assertProg(t, "let x1; let x2; max x1 + 5 + x2; s.t. x1 + x2 = 5 / x1;")
 	if err == nil {
-		t.Errorf("invalid program passed")
+		t.Errorf("invalid program passed (division by a variable)")
+	}
+
+	err = assertProg(t, "let x1; let x2; max (x1 + 1) * (x2 - 1); s.t. x1 + x2 = 5;")
+	if err == nil {
+		t.Errorf("invalid program passed (nonlinear objective)")
+	}
+
+	err = assertProg(t, "let x1; let x2; max x1 + x2 + 5; s.t. c1: x1 + x2 <= 5; c2: x1 <= 3; x2 <= 3;")
+	if err != nil {
+		t.Errorf("valid program failed (%v)", err)
+	}
+
+	err = assertProg(t, "let x1; let x2; max x1 + x2 + 5; s.t. c1: x1 + x2 <= 5; c1: x1 <= 3;")
+	if err == nil {
+		t.Errorf("invalid program passed (duplicate constraint name)")
+	}
+}
+
+func TestSemantics_ErrorSpan(t *testing.T) {
+	err := assertProg(t, "let x1;\nmax x1;\ns.t. x1 + y2 <= 3;")
+
+	var spanErr *lexer.SpanError
+	if !errors.As(err, &spanErr) {
+		t.Fatalf("expected SpanError, got %v", err)
+	}
+	if spanErr.Span.Start.Line != 3 || spanErr.Span.Start.Column != 11 || spanErr.Span.End.Column != 13 {
+		t.Errorf("error at %+v; want line 3, columns 11 to 13", spanErr.Span)
+	}
+}
+
+func TestSemantics_VarTypes(t *testing.T) {
+	tokens, err := lexer.Tokenize(strings.NewReader("let int x1; let bin x2; let x3; max x1 + x2 + x3; s.t. x1 + x2 + x3 <= 3;"))
+	if err != nil {
+		t.Fatalf("Tokenize() error: %v", err)
+	}
+
+	p := &parser.Parser{Tokens: tokens}
+	prog, err := p.ParseProgram()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, varTypes, err := SemanticCheck(prog)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]parser.VarType{"x1": parser.VarInteger, "x2": parser.VarBinary, "x3": parser.VarContinuous}
+	for name, varType := range want {
+		if varTypes[name] != varType {
+			t.Errorf("type of %s = %v; want %v", name, varTypes[name], varType)
+		}
 	}
 }
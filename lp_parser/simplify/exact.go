@@ -0,0 +1,172 @@
+package simplify
+
+import (
+	"math/big"
+
+	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/linear"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/parser"
+)
+
+// ExactParams maps each parameter to its value without rounding
+type ExactParams map[string]*big.Rat
+
+// ExactParamValues is ParamValues without rounding, p must already pass ParamValues
+func ExactParamValues(p *parser.Program) (ExactParams, error) {
+	params := make(ExactParams, len(p.Params))
+	for _, param := range p.Params {
+		value, err := ExactLinearize(param.Value, params)
+		if err != nil {
+			return nil, err
+		}
+		if len(value.Coefficients) != 0 {
+			return nil, lexer.Errorf(param.Span, "parameter %v must be constant: %v", param.ID.Value, param.Value)
+		}
+		params[param.ID.Value] = value.Constant
+	}
+
+	return params, nil
+}
+
+// ExactLinearize folds an expanded (see expand.ExpandProgram) expression into linear form using rationals,
+// so no rounding builds up while folding (e.g. 0.1 + 0.2 is exactly 3/10).
+// Number literals are read with linear.RatFromFloat.
+func ExactLinearize(expr parser.Expr, params ExactParams) (*linear.ExactExpr, error) {
+	switch e := expr.(type) {
+	case *parser.BinaryExpr:
+		left, err := ExactLinearize(e.Left, params)
+		if err != nil {
+			return nil, err
+		}
+		right, err := ExactLinearize(e.Right, params)
+		if err != nil {
+			return nil, err
+		}
+
+		switch e.Operator.Type {
+		case lexer.TokenPlus:
+			return addExact(left, right, 1), nil
+		case lexer.TokenMinus:
+			return addExact(left, right, -1), nil
+		case lexer.TokenAsterisk:
+			if len(left.Coefficients) == 0 {
+				return scaleExact(right, left.Constant), nil
+			}
+			if len(right.Coefficients) == 0 {
+				return scaleExact(left, right.Constant), nil
+			}
+			return nil, lexer.Errorf(e.Span, "nonlinear expression (both sides): %v", e)
+		case lexer.TokenDivide:
+			if len(right.Coefficients) != 0 {
+				return nil, lexer.Errorf(e.Span, "nonlinear expression (RHS rational): %v", e)
+			}
+			if right.Constant.Sign() == 0 {
+				return nil, lexer.Errorf(e.Span, "division by zero: %v", e)
+			}
+			return scaleExact(left, new(big.Rat).Inv(right.Constant)), nil
+		default:
+			return nil, lexer.Errorf(e.Operator.Span, "invalid operator \"%v\": %v", e.Operator.Value, e)
+		}
+	case *parser.UnaryExpr:
+		inner, err := ExactLinearize(e.Expr, params)
+		if err != nil {
+			return nil, err
+		}
+		switch e.Operator.Type {
+		case lexer.TokenMinus:
+			return scaleExact(inner, big.NewRat(-1, 1)), nil
+		case lexer.TokenPlus:
+			return inner, nil
+		default:
+			return nil, lexer.Errorf(e.Span, "invalid UnaryExpr operator %v: %v", e.Operator.Value, e)
+		}
+	case *parser.Variable:
+		expr := linear.NewExactExpr()
+		if value, ok := params[e.ID.Value]; ok {
+			expr.Constant.Set(value)
+		} else {
+			expr.Coefficients[e.ID.Value] = big.NewRat(1, 1)
+		}
+		return expr, nil
+	case *parser.NumberLiteral:
+		expr := linear.NewExactExpr()
+		expr.Constant = linear.RatFromFloat(e.Value)
+		return expr, nil
+	default:
+		return nil, lexer.Errorf(parser.SpanOf(e), "invalid Expr type found: %T", e)
+	}
+}
+
+// a + sign * b, dropping variables that cancel out
+func addExact(a *linear.ExactExpr, b *linear.ExactExpr, sign int64) *linear.ExactExpr {
+	sum := a.Clone()
+	scaled := scaleExact(b, big.NewRat(sign, 1))
+	for variable, coefficient := range scaled.Coefficients {
+		if existing, ok := sum.Coefficients[variable]; ok {
+			existing.Add(existing, coefficient)
+			if existing.Sign() == 0 {
+				delete(sum.Coefficients, variable)
+			}
+			continue
+		}
+		sum.Coefficients[variable] = coefficient
+	}
+	sum.Constant.Add(sum.Constant, scaled.Constant)
+
+	return sum
+}
+
+// factor * e, as a new expression
+func scaleExact(e *linear.ExactExpr, factor *big.Rat) *linear.ExactExpr {
+	scaled := linear.NewExactExpr()
+	if factor.Sign() == 0 {
+		return scaled
+	}
+	for variable, coefficient := range e.Coefficients {
+		scaled.Coefficients[variable] = new(big.Rat).Mul(coefficient, factor)
+	}
+	scaled.Constant.Mul(e.Constant, factor)
+
+	return scaled
+}
+
+// ExactLinearizeProgram gives the exact objective and constraints (as Left - Right, like LinearizeProgram moves them) of
+// an expanded program, in the same order as LinearizeProgram. It must run before SimplifyProgram, which rounds in place.
+func ExactLinearizeProgram(p *parser.Program) (*linear.ExactExpr, []*linear.ExactExpr, error) {
+	params, err := ExactParamValues(p)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	objective, err := ExactLinearize(p.Objective.Expr, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	constraints := make([]*linear.ExactExpr, 0, len(p.Constraints))
+	for _, constraint := range p.Constraints {
+		left, err := ExactLinearize(constraint.Left, params)
+		if err != nil {
+			return nil, nil, err
+		}
+		right, err := ExactLinearize(constraint.Right, params)
+		if err != nil {
+			return nil, nil, err
+		}
+		constraints = append(constraints, addExact(left, right, -1))
+	}
+
+	return objective, constraints, nil
+}
+
+// AttachExact sets the exact values of lp (see linear.LinearExpr.Exact) from ExactLinearizeProgram's results
+func AttachExact(lp *linear.LinearProgram, objective *linear.ExactExpr, constraints []*linear.ExactExpr) {
+	lp.Objective.Expr.Exact = objective
+	for i := range lp.Constraints {
+		left := constraints[i]
+		// constants are moved to the right
+		lp.Constraints[i].ExactRight = new(big.Rat).Neg(left.Constant)
+		left.Constant = new(big.Rat)
+		lp.Constraints[i].Left.Exact = left
+	}
+}
@@ -1,9 +1,10 @@
 package simplify
 
 import (
-	"fmt"
+	"sort"
 
 	"github.com/animalat/Simplex-Algorithm/lp_parser/lexer"
+	"github.com/animalat/Simplex-Algorithm/lp_parser/linear"
 	"github.com/animalat/Simplex-Algorithm/lp_parser/parser"
 )
 
@@ -19,27 +20,73 @@ type isConstant struct {
 	isConstant bool
 }
 
+// DeclarationOrder maps each declared variable to its declaration index (the same index semantics.SemanticCheck gives it)
+func DeclarationOrder(p *parser.Program) map[string]int {
+	order := make(map[string]int, len(p.Decls))
+	for i, decl := range p.Decls {
+		if _, ok := order[decl.ID.Value]; !ok {
+			order[decl.ID.Value] = i
+		}
+	}
+
+	return order
+}
+
+// Params maps each parameter (e.g. "cost" or "demand[2]") to its value
+type Params map[string]float64
+
+// ParamValues folds the value of each parameter of p, in order (a parameter can use the ones before it).
+// Parameters must be constant and can't share a name with a variable.
+func ParamValues(p *parser.Program) (Params, error) {
+	declOrder := DeclarationOrder(p)
+	params := make(Params, len(p.Params))
+	for _, param := range p.Params {
+		if _, ok := params[param.ID.Value]; ok {
+			return nil, lexer.Errorf(param.ID.Span, "duplicate parameter: %v", param.ID.Value)
+		}
+		if _, ok := declOrder[param.ID.Value]; ok {
+			return nil, lexer.Errorf(param.ID.Span, "parameter %v has the same name as a variable", param.ID.Value)
+		}
+
+		value, err := exprIsConstant(param.Value, params)
+		if err != nil {
+			return nil, err
+		}
+		if !value.isConstant {
+			return nil, lexer.Errorf(param.Span, "parameter %v must be constant: %v", param.ID.Value, param.Value)
+		}
+		params[param.ID.Value] = value.value
+	}
+
+	return params, nil
+}
+
 func SimplifyProgram(p *parser.Program) error {
-	var err error
-	p.Objective.Expr, err = SimplifyExpr(p.Objective.Expr)
+	params, err := ParamValues(p)
 	if err != nil {
 		return err
 	}
-	p.Objective.Expr, _, err = CollectLikeTerms(p.Objective.Expr, &parser.NumberLiteral{Value: 0}, enableObjective, make(map[string]float64))
+
+	declOrder := DeclarationOrder(p)
+	p.Objective.Expr, err = SimplifyExpr(p.Objective.Expr, params)
+	if err != nil {
+		return err
+	}
+	p.Objective.Expr, _, err = CollectLikeTerms(p.Objective.Expr, &parser.NumberLiteral{Value: 0}, enableObjective, make(map[string]float64), declOrder)
 	if err != nil {
 		return err
 	}
 	for _, constraint := range p.Constraints {
-		constraint.Left, err = SimplifyExpr(constraint.Left)
+		constraint.Left, err = SimplifyExpr(constraint.Left, params)
 		if err != nil {
 			return err
 		}
-		constraint.Right, err = SimplifyExpr(constraint.Right)
+		constraint.Right, err = SimplifyExpr(constraint.Right, params)
 		if err != nil {
 			return err
 		}
 
-		constraint.Left, constraint.Right, err = CollectLikeTerms(constraint.Left, constraint.Right, disableObjective, make(map[string]float64))
+		constraint.Left, constraint.Right, err = CollectLikeTerms(constraint.Left, constraint.Right, disableObjective, make(map[string]float64), declOrder)
 		if err != nil {
 			return err
 		}
@@ -48,8 +95,9 @@ func SimplifyProgram(p *parser.Program) error {
 	return nil
 }
 
-func SimplifyExpr(expr parser.Expr) (parser.Expr, error) {
-	expr, err := DistributeFold(expr, defaultMultiplicative)
+// SimplifyExpr distributes and folds expr, replacing parameters (params may be nil) with their values
+func SimplifyExpr(expr parser.Expr, params Params) (parser.Expr, error) {
+	expr, err := DistributeFold(expr, defaultMultiplicative, params)
 	if err != nil {
 		return expr, err
 	}
@@ -71,26 +119,34 @@ func doOperation(a float64, b float64, tt lexer.TokenType) float64 {
 	return res
 }
 
-func exprIsConstant(expr parser.Expr) (isConstant, error) {
+// Whether e divides by a right side that folds to zero (which would give an infinite or NaN coefficient)
+func isDivisionByZero(e *parser.BinaryExpr, right isConstant) bool {
+	return e.Operator.Type == lexer.TokenDivide && right.isConstant && right.value == 0
+}
+
+func exprIsConstant(expr parser.Expr, params Params) (isConstant, error) {
 	switch e := expr.(type) {
 	case *parser.BinaryExpr:
-		leftIsConstant, err := exprIsConstant(e.Left)
+		leftIsConstant, err := exprIsConstant(e.Left, params)
 		if err != nil {
 			return isConstant{isConstant: false}, err
 		}
-		rightIsConstant, err := exprIsConstant(e.Right)
+		rightIsConstant, err := exprIsConstant(e.Right, params)
 		if err != nil {
 			return isConstant{isConstant: false}, err
 		}
 		if !leftIsConstant.isConstant || !rightIsConstant.isConstant {
 			return isConstant{isConstant: false}, nil
 		}
+		if isDivisionByZero(e, rightIsConstant) {
+			return isConstant{isConstant: false}, lexer.Errorf(e.Span, "division by zero: %v", e)
+		}
 
 		newConst := doOperation(leftIsConstant.value, rightIsConstant.value, e.Operator.Type)
 
 		return isConstant{value: newConst, isConstant: true}, nil
 	case *parser.UnaryExpr:
-		innerIsConstant, err := exprIsConstant(e.Expr)
+		innerIsConstant, err := exprIsConstant(e.Expr, params)
 		if err != nil {
 			return isConstant{isConstant: false}, err
 		}
@@ -100,7 +156,7 @@ func exprIsConstant(expr parser.Expr) (isConstant, error) {
 		} else if e.Operator.Type == lexer.TokenPlus {
 			multiplicative = defaultMultiplicative
 		} else {
-			return isConstant{isConstant: false}, fmt.Errorf("invalid UnaryExpr operator %v: %v", e.Operator.Value, e)
+			return isConstant{isConstant: false}, lexer.Errorf(e.Span, "invalid UnaryExpr operator %v: %v", e.Operator.Value, e)
 		}
 
 		if innerIsConstant.isConstant {
@@ -110,17 +166,22 @@ func exprIsConstant(expr parser.Expr) (isConstant, error) {
 		}
 	case *parser.NumberLiteral:
 		return isConstant{value: e.Value, isConstant: true}, nil
+	case *parser.Variable:
+		if value, ok := params[e.ID.Value]; ok {
+			return isConstant{value: value, isConstant: true}, nil
+		}
+		return isConstant{isConstant: false}, nil
 	default:
 		return isConstant{isConstant: false}, nil
 	}
 }
 
-func DistributeFold(expr parser.Expr, multiplicative float64) (parser.Expr, error) {
+func DistributeFold(expr parser.Expr, multiplicative float64, params Params) (parser.Expr, error) {
 	switch e := expr.(type) {
 	case *parser.BinaryExpr:
 		switch e.Operator.Type {
 		case lexer.TokenPlus, lexer.TokenMinus:
-			newLeft, err := DistributeFold(e.Left, multiplicative)
+			newLeft, err := DistributeFold(e.Left, multiplicative, params)
 			if err != nil {
 				return nil, err
 			}
@@ -132,75 +193,91 @@ func DistributeFold(expr parser.Expr, multiplicative float64) (parser.Expr, erro
 				isNegativeLHS = defaultMultiplicative
 			}
 
-			newRight, err := DistributeFold(e.Right, isNegativeLHS*multiplicative)
+			newRight, err := DistributeFold(e.Right, isNegativeLHS*multiplicative, params)
 			if err != nil {
 				return nil, err
 			}
 
+			// the sign of a subtraction has been folded into newRight, so it becomes an addition
 			return &parser.BinaryExpr{
 				Left:     newLeft,
-				Operator: e.Operator,
+				Operator: lexer.Token{Type: lexer.TokenPlus, Value: "+", Line: e.Operator.Line, Span: e.Operator.Span},
 				Right:    newRight,
 				Line:     e.Line,
+				Span:     e.Span,
 			}, nil
 		case lexer.TokenAsterisk, lexer.TokenDivide:
-			leftIsConstant, err := exprIsConstant(e.Left)
+			leftIsConstant, err := exprIsConstant(e.Left, params)
 			if err != nil {
 				return nil, err
 			}
-			rightIsConstant, err := exprIsConstant(e.Right)
+			rightIsConstant, err := exprIsConstant(e.Right, params)
 			if err != nil {
 				return nil, err
 			}
+			if isDivisionByZero(e, rightIsConstant) {
+				return nil, lexer.Errorf(e.Span, "division by zero: %v", e)
+			}
 			if leftIsConstant.isConstant && rightIsConstant.isConstant {
-				return &parser.NumberLiteral{Value: doOperation(leftIsConstant.value, rightIsConstant.value, e.Operator.Type), Line: e.Line}, nil
+				return &parser.NumberLiteral{Value: multiplicative * doOperation(leftIsConstant.value, rightIsConstant.value, e.Operator.Type), Line: e.Line, Span: e.Span}, nil
 			} else if !leftIsConstant.isConstant && !rightIsConstant.isConstant {
-				return nil, fmt.Errorf("nonlinear expression (both sides): %v", e)
+				return nil, lexer.Errorf(e.Span, "nonlinear expression (both sides): %v", e)
 			} else if !leftIsConstant.isConstant && rightIsConstant.isConstant {
-				return DistributeFold(e.Left, doOperation(multiplicative, rightIsConstant.value, e.Operator.Type))
+				return DistributeFold(e.Left, doOperation(multiplicative, rightIsConstant.value, e.Operator.Type), params)
 			} else {
 				// leftIsConstant.isConstant && !rightIsConstant.isConstant
 				if e.Operator.Type == lexer.TokenAsterisk {
-					return DistributeFold(e.Right, doOperation(leftIsConstant.value, multiplicative, e.Operator.Type))
+					return DistributeFold(e.Right, doOperation(leftIsConstant.value, multiplicative, e.Operator.Type), params)
 				} else {
 					// TokenDivide
-					return nil, fmt.Errorf("nonlinear expression (RHS rational): %v", e)
+					return nil, lexer.Errorf(e.Span, "nonlinear expression (RHS rational): %v", e)
 				}
 			}
 		default:
-			return nil, fmt.Errorf("invalid operator \"%v\": %v", e.Operator.Value, e)
+			return nil, lexer.Errorf(e.Operator.Span, "invalid operator \"%v\": %v", e.Operator.Value, e)
 		}
 	case *parser.UnaryExpr:
 		if e.Operator.Type == lexer.TokenMinus {
 			multiplicative *= negativeMultiplicative
 		}
-		return DistributeFold(e.Expr, multiplicative)
+		return DistributeFold(e.Expr, multiplicative, params)
 	case *parser.Variable:
+		if value, ok := params[e.ID.Value]; ok {
+			return &parser.NumberLiteral{Value: value * multiplicative, Line: e.ID.Line, Span: e.ID.Span}, nil
+		}
 		return &parser.BinaryExpr{
-			Left:     &parser.NumberLiteral{Value: multiplicative, Line: e.ID.Line},
-			Operator: lexer.Token{Type: lexer.TokenAsterisk, Value: "*", Line: e.ID.Line},
+			Left:     &parser.NumberLiteral{Value: multiplicative, Line: e.ID.Line, Span: e.ID.Span},
+			Operator: lexer.Token{Type: lexer.TokenAsterisk, Value: "*", Line: e.ID.Line, Span: e.ID.Span},
 			Right:    e,
 			Line:     e.ID.Line,
+			Span:     e.ID.Span,
 		}, nil
 	case *parser.NumberLiteral:
 		e.Value *= multiplicative
 		return e, nil
 	default:
-		return nil, fmt.Errorf("invalid Expr type found: %T", e)
+		return nil, lexer.Errorf(parser.SpanOf(e), "invalid Expr type found: %T", e)
 	}
 }
 
-func changeMultiplicative(expr parser.Expr, isLeft bool, isObjective bool, multiplicativeTable map[string]float64) error {
+// Remembers where key first appeared in the source (so rebuilt terms keep a position)
+func recordSpan(spans map[string]lexer.Span, key string, span lexer.Span) {
+	if _, ok := spans[key]; !ok {
+		spans[key] = span
+	}
+}
+
+func changeMultiplicative(expr parser.Expr, isLeft bool, isObjective bool, multiplicativeTable map[string]float64, spans map[string]lexer.Span) error {
 	switch e := expr.(type) {
 	case *parser.BinaryExpr:
 		if e.Operator.Type == lexer.TokenAsterisk {
 			nl, ok := e.Left.(*parser.NumberLiteral)
 			if !ok {
-				return fmt.Errorf("invalid LHS Expr type %T in %v", e.Left, e)
+				return lexer.Errorf(e.Span, "invalid LHS Expr type %T in %v", e.Left, e)
 			}
 			v, ok := e.Right.(*parser.Variable)
 			if !ok {
-				return fmt.Errorf("invalid RHS Expr type %T in %v", e.Right, e)
+				return lexer.Errorf(e.Span, "invalid RHS Expr type %T in %v", e.Right, e)
 			}
 			toAdd := nl.Value
 			if !isLeft && !isObjective {
@@ -211,8 +288,9 @@ func changeMultiplicative(expr parser.Expr, isLeft bool, isObjective bool, multi
 			} else {
 				multiplicativeTable[v.ID.Value] += toAdd
 			}
+			recordSpan(spans, v.ID.Value, v.ID.Span)
 		} else {
-			return fmt.Errorf("invalid operator %v in BinaryExpr: %v", e.Operator.Value, e)
+			return lexer.Errorf(e.Operator.Span, "invalid operator %v in BinaryExpr: %v", e.Operator.Value, e)
 		}
 	case *parser.NumberLiteral:
 		toAdd := e.Value
@@ -224,6 +302,7 @@ func changeMultiplicative(expr parser.Expr, isLeft bool, isObjective bool, multi
 		} else {
 			multiplicativeTable[constantKey] += toAdd
 		}
+		recordSpan(spans, constantKey, e.Span)
 	case *parser.Variable:
 		toAdd := defaultMultiplicative + 0.0
 		if !isLeft && !isObjective {
@@ -234,60 +313,93 @@ func changeMultiplicative(expr parser.Expr, isLeft bool, isObjective bool, multi
 		} else {
 			multiplicativeTable[e.ID.Value] += toAdd
 		}
+		recordSpan(spans, e.ID.Value, e.ID.Span)
 	default:
-		return fmt.Errorf("invalid expr type: %T", e)
+		return lexer.Errorf(parser.SpanOf(e), "invalid expr type: %T", e)
 	}
 
 	return nil
 }
 
 // changes multiplicativeTable
-func findMultiplicatives(expr parser.Expr, isLeft bool, isObjective bool, multiplicativeTable map[string]float64) error {
+func findMultiplicatives(expr parser.Expr, isLeft bool, isObjective bool, multiplicativeTable map[string]float64, spans map[string]lexer.Span) error {
 	switch e := expr.(type) {
 	case *parser.BinaryExpr:
 		if e.Operator.Type == lexer.TokenPlus {
-			if err := changeMultiplicative(e.Right, isLeft, isObjective, multiplicativeTable); err != nil {
+			// either side can be a sum, e.g. x1 + (x2 + x3)
+			if err := findMultiplicatives(e.Left, isLeft, isObjective, multiplicativeTable, spans); err != nil {
 				return err
 			}
-			if err := findMultiplicatives(e.Left, isLeft, isObjective, multiplicativeTable); err != nil {
+			if err := findMultiplicatives(e.Right, isLeft, isObjective, multiplicativeTable, spans); err != nil {
 				return err
 			}
 		} else if e.Operator.Type == lexer.TokenAsterisk {
-			if err := changeMultiplicative(e, isLeft, isObjective, multiplicativeTable); err != nil {
+			if err := changeMultiplicative(e, isLeft, isObjective, multiplicativeTable, spans); err != nil {
 				return err
 			}
 		} else {
-			return fmt.Errorf("invalid operator %v found in Expr: %v", e.Operator.Value, e)
+			return lexer.Errorf(e.Operator.Span, "invalid operator %v found in Expr: %v", e.Operator.Value, e)
 		}
 	case *parser.NumberLiteral, *parser.Variable:
-		if err := changeMultiplicative(e, isLeft, isObjective, multiplicativeTable); err != nil {
+		if err := changeMultiplicative(e, isLeft, isObjective, multiplicativeTable, spans); err != nil {
 			return err
 		}
 	default:
-		return fmt.Errorf("invalid expr type: %T", e)
+		return lexer.Errorf(parser.SpanOf(e), "invalid expr type: %T", e)
 	}
 
 	return nil
 }
 
-func CollectLikeTerms(lhs parser.Expr, rhs parser.Expr, isObjective bool, multiplicativeTable map[string]float64) (parser.Expr, parser.Expr, error) {
-	if err := findMultiplicatives(lhs, useLeft, isObjective, multiplicativeTable); err != nil {
+// Orders the variables in multiplicativeTable by declaration order (undeclared variables go last, by name)
+func sortedVariables(multiplicativeTable map[string]float64, declOrder map[string]int) []string {
+	keys := make([]string, 0, len(multiplicativeTable))
+	for key := range multiplicativeTable {
+		if key != constantKey {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		iOrder, iDeclared := declOrder[keys[i]]
+		jOrder, jDeclared := declOrder[keys[j]]
+		if iDeclared != jDeclared {
+			return iDeclared
+		}
+		if iDeclared && iOrder != jOrder {
+			return iOrder < jOrder
+		}
+		return keys[i] < keys[j]
+	})
+
+	return keys
+}
+
+// Rebuilds lhs as a sum of (coefficient * variable) terms in declaration order (see DeclarationOrder),
+// with all constants moved to rhs (or added on at the end for the objective)
+func CollectLikeTerms(lhs parser.Expr, rhs parser.Expr, isObjective bool, multiplicativeTable map[string]float64, declOrder map[string]int) (parser.Expr, parser.Expr, error) {
+	spans := make(map[string]lexer.Span)
+	if err := findMultiplicatives(lhs, useLeft, isObjective, multiplicativeTable, spans); err != nil {
 		return nil, nil, err
 	}
-	if err := findMultiplicatives(rhs, !useLeft, isObjective, multiplicativeTable); err != nil {
+	if err := findMultiplicatives(rhs, !useLeft, isObjective, multiplicativeTable, spans); err != nil {
 		return nil, nil, err
 	}
 
-	firstExpr := true
-	for key, val := range multiplicativeTable {
-		if key == constantKey {
-			continue
-		}
+	// the rebuilt sides keep the spans of the sides they came from
+	lhsSpan := parser.SpanOf(lhs)
+	rhsSpan := parser.SpanOf(rhs)
 
+	firstExpr := true
+	for _, key := range sortedVariables(multiplicativeTable, declOrder) {
+		val := multiplicativeTable[key]
+		span := spans[key]
 		newTerm := &parser.BinaryExpr{
-			Left:     &parser.NumberLiteral{Value: val},
-			Operator: lexer.Token{Type: lexer.TokenAsterisk, Value: "*"},
-			Right:    &parser.Variable{ID: lexer.Token{Type: lexer.TokenId, Value: key}},
+			Left:     &parser.NumberLiteral{Value: val, Line: span.Start.Line, Span: span},
+			Operator: lexer.Token{Type: lexer.TokenAsterisk, Value: "*", Line: span.Start.Line, Span: span},
+			Right:    &parser.Variable{ID: lexer.Token{Type: lexer.TokenId, Value: key, Line: span.Start.Line, Span: span}},
+			Line:     span.Start.Line,
+			Span:     span,
 		}
 		if firstExpr {
 			lhs = newTerm
@@ -295,25 +407,96 @@ func CollectLikeTerms(lhs parser.Expr, rhs parser.Expr, isObjective bool, multip
 		} else {
 			lhs = &parser.BinaryExpr{
 				Left:     lhs,
-				Operator: lexer.Token{Type: lexer.TokenPlus, Value: "+"},
+				Operator: lexer.Token{Type: lexer.TokenPlus, Value: "+", Line: lhsSpan.Start.Line, Span: lhsSpan},
 				Right:    newTerm,
+				Line:     lhsSpan.Start.Line,
+				Span:     lhsSpan,
 			}
 		}
 	}
 
 	if val, ok := multiplicativeTable[constantKey]; ok {
-		rhs = &parser.NumberLiteral{Value: val}
+		rhs = &parser.NumberLiteral{Value: val, Line: rhsSpan.Start.Line, Span: rhsSpan}
 	} else {
-		rhs = &parser.NumberLiteral{Value: 0}
+		rhs = &parser.NumberLiteral{Value: 0, Line: rhsSpan.Start.Line, Span: rhsSpan}
 	}
 
 	if isObjective {
 		lhs = &parser.BinaryExpr{
 			Left:     lhs,
-			Operator: lexer.Token{Type: lexer.TokenPlus, Value: "+"},
+			Operator: lexer.Token{Type: lexer.TokenPlus, Value: "+", Line: lhsSpan.Start.Line, Span: lhsSpan},
 			Right:    rhs,
+			Line:     lhsSpan.Start.Line,
+			Span:     lhsSpan,
 		}
 	}
 
 	return lhs, rhs, nil
 }
+
+// Turns lhs and rhs (for the objective, rhs is nil) into a LinearExpr.
+// For constraints, variables are moved to the left and constants to the right (the returned Constant).
+func linearize(lhs parser.Expr, rhs parser.Expr, isObjective bool) (linear.LinearExpr, error) {
+	multiplicativeTable := make(map[string]float64)
+	spans := make(map[string]lexer.Span)
+	if err := findMultiplicatives(lhs, useLeft, isObjective, multiplicativeTable, spans); err != nil {
+		return linear.LinearExpr{}, err
+	}
+	if rhs != nil {
+		if err := findMultiplicatives(rhs, !useLeft, isObjective, multiplicativeTable, spans); err != nil {
+			return linear.LinearExpr{}, err
+		}
+	}
+
+	expr := linear.NewLinearExpr()
+	for key, val := range multiplicativeTable {
+		if key == constantKey {
+			expr.Constant = val
+			continue
+		}
+		expr.Add(key, val, spans[key])
+	}
+
+	return expr, nil
+}
+
+// LinearizeProgram converts a simplified program (see SimplifyProgram) into a LinearProgram,
+// taking each variable's type from varTypes (see semantics.SemanticCheck)
+func LinearizeProgram(p *parser.Program, varTypes map[string]parser.VarType) (*linear.LinearProgram, error) {
+	lp := &linear.LinearProgram{}
+	for _, decl := range p.Decls {
+		lp.Variables = append(lp.Variables, linear.Variable{Name: decl.ID.Value, NonNegative: decl.NonNegative, Type: varTypes[decl.ID.Value], Span: decl.ID.Span})
+	}
+
+	objective, err := linearize(p.Objective.Expr, nil, enableObjective)
+	if err != nil {
+		return nil, err
+	}
+	lp.Objective = linear.Objective{IsMax: p.Objective.IsMax, Expr: objective, Span: p.Objective.Span}
+
+	for _, constraint := range p.Constraints {
+		switch constraint.Operator.Type {
+		case lexer.TokenLessEqual, lexer.TokenEqual, lexer.TokenGreaterEqual:
+		default:
+			return nil, lexer.Errorf(constraint.Operator.Span, "invalid comparison operator %v", constraint.Operator.Value)
+		}
+
+		left, err := linearize(constraint.Left, constraint.Right, disableObjective)
+		if err != nil {
+			return nil, err
+		}
+
+		right := left.Constant
+		left.Constant = 0
+		lp.Constraints = append(lp.Constraints, linear.Constraint{
+			Name:     constraint.Name,
+			Left:     left,
+			Operator: constraint.Operator.Type,
+			Right:    right,
+			Span:     constraint.Span,
+			IsRange:  constraint.IsRange,
+		})
+	}
+
+	return lp, nil
+}
@@ -1,6 +1,7 @@
 package simplify
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -22,16 +23,16 @@ func TestSimplify_Distribute(t *testing.T) {
 		t.Fatalf("Parsing failed: %v", err)
 	}
 
-	if prog.Objective.Expr, err = SimplifyExpr(prog.Objective.Expr); err != nil {
+	if prog.Objective.Expr, err = SimplifyExpr(prog.Objective.Expr, nil); err != nil {
 		t.Fatalf("Simplification failed on objective: %v", err)
 	}
 
 	for i, constraint := range prog.Constraints {
-		if constraint.Left, err = SimplifyExpr(constraint.Left); err != nil {
+		if constraint.Left, err = SimplifyExpr(constraint.Left, nil); err != nil {
 			t.Fatalf("Simplification failed on constraint %d: %v", i, err)
 		}
 
-		if constraint.Right, err = SimplifyExpr(constraint.Right); err != nil {
+		if constraint.Right, err = SimplifyExpr(constraint.Right, nil); err != nil {
 			t.Fatalf("Simplification failed on constraint %d: %v", i, err)
 		}
 	}
@@ -71,6 +72,37 @@ func TestSimplify_Distribute(t *testing.T) {
 	}
 }
 
+func TestSimplify_NegatedConstantProduct(t *testing.T) {
+	// the sign in front of a product of constants is kept, e.g. -(8 / 2) is -4
+	input := "let x1; max x1 - 2 * 3; s.t. 2 * x1 <= -(8 / 2);"
+	tokens, err := lexer.Tokenize(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Tokenizing failed: %v", err)
+	}
+
+	parser := &parser.Parser{Tokens: tokens}
+	prog, err := parser.ParseProgram()
+	if err != nil {
+		t.Fatalf("Parsing failed: %v", err)
+	}
+
+	objective, err := SimplifyExpr(prog.Objective.Expr, nil)
+	if err != nil {
+		t.Fatalf("Simplification failed on objective: %v", err)
+	}
+	if got, want := fmt.Sprint(objective), "((1 * x1) + -6)"; got != want {
+		t.Errorf("Objective simplify mismatch:\nGot:  %v\nWant: %v", got, want)
+	}
+
+	right, err := SimplifyExpr(prog.Constraints[0].Right, nil)
+	if err != nil {
+		t.Fatalf("Simplification failed on constraint: %v", err)
+	}
+	if got, want := fmt.Sprint(right), "-4"; got != want {
+		t.Errorf("Constraint right side mismatch:\nGot:  %v\nWant: %v", got, want)
+	}
+}
+
 func TestSimplify_CollectLikeTerms(t *testing.T) {
 	input := "let x1; let x2; max 3 * x1 + x2 + 10 + x1 + 4 * x2 + 5 + 6 + 3; s.t. x1 + x2 + 4 * x1 + 6 * x2 + 4 + 5 <= 3 + x1 + x2 + 3 * x1 + 4 + 3 * x2 + 5;"
 	tokens, err := lexer.Tokenize(strings.NewReader(input))
@@ -85,12 +117,13 @@ func TestSimplify_CollectLikeTerms(t *testing.T) {
 	}
 
 	beforeObjective := prog.Objective.Expr
-	prog.Objective.Expr, _, err = CollectLikeTerms(prog.Objective.Expr, &parser.NumberLiteral{Value: 0}, enableObjective, make(map[string]float64))
+	declOrder := DeclarationOrder(prog)
+	prog.Objective.Expr, _, err = CollectLikeTerms(prog.Objective.Expr, &parser.NumberLiteral{Value: 0}, enableObjective, make(map[string]float64), declOrder)
 	if err != nil {
 		t.Fatalf("failed to collect like terms on objective: %v", err)
 	}
 	for i, constraint := range prog.Constraints {
-		constraint.Left, constraint.Right, err = CollectLikeTerms(constraint.Left, constraint.Right, disableObjective, make(map[string]float64))
+		constraint.Left, constraint.Right, err = CollectLikeTerms(constraint.Left, constraint.Right, disableObjective, make(map[string]float64), declOrder)
 		if err != nil {
 			t.Fatalf("failed to collect like terms on constraint %d: %v", i, err)
 		}
@@ -104,16 +137,298 @@ func TestSimplify_CollectLikeTerms(t *testing.T) {
 		fmt.Printf("before: %v, after: %v", beforeObjective, prog.Objective.Expr)
 	}
 
-	wantA := "((3 * x2) + (1 * x1))"
-	wantB := "((1 * x1) + (3 * x2))"
+	want := "((1 * x1) + (3 * x2))"
 	got := fmt.Sprint(prog.Constraints[0].Left)
-	if got != wantA && got != wantB {
-		t.Errorf("Constraint left side mismatch:\nGot:  %v\nWant: %v", got, wantA)
+	if got != want {
+		t.Errorf("Constraint left side mismatch:\nGot:  %v\nWant: %v", got, want)
 	}
 
-	want := "3"
+	want = "3"
 	got = fmt.Sprint(prog.Constraints[0].Right)
 	if got != want {
 		t.Errorf("Constraint right side mismatch:\nGot:  %v\nWant: %v", got, want)
 	}
 }
+
+func TestSimplify_ErrorSpan(t *testing.T) {
+	tokens, err := lexer.Tokenize(strings.NewReader("let x1; let x2;\nmax x1;\ns.t. 3 + x1 * x2 <= 3;"))
+	if err != nil {
+		t.Fatalf("Tokenizing failed: %v", err)
+	}
+
+	p := &parser.Parser{Tokens: tokens}
+	prog, err := p.ParseProgram()
+	if err != nil {
+		t.Fatalf("Parsing failed: %v", err)
+	}
+
+	err = SimplifyProgram(prog)
+	var spanErr *lexer.SpanError
+	if !errors.As(err, &spanErr) {
+		t.Fatalf("expected SpanError, got %v", err)
+	}
+	if spanErr.Span.Start.Line != 3 || spanErr.Span.Start.Column != 10 || spanErr.Span.End.Column != 17 {
+		t.Errorf("error at %+v; want line 3, columns 10 to 17", spanErr.Span)
+	}
+}
+
+func TestSimplify_DivisionByZero(t *testing.T) {
+	tests := []struct {
+		input string
+		// source the error should be at
+		span string
+	}{
+		{"let x; max x / 0; s.t. x <= 1;", "x / 0"},
+		{"param z = 0; let x; max x; s.t. 2 * x / z <= 1;", "2 * x / z"},
+		{"param z = 0; let x; max x + 3 / z; s.t. x <= 1;", "3 / z"},
+		{"param z = 1; param w = z - 1; let x; max x; s.t. x <= 1 / w;", "1 / w"},
+	}
+
+	for _, tc := range tests {
+		tokens, err := lexer.Tokenize(strings.NewReader(tc.input))
+		if err != nil {
+			t.Fatalf("Tokenizing failed: %v", err)
+		}
+		prog, err := (&parser.Parser{Tokens: tokens}).ParseProgram()
+		if err != nil {
+			t.Fatalf("Parsing failed: %v", err)
+		}
+
+		var spanErr *lexer.SpanError
+		if err := SimplifyProgram(prog); !errors.As(err, &spanErr) {
+			t.Errorf("expected SpanError for %q, got %v", tc.input, err)
+			continue
+		}
+		if got := tc.input[spanErr.Span.Start.Offset:spanErr.Span.End.Offset]; got != tc.span {
+			t.Errorf("error span mismatch for %q: got %q, want %q", tc.input, got, tc.span)
+		}
+	}
+}
+
+func TestSimplify_KeepsVariableSpans(t *testing.T) {
+	tokens, err := lexer.Tokenize(strings.NewReader("let x1;\nmax x1;\ns.t. 2 * (x1 + 1) <= 3;"))
+	if err != nil {
+		t.Fatalf("Tokenizing failed: %v", err)
+	}
+
+	p := &parser.Parser{Tokens: tokens}
+	prog, err := p.ParseProgram()
+	if err != nil {
+		t.Fatalf("Parsing failed: %v", err)
+	}
+
+	if err = SimplifyProgram(prog); err != nil {
+		t.Fatalf("Simplification failed: %v", err)
+	}
+
+	term, ok := prog.Constraints[0].Left.(*parser.BinaryExpr)
+	if !ok {
+		t.Fatalf("expected BinaryExpr, got %T", prog.Constraints[0].Left)
+	}
+	v, ok := term.Right.(*parser.Variable)
+	if !ok {
+		t.Fatalf("expected Variable, got %T", term.Right)
+	}
+	if v.ID.Span.Start != (lexer.Position{Line: 3, Column: 11, Offset: 26}) {
+		t.Errorf("variable span %+v; want line 3, column 11", v.ID.Span)
+	}
+}
+
+func TestSimplify_CanonicalOrder(t *testing.T) {
+	input := "let y; let a; max a - 2 * y + 3; s.t. a - y + y - a + y <= 4 - a; b + a - (y - 2) >= 1;"
+
+	for run := 0; run < 20; run++ {
+		tokens, err := lexer.Tokenize(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("Tokenizing failed: %v", err)
+		}
+
+		p := &parser.Parser{Tokens: tokens}
+		prog, err := p.ParseProgram()
+		if err != nil {
+			t.Fatalf("Parsing failed: %v", err)
+		}
+
+		if err = SimplifyProgram(prog); err != nil {
+			t.Fatalf("Simplification failed: %v", err)
+		}
+
+		got := fmt.Sprint(prog.Objective.Expr)
+		want := "(((-2 * y) + (1 * a)) + 3)"
+		if got != want {
+			t.Fatalf("Objective mismatch:\nGot:  %v\nWant: %v", got, want)
+		}
+
+		got = fmt.Sprintf("%v <= %v", prog.Constraints[0].Left, prog.Constraints[0].Right)
+		want = "((1 * y) + (1 * a)) <= 4"
+		if got != want {
+			t.Fatalf("Constraint 0 mismatch:\nGot:  %v\nWant: %v", got, want)
+		}
+
+		// undeclared variables go last (semantics reports them later)
+		got = fmt.Sprintf("%v >= %v", prog.Constraints[1].Left, prog.Constraints[1].Right)
+		want = "(((-1 * y) + (1 * a)) + (1 * b)) >= -1"
+		if got != want {
+			t.Fatalf("Constraint 1 mismatch:\nGot:  %v\nWant: %v", got, want)
+		}
+	}
+}
+
+func TestSimplify_LinearizeProgram(t *testing.T) {
+	input := "let x1 >= 0; let x2; min 2 * (x1 - x2) + 5; s.t. cap: x1 + 3 <= 2 * x2 + 10; x2 - x1 = 1;"
+	tokens, err := lexer.Tokenize(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Tokenizing failed: %v", err)
+	}
+
+	p := &parser.Parser{Tokens: tokens}
+	prog, err := p.ParseProgram()
+	if err != nil {
+		t.Fatalf("Parsing failed: %v", err)
+	}
+
+	if err = SimplifyProgram(prog); err != nil {
+		t.Fatalf("Simplification failed: %v", err)
+	}
+
+	lp, err := LinearizeProgram(prog, map[string]parser.VarType{"x1": parser.VarContinuous, "x2": parser.VarInteger})
+	if err != nil {
+		t.Fatalf("Linearizing failed: %v", err)
+	}
+
+	if len(lp.Variables) != 2 || lp.Variables[0].Name != "x1" || !lp.Variables[0].NonNegative || lp.Variables[1].NonNegative ||
+		lp.Variables[0].Type != parser.VarContinuous || lp.Variables[1].Type != parser.VarInteger {
+		t.Fatalf("variables mismatch: %+v", lp.Variables)
+	}
+
+	idTable := lp.IdTable()
+	if lp.Objective.IsMax {
+		t.Errorf("expected MIN objective")
+	}
+	if got := fmt.Sprint(lp.Objective.Expr.Dense(idTable), lp.Objective.Expr.Constant); got != "[2 -2] 5" {
+		t.Errorf("objective mismatch: got %v", got)
+	}
+
+	if len(lp.Constraints) != 2 {
+		t.Fatalf("Expected 2 constraints, got %d", len(lp.Constraints))
+	}
+
+	want := []string{"cap [1 -2] LEQ 7", " [-1 1] EQ 1"}
+	for i, constraint := range lp.Constraints {
+		got := fmt.Sprint(constraint.Name, " ", constraint.Left.Dense(idTable), " ", constraint.Operator, " ", constraint.Right)
+		if got != want[i] {
+			t.Errorf("constraint %d mismatch:\nGot:  %v\nWant: %v", i, got, want[i])
+		}
+		if constraint.Left.Constant != 0 {
+			t.Errorf("constraint %d: expected constant moved to the right, got %v", i, constraint.Left.Constant)
+		}
+	}
+
+	if span := lp.Constraints[0].Left.Spans["x2"]; span.Start.Column != 69 {
+		t.Errorf("x2 span %+v; want column 69", span)
+	}
+}
+
+func TestSimplify_Params(t *testing.T) {
+	input := "param cost = 12.5; param limit = 2 * cost - 5; let x >= 0; let y; min cost * x - y / cost; s.t. x - limit * 2 * y <= -limit;"
+	tokens, err := lexer.Tokenize(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Tokenizing failed: %v", err)
+	}
+
+	p := &parser.Parser{Tokens: tokens}
+	prog, err := p.ParseProgram()
+	if err != nil {
+		t.Fatalf("Parsing failed: %v", err)
+	}
+
+	params, err := ParamValues(prog)
+	if err != nil {
+		t.Fatalf("ParamValues failed: %v", err)
+	}
+	if params["cost"] != 12.5 || params["limit"] != 20 {
+		t.Errorf("param values mismatch: %v", params)
+	}
+
+	if err = SimplifyProgram(prog); err != nil {
+		t.Fatalf("Simplification failed: %v", err)
+	}
+
+	got := fmt.Sprint(prog.Objective.Expr)
+	want := "(((12.5 * x) + (-0.08 * y)) + 0)"
+	if got != want {
+		t.Errorf("objective mismatch:\nGot:  %v\nWant: %v", got, want)
+	}
+
+	got = fmt.Sprintf("%v <= %v", prog.Constraints[0].Left, prog.Constraints[0].Right)
+	want = "((1 * x) + (-40 * y)) <= -20"
+	if got != want {
+		t.Errorf("constraint mismatch:\nGot:  %v\nWant: %v", got, want)
+	}
+
+	for _, input := range []string{
+		"param x = 1; let x; min x; s.t. x >= 0;",
+		"param c = y; let y; min y; s.t. y >= 0;",
+	} {
+		tokens, err := lexer.Tokenize(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("Tokenizing failed: %v", err)
+		}
+		prog, err := (&parser.Parser{Tokens: tokens}).ParseProgram()
+		if err != nil {
+			t.Fatalf("Parsing failed: %v", err)
+		}
+
+		var spanErr *lexer.SpanError
+		if err := SimplifyProgram(prog); !errors.As(err, &spanErr) {
+			t.Errorf("expected SpanError for %q, got %v", input, err)
+		}
+	}
+}
+
+func TestSimplify_ExactLinearizeProgram(t *testing.T) {
+	input := "param half = 1 / 2; let x1; let x2; max x1 / 3 + x1 / 3 + x1 / 3 + half * x2 + 0.1; s.t. 0.1 * x1 + 0.2 * x1 - x2 <= 0.3 - x2 / 7;"
+	tokens, err := lexer.Tokenize(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Tokenizing failed: %v", err)
+	}
+	prog, err := parser.ConstructParser(tokens).ParseProgram()
+	if err != nil {
+		t.Fatalf("Parsing failed: %v", err)
+	}
+
+	objective, constraints, err := ExactLinearizeProgram(prog)
+	if err != nil {
+		t.Fatalf("ExactLinearizeProgram failed: %v", err)
+	}
+
+	checks := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"objective x1", objective.Coefficients["x1"].RatString(), "1"},
+		{"objective x2", objective.Coefficients["x2"].RatString(), "1/2"},
+		{"objective constant", objective.Constant.RatString(), "1/10"},
+		{"constraint x1", constraints[0].Coefficients["x1"].RatString(), "3/10"},
+		{"constraint x2", constraints[0].Coefficients["x2"].RatString(), "-6/7"},
+		{"constraint constant", constraints[0].Constant.RatString(), "-3/10"},
+	}
+	for _, check := range checks {
+		if check.got != check.want {
+			t.Errorf("%s: got %s, want %s", check.name, check.got, check.want)
+		}
+	}
+
+	tokens, err = lexer.Tokenize(strings.NewReader("let x1; max x1 / (1 - 1); s.t. x1 <= 1;"))
+	if err != nil {
+		t.Fatalf("Tokenizing failed: %v", err)
+	}
+	prog, err = parser.ConstructParser(tokens).ParseProgram()
+	if err != nil {
+		t.Fatalf("Parsing failed: %v", err)
+	}
+	if _, _, err := ExactLinearizeProgram(prog); err == nil {
+		t.Errorf("expected an error dividing by zero")
+	}
+}